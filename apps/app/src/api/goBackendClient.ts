@@ -35,9 +35,18 @@ export interface ValidateCodeRequest {
   code: string;
 }
 
+export interface CodeDiagnostic {
+  line: number;
+  column: number;
+  severity: 'error' | 'warning';
+  rule: string;
+  message: string;
+}
+
 export interface ValidateCodeResponse {
   valid: boolean;
   error?: string;
+  diagnostics?: CodeDiagnostic[];
 }
 
 export class GoBackendClient {
@@ -98,7 +107,7 @@ export class GoBackendClient {
   /**
    * Validate Go code syntax
    */
-  async validateCode(code: string): Promise<{ valid: boolean; error?: string }> {
+  async validateCode(code: string): Promise<ValidateCodeResponse> {
     try {
       const response = await fetch(`${this.baseURL}/api/v1/validate-code`, {
         method: 'POST',
@@ -2,12 +2,20 @@ package analysis
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/vyx/go-screener/pkg/indicators"
 	"github.com/vyx/go-screener/pkg/types"
 )
 
+// rawFilter encodes a trader filter the way it is stored
+func rawFilter(filter types.TraderFilter) json.RawMessage {
+	raw, _ := json.Marshal(filter)
+	return raw
+}
+
 // TestNewEngine tests engine creation
 func TestNewEngine(t *testing.T) {
 	config := DefaultConfig()
@@ -102,7 +110,7 @@ func TestCalculatorWithRealData(t *testing.T) {
 
 	// Create trader with RSI indicator
 	trader := &types.Trader{
-		Filter: types.TraderFilter{
+		Filter: rawFilter(types.TraderFilter{
 			Indicators: []types.IndicatorConfig{
 				{
 					Name: "RSI",
@@ -111,7 +119,7 @@ func TestCalculatorWithRealData(t *testing.T) {
 					},
 				},
 			},
-		},
+		}),
 	}
 
 	req := &AnalysisRequest{
@@ -142,13 +150,55 @@ func TestCalculatorWithRealData(t *testing.T) {
 }
 
 // TestPrompterFormatting tests prompt building
+// streamSource serves a fixed RSI stream value for any period
+type streamSource struct{ openTime int64 }
+
+func (s streamSource) Indicator(interval, name string, params ...float64) ([]float64, int64, bool) {
+	if name != indicators.StreamRSI {
+		return nil, 0, false
+	}
+	return []float64{55}, s.openTime, true
+}
+
+func TestCalculatorStreamedPeriods(t *testing.T) {
+	calculator := NewCalculator(100)
+
+	calculate := func(period float64) interface{} {
+		req := &AnalysisRequest{
+			Trader: &types.Trader{
+				Filter: rawFilter(types.TraderFilter{
+					Indicators: []types.IndicatorConfig{{Name: "RSI", Params: map[string]interface{}{"period": period}}},
+				}),
+			},
+			Interval: "5m",
+			MarketData: &types.MarketData{
+				Klines:     map[string][]types.Kline{"5m": {{OpenTime: 0, Close: 100}, {OpenTime: 60000, Close: 101}}},
+				Indicators: streamSource{openTime: 60000},
+			},
+		}
+		result, err := calculator.CalculateIndicators(req)
+		if err != nil {
+			t.Fatalf("Failed to calculate indicators: %v", err)
+		}
+		return result["RSI"]
+	}
+
+	rsi, ok := calculate(14).(map[string]interface{})
+	if !ok || rsi["source"] != "stream" || rsi["period"] != 14 || rsi["value"] != 55.0 {
+		t.Errorf("RSI = %+v, want the streamed value with period 14", rsi)
+	}
+	if rsi := calculate(14.5); rsi != nil {
+		t.Errorf("expected a fractional period to be rejected, got %+v", rsi)
+	}
+}
+
 func TestPrompterFormatting(t *testing.T) {
 	prompter := NewPrompter()
 
 	trader := &types.Trader{
-		Filter: types.TraderFilter{
+		Filter: rawFilter(types.TraderFilter{
 			Description: []string{"Buy when RSI < 30"},
-		},
+		}),
 	}
 
 	req := &AnalysisRequest{
@@ -205,6 +255,43 @@ func TestPrompterFormatting(t *testing.T) {
 	t.Logf("Generated prompt:\n%s", prompt)
 }
 
+// TestPrompterFilterResult tests that filter scores, tags and values reach the prompt
+func TestPrompterFilterResult(t *testing.T) {
+	prompter := NewPrompter()
+
+	req := &AnalysisRequest{
+		Symbol:   "BTCUSDT",
+		Interval: "5m",
+		MarketData: &types.MarketData{
+			Ticker: &types.SimplifiedTicker{LastPrice: 50000.0},
+		},
+		Metadata: types.FilterResult{
+			Matched:  true,
+			Score:    0.85,
+			Tags:     []string{"oversold", "volume-spike"},
+			Metadata: map[string]interface{}{"rsi": 24.5},
+		}.SignalMetadata(),
+	}
+
+	prompt, err := prompter.BuildAnalysisPrompt(req, nil)
+	if err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+
+	expectedStrings := []string{
+		"FILTER RESULT:",
+		"score: 0.8500",
+		"tags: oversold, volume-spike",
+		"rsi: 24.5000",
+	}
+
+	for _, expected := range expectedStrings {
+		if !contains(prompt, expected) {
+			t.Errorf("Prompt missing expected string: %s", expected)
+		}
+	}
+}
+
 // TestEngineStartStop tests engine lifecycle
 func TestEngineStartStop(t *testing.T) {
 	config := DefaultConfig()
@@ -290,12 +377,12 @@ func BenchmarkCalculateIndicators(b *testing.B) {
 	}
 
 	trader := &types.Trader{
-		Filter: types.TraderFilter{
+		Filter: rawFilter(types.TraderFilter{
 			Indicators: []types.IndicatorConfig{
 				{Name: "RSI", Params: map[string]interface{}{"period": 14.0}},
 				{Name: "MACD", Params: map[string]interface{}{"shortPeriod": 12.0, "longPeriod": 26.0, "signalPeriod": 9.0}},
 			},
-		},
+		}),
 	}
 
 	req := &AnalysisRequest{
@@ -317,9 +404,9 @@ func BenchmarkBuildPrompt(b *testing.B) {
 
 	req := &AnalysisRequest{
 		Trader: &types.Trader{
-			Filter: types.TraderFilter{
+			Filter: rawFilter(types.TraderFilter{
 				Description: []string{"Test strategy"},
-			},
+			}),
 		},
 		Symbol:   "BTCUSDT",
 		Interval: "5m",
@@ -3,8 +3,10 @@ package analysis
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/vyx/go-screener/pkg/indicators"
 	"github.com/vyx/go-screener/pkg/openrouter"
 )
 
@@ -42,12 +44,30 @@ func (p *Prompter) BuildAnalysisPrompt(req *AnalysisRequest, calculatedIndicator
 	// Format recent klines (OHLCV data)
 	klinesStr := p.formatRecentKlines(req)
 
+	// Format candlestick patterns on the recent klines
+	patternsStr := p.formatPatterns(req)
+
+	// Format support/resistance and volume profile levels
+	levelsStr := p.formatLevels(req)
+
+	// Format taker volume order flow
+	orderFlowStr := p.formatOrderFlow(req)
+
+	// Format perpetual funding, open interest and liquidations
+	futuresStr := p.formatFutures(req)
+
+	// Format what the filter reported about the match
+	filterResultStr := p.formatFilterResult(req.Metadata)
+
 	// Build the prompt
 	prompt := fmt.Sprintf(`Analyze this trading signal:
 
 STRATEGY:
 %s
 
+FILTER RESULT:
+%s
+
 SYMBOL: %s
 CURRENT PRICE: $%.8f
 24H CHANGE: %.2f%%
@@ -59,18 +79,35 @@ TECHNICAL INDICATORS:
 RECENT PRICE ACTION:
 %s
 
+CANDLESTICK PATTERNS:
+%s
+
+KEY LEVELS:
+%s
+
+ORDER FLOW:
+%s
+
+DERIVATIVES:
+%s
+
 Provide your analysis as JSON following the specified format. Focus on:
 1. Whether the setup meets the strategy criteria
 2. Risk/reward assessment at current price
 3. Key support/resistance levels for stop loss and take profit
 4. Overall confidence in this trade setup`,
 		strategyDesc,
+		filterResultStr,
 		req.Symbol,
 		ticker.LastPrice,
 		ticker.PriceChangePercent,
 		ticker.QuoteVolume,
 		indicatorsStr,
 		klinesStr,
+		patternsStr,
+		levelsStr,
+		orderFlowStr,
+		futuresStr,
 	)
 
 	return prompt, nil
@@ -126,6 +163,35 @@ Since this signal is being monitored, focus on what has CHANGED:
 	return monitoringPrompt, nil
 }
 
+// formatFilterResult formats the score, tags and values a filter attached to the signal
+func (p *Prompter) formatFilterResult(metadata map[string]interface{}) string {
+	if len(metadata) == 0 {
+		return "  Matched (no score or details reported by the filter)"
+	}
+
+	var lines []string
+	if score, ok := metadata["score"].(float64); ok {
+		lines = append(lines, fmt.Sprintf("  score: %.4f", score))
+	}
+	if tags, ok := metadata["tags"].([]string); ok && len(tags) > 0 {
+		lines = append(lines, fmt.Sprintf("  tags: %s", strings.Join(tags, ", ")))
+	}
+
+	// Remaining values in a stable order
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		if key != "score" && key != "tags" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("  %s: %s", key, p.formatIndicatorValue(key, metadata[key])))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 // formatIndicators formats calculated indicators for the prompt
 func (p *Prompter) formatIndicators(indicators map[string]interface{}) string {
 	if len(indicators) == 0 {
@@ -185,6 +251,169 @@ func (p *Prompter) formatIndicatorMap(m map[string]interface{}) string {
 	return string(jsonVal)
 }
 
+// patternCandles is the number of recent candles checked for candlestick patterns
+const patternCandles = 3
+
+// formatPatterns lists the candlestick patterns completed by the recent candles
+func (p *Prompter) formatPatterns(req *AnalysisRequest) string {
+	klines := req.MarketData.Klines[req.Interval]
+
+	var lines []string
+	for ago := 0; ago < patternCandles && ago < len(klines); ago++ {
+		when := "on the last candle"
+		if ago > 0 {
+			when = fmt.Sprintf("%d candles ago", ago)
+		}
+		for _, match := range indicators.DetectPatternsAt(klines, len(klines)-1-ago, indicators.PatternOptions{}) {
+			lines = append(lines, fmt.Sprintf("  %s (%s, %d-candle pattern) %s", match.Name, match.Direction, match.Candles, when))
+		}
+	}
+
+	if len(lines) == 0 {
+		return fmt.Sprintf("  None on the last %d candles (%s interval)", patternCandles, req.Interval)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Key level settings: swing points confirmed by levelSwingBars on each side, clustered
+// within levelTolerance of price, and a volume profile of levelProfileBins bins
+const (
+	levelSwingBars   = 3
+	levelTolerance   = 0.005
+	levelProfileBins = 24
+	levelCount       = 3 // Nearest levels listed on each side of price
+)
+
+// formatLevels lists the nearest support/resistance zones and the volume profile of the klines
+func (p *Prompter) formatLevels(req *AnalysisRequest) string {
+	klines := req.MarketData.Klines[req.Interval]
+	if len(klines) == 0 {
+		return "  No kline data available"
+	}
+	price := klines[len(klines)-1].Close
+
+	var supports, resistances []indicators.PriceLevel
+	for _, level := range indicators.FindSupportResistance(klines, levelSwingBars, levelSwingBars, levelTolerance) {
+		if level.Type == indicators.LevelSupport {
+			supports = append(supports, level)
+		} else {
+			resistances = append(resistances, level)
+		}
+	}
+
+	// Closest to price first
+	sort.Slice(supports, func(i, j int) bool { return supports[i].Price > supports[j].Price })
+	sort.Slice(resistances, func(i, j int) bool { return resistances[i].Price < resistances[j].Price })
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("  From the last %d candles (%s interval), last close %.8f:", len(klines), req.Interval, price))
+	lines = append(lines, p.formatLevelList("Resistance", resistances, price)...)
+	lines = append(lines, p.formatLevelList("Support", supports, price)...)
+
+	if profile := indicators.CalculateVolumeProfile(klines, levelProfileBins, 0.7); profile != nil {
+		lines = append(lines, fmt.Sprintf("    Volume profile: POC %.8f, value area %.8f - %.8f",
+			profile.POC, profile.ValueAreaLow, profile.ValueAreaHigh))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// formatLevelList formats the levelCount levels closest to price
+func (p *Prompter) formatLevelList(name string, levels []indicators.PriceLevel, price float64) []string {
+	if len(levels) == 0 {
+		return []string{fmt.Sprintf("    %s: none found", name)}
+	}
+
+	var lines []string
+	for i, level := range levels {
+		if i == levelCount {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("    %s: %.8f (zone %.8f - %.8f, %d touches, %.2f%% from price)",
+			name, level.Price, level.Low, level.High, level.Touches, (level.Price-price)/price*100))
+	}
+	return lines
+}
+
+// orderFlowPeriod is the number of recent candles order flow ratios are taken over
+const orderFlowPeriod = 20
+
+// formatOrderFlow summarizes taker buy/sell volume: CVD, imbalance, delta divergences and absorption
+func (p *Prompter) formatOrderFlow(req *AnalysisRequest) string {
+	klines := req.MarketData.Klines[req.Interval]
+	if len(klines) < orderFlowPeriod {
+		return "  Not enough kline data available"
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("  Last %d candles (%s interval):", orderFlowPeriod, req.Interval))
+	if cvd := indicators.CalculateRollingCVD(klines, orderFlowPeriod); cvd != nil {
+		lines = append(lines, fmt.Sprintf("    Volume delta: %.2f (CVD over all %d candles: %.2f)",
+			*cvd, len(klines), *indicators.CalculateCVD(klines)))
+	}
+	if imbalance := indicators.CalculateImbalance(klines, orderFlowPeriod); imbalance != nil {
+		lines = append(lines, fmt.Sprintf("    Taker imbalance: %.3f (-1 all sells, 1 all buys)", *imbalance))
+	}
+	if ratio := indicators.CalculateBuySellRatio(klines, orderFlowPeriod); ratio != nil {
+		lines = append(lines, fmt.Sprintf("    Buy/sell volume ratio: %.3f", *ratio))
+	}
+
+	divergences := indicators.DetectCVDDivergences(klines, indicators.DivergenceOptions{})
+	if d := indicators.LatestDivergence(klines, divergences, orderFlowPeriod); d != nil {
+		lines = append(lines, fmt.Sprintf("    CVD divergence: %s, price %.8f -> %.8f while CVD %.2f -> %.2f, %d candles ago",
+			d.Type, d.StartPrice, d.EndPrice, d.StartValue, d.EndValue, len(klines)-1-d.EndIndex))
+	}
+
+	for i := len(klines) - orderFlowPeriod; i < len(klines); i++ {
+		if a := indicators.DetectAbsorptionAt(klines, i, indicators.AbsorptionOptions{}); a != nil {
+			side := "bearish (buying absorbed)"
+			if a.Bullish {
+				side = "bullish (selling absorbed)"
+			}
+			lines = append(lines, fmt.Sprintf("    Absorption %d candles ago: %s, %.1fx volume in %.1fx range, imbalance %.2f",
+				len(klines)-1-i, side, a.VolumeRatio, a.RangeRatio, a.Imbalance))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// liquidationMinutes is the window liquidations are summed over
+const liquidationMinutes = 60
+
+// formatFutures summarizes the perpetual's funding, open interest, top trader positioning and liquidations
+func (p *Prompter) formatFutures(req *AnalysisRequest) string {
+	futures := req.MarketData.Futures
+	if futures == nil {
+		return "  No futures data available"
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("  Perpetual mark price %.8f (index %.8f)", futures.MarkPrice, futures.IndexPrice))
+	lines = append(lines, fmt.Sprintf("    Funding rate: %.4f%% per period", futures.FundingRate*100))
+
+	if history := futures.OpenInterestHist; len(history) > 0 {
+		line := fmt.Sprintf("    Open interest: %.2f ($%.0f)", futures.OpenInterest, futures.OpenInterestValue)
+		if change := indicators.OpenInterestChange(req.MarketData, len(history)-1); change != nil {
+			line += fmt.Sprintf(", %+.2f%% over the last %d periods", *change, len(history)-1)
+		}
+		lines = append(lines, line)
+	}
+
+	if history := futures.LongShortHist; len(history) > 0 {
+		latest := history[len(history)-1]
+		lines = append(lines, fmt.Sprintf("    Top trader long/short ratio: %.3f (%.1f%% long, was %.3f %d periods ago)",
+			latest.Ratio, latest.Long*100, history[0].Ratio, len(history)-1))
+	}
+
+	longs := indicators.LongLiquidations(req.MarketData, liquidationMinutes)
+	shorts := indicators.ShortLiquidations(req.MarketData, liquidationMinutes)
+	lines = append(lines, fmt.Sprintf("    Liquidations (last %d minutes): longs $%.0f (%d), shorts $%.0f (%d)",
+		liquidationMinutes, indicators.LiquidationNotional(longs), len(longs), indicators.LiquidationNotional(shorts), len(shorts)))
+
+	return strings.Join(lines, "\n")
+}
+
 // formatRecentKlines formats recent price action for the prompt
 func (p *Prompter) formatRecentKlines(req *AnalysisRequest) string {
 	klines, ok := req.MarketData.Klines[req.Interval]
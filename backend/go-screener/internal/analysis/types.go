@@ -15,6 +15,7 @@ type AnalysisRequest struct {
 	Symbol       string
 	Interval     string
 	MarketData   *types.MarketData
+	Metadata     map[string]interface{} // Score, tags and values returned by the filter
 	Trader       *types.Trader
 	IsReanalysis bool
 	QueuedAt     time.Time
@@ -20,6 +20,14 @@ type EventBus struct {
 	signalSubscribers []chan *SignalEvent
 	signalMu          sync.RWMutex
 
+	// Depth event subscriptions
+	depthSubscribers []chan *DepthEvent
+	depthMu          sync.RWMutex
+
+	// Large trade event subscriptions
+	largeTradeSubscribers []chan *LargeTradeEvent
+	largeTradeMu          sync.RWMutex
+
 	// Context for shutdown
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -33,6 +41,8 @@ func NewEventBus() *EventBus {
 		candleSubscribers:      make([]chan *CandleEvent, 0),
 		candleCloseSubscribers: make([]chan *CandleCloseEvent, 0),
 		signalSubscribers:      make([]chan *SignalEvent, 0),
+		depthSubscribers:       make([]chan *DepthEvent, 0),
+		largeTradeSubscribers:  make([]chan *LargeTradeEvent, 0),
 		ctx:                    ctx,
 		cancel:                 cancel,
 	}
@@ -74,6 +84,20 @@ func (b *EventBus) Stop() error {
 	b.signalSubscribers = nil
 	b.signalMu.Unlock()
 
+	b.depthMu.Lock()
+	for _, ch := range b.depthSubscribers {
+		close(ch)
+	}
+	b.depthSubscribers = nil
+	b.depthMu.Unlock()
+
+	b.largeTradeMu.Lock()
+	for _, ch := range b.largeTradeSubscribers {
+		close(ch)
+	}
+	b.largeTradeSubscribers = nil
+	b.largeTradeMu.Unlock()
+
 	// Wait for all goroutines
 	b.wg.Wait()
 
@@ -203,3 +227,85 @@ func (b *EventBus) GetCandleCloseSubscriberCount() int {
 	defer b.candleCloseMu.RUnlock()
 	return len(b.candleCloseSubscribers)
 }
+
+// PublishDepthEvent publishes an order book event to all subscribers
+func (b *EventBus) PublishDepthEvent(event *DepthEvent) {
+	b.depthMu.RLock()
+	defer b.depthMu.RUnlock()
+
+	// Send to all subscribers (non-blocking)
+	for _, ch := range b.depthSubscribers {
+		select {
+		case ch <- event:
+			// Sent successfully
+		default:
+			// Subscriber's channel is full, skip (prevents blocking)
+			log.Printf("[EventBus] Warning: Depth subscriber channel full, dropping %s event for %s",
+				event.Type, event.Symbol)
+		}
+	}
+}
+
+// SubscribeDepth creates a new subscription to order book events
+// Returns a channel that receives DepthEvent pointers
+// The channel is buffered with 1000 capacity
+func (b *EventBus) SubscribeDepth() <-chan *DepthEvent {
+	b.depthMu.Lock()
+	defer b.depthMu.Unlock()
+
+	// Create buffered channel
+	ch := make(chan *DepthEvent, 1000)
+	b.depthSubscribers = append(b.depthSubscribers, ch)
+
+	log.Printf("[EventBus] New depth subscription (total: %d)", len(b.depthSubscribers))
+
+	return ch
+}
+
+// GetDepthSubscriberCount returns the number of active depth subscribers
+func (b *EventBus) GetDepthSubscriberCount() int {
+	b.depthMu.RLock()
+	defer b.depthMu.RUnlock()
+	return len(b.depthSubscribers)
+}
+
+// PublishLargeTradeEvent publishes a large trade event to all subscribers
+func (b *EventBus) PublishLargeTradeEvent(event *LargeTradeEvent) {
+	b.largeTradeMu.RLock()
+	defer b.largeTradeMu.RUnlock()
+
+	// Send to all subscribers (non-blocking)
+	for _, ch := range b.largeTradeSubscribers {
+		select {
+		case ch <- event:
+			// Sent successfully
+		default:
+			// Subscriber's channel is full, skip (prevents blocking)
+			log.Printf("[EventBus] Warning: Large trade subscriber channel full, dropping event for %s",
+				event.Symbol)
+		}
+	}
+}
+
+// SubscribeLargeTrades creates a new subscription to large trade events
+// Returns a channel that receives LargeTradeEvent pointers
+// The channel is buffered with 1000 capacity
+func (b *EventBus) SubscribeLargeTrades() <-chan *LargeTradeEvent {
+	b.largeTradeMu.Lock()
+	defer b.largeTradeMu.Unlock()
+
+	// Create buffered channel
+	ch := make(chan *LargeTradeEvent, 1000)
+	b.largeTradeSubscribers = append(b.largeTradeSubscribers, ch)
+
+	log.Printf("[EventBus] New large trade subscription (total: %d)", len(b.largeTradeSubscribers))
+
+	return ch
+}
+
+// GetLargeTradeSubscriberCount returns the number of active large trade subscribers
+func (b *EventBus) GetLargeTradeSubscriberCount() int {
+	b.largeTradeMu.RLock()
+	defer b.largeTradeMu.RUnlock()
+	return len(b.largeTradeSubscribers)
+}
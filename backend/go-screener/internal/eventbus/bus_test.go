@@ -3,6 +3,8 @@ package eventbus
 import (
 	"testing"
 	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
 )
 
 func TestNewEventBus(t *testing.T) {
@@ -417,6 +419,87 @@ func TestCandleCloseEventNonBlockingPublish(t *testing.T) {
 	t.Log("Published 2000 candle close events without blocking")
 }
 
+func TestDepthEventPubSub(t *testing.T) {
+	bus := NewEventBus()
+	err := bus.Start()
+	if err != nil {
+		t.Fatalf("Failed to start bus: %v", err)
+	}
+	defer bus.Stop()
+
+	// Subscribe
+	ch := bus.SubscribeDepth()
+	if bus.GetDepthSubscriberCount() != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", bus.GetDepthSubscriberCount())
+	}
+
+	// Publish event
+	event := &DepthEvent{
+		Symbol:   "BTCUSDT",
+		Type:     DepthWallPulled,
+		Features: types.BookFeatures{Imbalance: -0.4},
+		Wall:     &types.BookWall{Side: "bid", Price: 60000, Quantity: 25},
+		Time:     time.Now(),
+	}
+
+	bus.PublishDepthEvent(event)
+
+	// Receive event
+	select {
+	case received := <-ch:
+		if received.Symbol != event.Symbol || received.Type != DepthWallPulled {
+			t.Errorf("Expected %s %s, got %s %s", event.Symbol, event.Type, received.Symbol, received.Type)
+		}
+		if received.Wall == nil || received.Wall.Price != 60000 {
+			t.Errorf("Expected the wall at 60000, got %+v", received.Wall)
+		}
+		if received.Features.Imbalance != -0.4 {
+			t.Errorf("Expected imbalance -0.4, got %v", received.Features.Imbalance)
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Timeout waiting for depth event")
+	}
+
+	// Publishing to a full channel doesn't block
+	for i := 0; i < 2000; i++ {
+		bus.PublishDepthEvent(event)
+	}
+}
+
+func TestLargeTradeEventPubSub(t *testing.T) {
+	bus := NewEventBus()
+	err := bus.Start()
+	if err != nil {
+		t.Fatalf("Failed to start bus: %v", err)
+	}
+	defer bus.Stop()
+
+	// Subscribe
+	ch := bus.SubscribeLargeTrades()
+	if bus.GetLargeTradeSubscriberCount() != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", bus.GetLargeTradeSubscriberCount())
+	}
+
+	// Publish event
+	event := &LargeTradeEvent{
+		Symbol: "ETHUSDT",
+		Trade:  types.LargeTrade{ID: 42, Side: "buy", Notional: 750000},
+		Time:   time.Now(),
+	}
+
+	bus.PublishLargeTradeEvent(event)
+
+	// Receive event
+	select {
+	case received := <-ch:
+		if received.Symbol != event.Symbol || received.Trade.ID != 42 || received.Trade.Notional != 750000 {
+			t.Errorf("Expected %+v, got %+v", event, received)
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Timeout waiting for large trade event")
+	}
+}
+
 func BenchmarkPublishCandleCloseEvent(b *testing.B) {
 	bus := NewEventBus()
 	bus.Start()
@@ -19,6 +19,31 @@ type CandleCloseEvent struct {
 	Interval  string      // The timeframe (e.g., "1m", "5m", "1h")
 	Kline     types.Kline // The complete closed candle
 	CloseTime time.Time   // When the candle closed
+	Backfill  bool        // Missed by the stream and recovered over REST, published late
+}
+
+// Depth event types
+const (
+	DepthFeatures     = "features"      // Periodic order book features
+	DepthWallAppeared = "wall_appeared" // A wall appeared within the scanned levels
+	DepthWallPulled   = "wall_pulled"   // A wall was cancelled before price reached it
+	DepthWallFilled   = "wall_filled"   // Price traded through a wall
+)
+
+// DepthEvent represents order book features or a wall change from the depth stream
+type DepthEvent struct {
+	Symbol   string             // The trading pair (e.g., "BTCUSDT")
+	Type     string             // DepthFeatures or one of the wall types
+	Features types.BookFeatures // Order book features when the event was raised
+	Wall     *types.BookWall    // The wall, for wall events
+	Time     time.Time
+}
+
+// LargeTradeEvent represents an unusually large trade on the trade tape
+type LargeTradeEvent struct {
+	Symbol string           // The trading pair (e.g., "BTCUSDT")
+	Trade  types.LargeTrade // The trade, with its notional and taker side
+	Time   time.Time        // When the trade was flagged
 }
 
 // SignalEvent represents a signal creation/update event from PostgreSQL
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/vyx/go-screener/pkg/cache"
 	"github.com/vyx/go-screener/pkg/supabase"
 	"github.com/vyx/go-screener/pkg/types"
 )
@@ -190,9 +191,9 @@ func NewBinanceAdapter(client interface {
 	return &BinanceAdapter{client: client}
 }
 
-// GetKlines implements BinanceClient interface
+// GetKlines implements BinanceClient interface, building bar intervals such as ha:5m
 func (a *BinanceAdapter) GetKlines(ctx context.Context, symbol, interval string, limit int) ([]types.Kline, error) {
-	return a.client.GetKlines(ctx, symbol, interval, limit)
+	return cache.FetchKlines(ctx, a.client.GetKlines, symbol, interval, limit)
 }
 
 // GetTicker implements BinanceClient interface
@@ -2,12 +2,10 @@ package screener
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/traefik/yaegi/interp"
-	"github.com/traefik/yaegi/stdlib"
-
 	"github.com/vyx/go-screener/pkg/types"
 	"github.com/vyx/go-screener/pkg/yaegi"
 )
@@ -15,12 +13,19 @@ import (
 // SeriesExecutor handles execution of series code for indicator data generation
 type SeriesExecutor struct {
 	timeout time.Duration
+	policy  *yaegi.SandboxPolicy
 }
 
 // NewSeriesExecutor creates a new series executor with the specified timeout
 func NewSeriesExecutor(timeout time.Duration) *SeriesExecutor {
+	return NewSeriesExecutorWithPolicy(timeout, yaegi.DefaultSandboxPolicy())
+}
+
+// NewSeriesExecutorWithPolicy creates a series executor restricted to the policy's imports
+func NewSeriesExecutorWithPolicy(timeout time.Duration, policy *yaegi.SandboxPolicy) *SeriesExecutor {
 	return &SeriesExecutor{
 		timeout: timeout,
+		policy:  policy,
 	}
 }
 
@@ -35,32 +40,19 @@ func (se *SeriesExecutor) ExecuteSeriesCode(
 	ctx, cancel := context.WithTimeout(ctx, se.timeout)
 	defer cancel()
 
-	// Create Yaegi interpreter
-	i := interp.New(interp.Options{})
-
-	// Import standard library
-	if err := i.Use(stdlib.Symbols); err != nil {
-		return nil, fmt.Errorf("failed to import stdlib: %w", err)
+	// Reject imports outside the sandbox before compiling anything
+	if err := se.policy.CheckImports(seriesCode); err != nil {
+		return nil, err
 	}
 
-	// Import custom symbols (indicators and types packages)
-	if err := i.Use(yaegi.GetCustomSymbols()); err != nil {
-		return nil, fmt.Errorf("failed to import custom symbols: %w", err)
+	// Create Yaegi interpreter with only the sandboxed packages
+	i, err := se.policy.NewInterpreter()
+	if err != nil {
+		return nil, err
 	}
 
 	// Wrap series code in function
-	fullCode := fmt.Sprintf(`
-package main
-
-import (
-	"github.com/vyx/go-screener/pkg/indicators"
-	"github.com/vyx/go-screener/pkg/types"
-)
-
-func calculateSeries(data *types.MarketData) map[string]interface{} {
-%s
-}
-`, seriesCode)
+	fullCode := yaegi.WrapFunc("func calculateSeries(data *types.MarketData) map[string]interface{}", seriesCode)
 
 	// Evaluate code
 	if _, err := i.Eval(fullCode); err != nil {
@@ -79,29 +71,19 @@ func calculateSeries(data *types.MarketData) map[string]interface{} {
 		return nil, fmt.Errorf("calculateSeries is not the correct type")
 	}
 
-	// Execute with timeout
-	resultChan := make(chan map[string]interface{}, 1)
-	errChan := make(chan error, 1)
-
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				errChan <- fmt.Errorf("panic in series code: %v", r)
-			}
-		}()
-
-		result := fn(data)
-		resultChan <- result
-	}()
-
-	select {
-	case result := <-resultChan:
-		return result, nil
-	case err := <-errChan:
+	// Execute with timeout; on timeout the interpreter is stopped so the code doesn't keep running
+	var result map[string]interface{}
+	err = yaegi.RunWithContext(ctx, "series", func() { yaegi.StopInterpreter(i) }, func() {
+		result = fn(data)
+	})
+	if errors.Is(err, yaegi.ErrExecutionTimeout) {
+		return nil, fmt.Errorf("series code execution timeout: %w", err)
+	}
+	if err != nil {
 		return nil, err
-	case <-ctx.Done():
-		return nil, fmt.Errorf("series code execution timeout")
 	}
+
+	return result, nil
 }
 
 // ValidateSeriesOutput checks if series data has correct format
@@ -2,10 +2,12 @@ package screener
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/vyx/go-screener/pkg/types"
+	"github.com/vyx/go-screener/pkg/yaegi"
 )
 
 // createTestMarketData creates a mock MarketData for testing
@@ -171,6 +173,35 @@ func TestSeriesExecutor_ExecuteSeriesCode_Timeout(t *testing.T) {
 	}
 }
 
+func TestSeriesExecutor_ExecuteSeriesCode_StopsInfiniteLoop(t *testing.T) {
+	executor := NewSeriesExecutor(50 * time.Millisecond)
+	data := createTestMarketData()
+
+	seriesCode := `
+		n := 0
+		for {
+			n++
+		}
+		return map[string]interface{}{"n": n}
+	`
+
+	before := yaegi.GetAbandonedStats()
+
+	_, err := executor.ExecuteSeriesCode(context.Background(), seriesCode, data)
+	if !errors.Is(err, yaegi.ErrExecutionTimeout) {
+		t.Fatalf("err = %v, want ErrExecutionTimeout", err)
+	}
+
+	// The interpreter is stopped, so the goroutine running the loop exits
+	deadline := time.Now().Add(2 * time.Second)
+	for yaegi.GetAbandonedStats().InFlight != before.InFlight {
+		if time.Now().After(deadline) {
+			t.Fatal("series code goroutine still running after timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestSeriesExecutor_ExecuteSeriesCode_ContextCanceled(t *testing.T) {
 	executor := NewSeriesExecutor(5 * time.Second)
 	data := createTestMarketData()
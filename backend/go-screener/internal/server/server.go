@@ -3,10 +3,12 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -16,10 +18,12 @@ import (
 	"github.com/vyx/go-screener/internal/eventbus"
 	"github.com/vyx/go-screener/internal/monitoring"
 	"github.com/vyx/go-screener/internal/scheduler"
+	"github.com/vyx/go-screener/internal/streaming"
 	"github.com/vyx/go-screener/internal/trader"
 	"github.com/vyx/go-screener/pkg/binance"
 	"github.com/vyx/go-screener/pkg/cache"
 	"github.com/vyx/go-screener/pkg/config"
+	"github.com/vyx/go-screener/pkg/indicators"
 	"github.com/vyx/go-screener/pkg/supabase"
 	"github.com/vyx/go-screener/pkg/types"
 	"github.com/vyx/go-screener/pkg/yaegi"
@@ -37,9 +41,13 @@ type Server struct {
 	// WebSocket & Cache
 	klineCache      *cache.KlineCache
 	wsClient        *binance.WSClient
+	depthStream     *binance.DepthStream
+	tradeStream     *binance.TradeStream
+	futuresFeed     *binance.FuturesFeed
 
 	// Event-driven architecture
 	eventBus        *eventbus.EventBus
+	streamingEngine *streaming.Engine
 	candleScheduler *scheduler.CandleScheduler
 	analysisEngine  *analysis.Engine
 	monitoringEngine *monitoring.Engine
@@ -51,6 +59,32 @@ type Server struct {
 	startTime       time.Time
 }
 
+// cacheIntervals are the intervals kept in the kline cache
+var cacheIntervals = []string{"1m", "5m", "15m", "1h", "4h", "1d"}
+
+// baseInterval is streamed over WebSocket and resampled into derivedIntervals by the cache
+const baseInterval = "1m"
+
+// derivedIntervals are built from baseInterval instead of having their own streams
+// 1d stays streamed: a day is more 1m candles than the cache keeps
+var derivedIntervals = []string{"5m", "15m", "1h", "4h"}
+
+// streamIntervals are the cached intervals streamed over WebSocket
+func streamIntervals() []string {
+	derived := make(map[string]bool, len(derivedIntervals))
+	for _, interval := range derivedIntervals {
+		derived[interval] = true
+	}
+
+	var intervals []string
+	for _, interval := range cacheIntervals {
+		if !derived[interval] {
+			intervals = append(intervals, interval)
+		}
+	}
+	return intervals
+}
+
 // New creates a new server instance
 func New(cfg *config.Config) (*Server, error) {
 	log.Printf("[Server] Initializing event-driven architecture...")
@@ -59,23 +93,71 @@ func New(cfg *config.Config) (*Server, error) {
 	binanceClient := binance.NewClient(cfg.BinanceAPIURL)
 	supabaseClient := supabase.NewClient(cfg.SupabaseURL, cfg.SupabaseServiceKey)
 
-	// Initialize Yaegi executor
-	yaegiExec, err := yaegi.NewExecutor()
+	// Initialize Yaegi executor (user code may only import sandboxed packages)
+	yaegiExec, err := yaegi.NewExecutorWithPolicy(yaegi.NewSandboxPolicy(cfg.SandboxAllowedImports))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create yaegi executor: %w", err)
 	}
 
 	// Initialize kline cache (keep last 500 candles per symbol/interval)
 	klineCache := cache.NewKlineCache(500)
-	log.Printf("[Server] ✅ Kline Cache initialized (max 500 candles per symbol/interval)")
+	if err := klineCache.SetBaseInterval(baseInterval); err != nil {
+		return nil, fmt.Errorf("failed to set kline cache base interval: %w", err)
+	}
+	klineCache.SetStreamed(streamIntervals())
+	for _, interval := range derivedIntervals {
+		if err := klineCache.Derive(interval); err != nil {
+			return nil, fmt.Errorf("failed to derive %s klines: %w", interval, err)
+		}
+	}
+	log.Printf("[Server] ✅ Kline Cache initialized (max 500 candles per symbol/interval, %v resampled from %s)", derivedIntervals, baseInterval)
 
 	// 1. Initialize Event Bus
 	eventBus := eventbus.NewEventBus()
 	log.Printf("[Server] ✅ Event Bus initialized")
 
 	// Initialize WebSocket client (with eventBus for candle close events)
-	wsClient := binance.NewWSClient(cfg.BinanceWSURL, klineCache, eventBus)
-	log.Printf("[Server] ✅ WebSocket Client initialized")
+	wsConfig := binance.DefaultWSConfig()
+	wsConfig.StreamsPerShard = cfg.WSStreamsPerShard
+	wsClient := binance.NewWSClient(cfg.BinanceWSURL, binanceClient, klineCache, eventBus, wsConfig)
+	log.Printf("[Server] ✅ WebSocket Client initialized (up to %d streams per connection)", wsConfig.StreamsPerShard)
+
+	// Initialize order book stream (optional - disabled with DEPTH_SYMBOL_COUNT=0)
+	var depthStream *binance.DepthStream
+	if cfg.DepthSymbolCount > 0 {
+		depthStream = binance.NewDepthStream(cfg.BinanceWSURL, binanceClient, eventBus, binance.DefaultDepthConfig())
+		log.Printf("[Server] ✅ Depth Stream initialized (top %d symbols)", cfg.DepthSymbolCount)
+	}
+
+	// Initialize trade stream for footprint candles and large trades (optional - disabled with TRADE_SYMBOL_COUNT=0)
+	var tradeStream *binance.TradeStream
+	var tape *binance.Tape
+	if cfg.TradeSymbolCount > 0 {
+		footprintConfig := cache.FootprintConfig{
+			Intervals:     cfg.FootprintIntervals,
+			TicksPerLevel: cfg.FootprintTicks,
+			MaxCandles:    cache.DefaultFootprintCandles,
+		}
+		if err := klineCache.EnableFootprints(footprintConfig); err != nil {
+			return nil, fmt.Errorf("failed to enable footprints: %w", err)
+		}
+
+		tapeConfig := binance.DefaultTapeConfig()
+		tapeConfig.MinNotional = cfg.LargeTradeMinNotional
+		tapeConfig.Percentile = cfg.LargeTradePercentile
+		tape = binance.NewTape(tapeConfig)
+
+		tradeStream = binance.NewTradeStream(cfg.BinanceWSURL, binanceClient, klineCache, tape, eventBus)
+		log.Printf("[Server] ✅ Trade Stream initialized (top %d symbols, %v footprints)", cfg.TradeSymbolCount, cfg.FootprintIntervals)
+	}
+
+	// Initialize futures feed for funding, open interest and liquidations (optional - disabled with FUTURES_SYMBOL_COUNT=0)
+	var futuresFeed *binance.FuturesFeed
+	if cfg.FuturesSymbolCount > 0 {
+		futuresClient := binance.NewFuturesClient(cfg.BinanceFuturesAPIURL)
+		futuresFeed = binance.NewFuturesFeed(cfg.BinanceFuturesWSURL, futuresClient, binance.DefaultFuturesConfig())
+		log.Printf("[Server] ✅ Futures Feed initialized (top %d symbols)", cfg.FuturesSymbolCount)
+	}
 
 	// 2. Initialize Candle Scheduler
 	schedulerConfig := scheduler.DefaultConfig()
@@ -128,7 +210,23 @@ func New(cfg *config.Config) (*Server, error) {
 		log.Printf("[Server] ⚠️  Monitoring engine disabled (requires analysis engine)")
 	}
 
+	// Initialize Streaming Engine (indicator state updated per closed candle)
+	streamingEngine := streaming.NewEngine(eventBus, klineCache)
+	for _, interval := range cacheIntervals {
+		for _, spec := range streaming.DefaultSpecs {
+			if err := streamingEngine.Register(interval, spec); err != nil {
+				return nil, fmt.Errorf("failed to register streamed indicator: %w", err)
+			}
+		}
+	}
+	log.Printf("[Server] ✅ Streaming Engine initialized")
+
 	// 5. Initialize Trader Executor (event-driven)
+	filterStates := yaegi.NewStateStore(cfg.FilterStateMaxKeys, cfg.FilterStateMaxBytes)
+	if cfg.FilterStatePersist {
+		filterStates.SetPersister(supabaseClient)
+	}
+
 	traderExecutor := trader.NewExecutor(
 		yaegiExec,
 		binanceClient,
@@ -136,6 +234,11 @@ func New(cfg *config.Config) (*Server, error) {
 		analysisEngine,
 		eventBus,
 		klineCache,
+		filterStates,
+		streamingEngine,
+		depthStream,
+		tape,
+		futuresFeed,
 	)
 	log.Printf("[Server] ✅ Trader Executor initialized")
 
@@ -151,7 +254,11 @@ func New(cfg *config.Config) (*Server, error) {
 		yaegiExecutor:    yaegiExec,
 		klineCache:       klineCache,
 		wsClient:         wsClient,
+		depthStream:      depthStream,
+		tradeStream:      tradeStream,
+		futuresFeed:      futuresFeed,
 		eventBus:         eventBus,
+		streamingEngine:  streamingEngine,
 		candleScheduler:  candleScheduler,
 		analysisEngine:   analysisEngine,
 		monitoringEngine: monitoringEngine,
@@ -196,6 +303,12 @@ func (s *Server) setupRouter() {
 	// Klines
 	api.HandleFunc("/klines/{symbol}/{interval}", s.handleGetKlines).Methods("GET")
 
+	// Footprint candles
+	api.HandleFunc("/footprint/{symbol}/{interval}", s.handleGetFootprint).Methods("GET")
+
+	// Kline stream connection health
+	api.HandleFunc("/streams", s.handleGetStreams).Methods("GET")
+
 	// Traders
 	api.HandleFunc("/traders", s.handleGetTraders).Methods("GET")
 	api.HandleFunc("/traders/{id}", s.handleGetTrader).Methods("GET")
@@ -210,6 +323,12 @@ func (s *Server) setupRouter() {
 	// Validate code
 	api.HandleFunc("/validate-code", s.handleValidateCode).Methods("POST")
 
+	// Indicator catalog for the chart indicator picker
+	api.HandleFunc("/indicators", s.handleGetIndicators).Methods("GET")
+
+	// Candlestick patterns across the cached universe
+	api.HandleFunc("/patterns/{pattern}/{interval}", s.handleScanPattern).Methods("GET")
+
 	// Trader management (requires authentication and tier check)
 	traderAPI := api.PathPrefix("/traders").Subrouter()
 	traderAPI.Use(AuthMiddleware(s.supabaseClient))
@@ -220,6 +339,8 @@ func (s *Server) setupRouter() {
 	traderAPI.HandleFunc("/{id}/reload", s.traderHandler.ReloadTrader).Methods("POST")
 	traderAPI.HandleFunc("/{id}/execute-immediate", s.traderHandler.ExecuteImmediate).Methods("POST")
 	traderAPI.HandleFunc("/{id}/status", s.traderHandler.GetTraderStatus).Methods("GET")
+	traderAPI.HandleFunc("/{id}/state", s.traderHandler.GetFilterState).Methods("GET")
+	traderAPI.HandleFunc("/{id}/state", s.traderHandler.ResetFilterState).Methods("DELETE")
 	traderAPI.HandleFunc("/active", s.traderHandler.ListActiveTraders).Methods("GET")
 	traderAPI.HandleFunc("/metrics", s.traderHandler.GetManagerMetrics).Methods("GET")
 
@@ -256,8 +377,7 @@ func (s *Server) Start() error {
 	}
 	log.Printf("[Server] Retrieved %d symbols for bootstrap", len(symbols))
 
-	// Define all intervals we want to cache
-	intervals := []string{"1m", "5m", "15m", "1h", "4h", "1d"}
+	intervals := cacheIntervals
 	log.Printf("[Server] Bootstrapping cache for %d intervals: %v", len(intervals), intervals)
 
 	// Fetch historical klines for all intervals
@@ -276,18 +396,40 @@ func (s *Server) Start() error {
 	}
 	log.Printf("[Server] ✅ Kline cache bootstrapped: %d symbols × %d intervals = %d total klines", len(symbols), len(intervals), s.klineCache.Size())
 
-	// Start WebSocket connection for real-time updates on all intervals
+	// Start WebSocket connection for real-time updates; derived intervals follow the base interval
 	log.Printf("[Server] 🔄 Connecting to Binance WebSocket...")
-	if err := s.wsClient.Connect(symbols, intervals); err != nil {
+	streamed := streamIntervals()
+	if err := s.wsClient.Connect(symbols, streamed); err != nil {
 		return fmt.Errorf("failed to connect WebSocket: %w", err)
 	}
-	log.Printf("[Server] ✅ WebSocket connected and streaming %d symbols × %d intervals = %d streams", len(symbols), len(intervals), len(symbols)*len(intervals))
+	log.Printf("[Server] ✅ WebSocket connected and streaming %d symbols × %d intervals = %d streams on %d connections",
+		len(symbols), len(streamed), len(symbols)*len(streamed), len(s.wsClient.GetStats().Shards))
+
+	// Start order book tracking for the most traded symbols
+	if s.depthStream != nil {
+		s.depthStream.Start(symbols[:min(s.config.DepthSymbolCount, len(symbols))])
+	}
+
+	// Start trade ingestion for footprints and large trades of the most traded symbols
+	if s.tradeStream != nil {
+		s.tradeStream.Start(symbols[:min(s.config.TradeSymbolCount, len(symbols))])
+	}
+
+	// Start futures tracking for the perpetuals of the most traded symbols
+	if s.futuresFeed != nil {
+		s.futuresFeed.Start(symbols[:min(s.config.FuturesSymbolCount, len(symbols))])
+	}
 
 	// Start Event Bus
 	if err := s.eventBus.Start(); err != nil {
 		return fmt.Errorf("failed to start event bus: %w", err)
 	}
 
+	// Start Streaming Engine before traders register their indicators
+	if err := s.streamingEngine.Start(); err != nil {
+		return fmt.Errorf("failed to start streaming engine: %w", err)
+	}
+
 	// Load traders from database
 	log.Printf("[Server] Loading traders from database...")
 	if err := s.traderManager.LoadTradersFromDB(); err != nil {
@@ -340,6 +482,21 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	if err := s.wsClient.Close(); err != nil {
 		log.Printf("[Server] Warning: WebSocket shutdown error: %v", err)
 	}
+	if s.depthStream != nil {
+		if err := s.depthStream.Close(); err != nil {
+			log.Printf("[Server] Warning: Depth stream shutdown error: %v", err)
+		}
+	}
+	if s.tradeStream != nil {
+		if err := s.tradeStream.Close(); err != nil {
+			log.Printf("[Server] Warning: Trade stream shutdown error: %v", err)
+		}
+	}
+	if s.futuresFeed != nil {
+		if err := s.futuresFeed.Close(); err != nil {
+			log.Printf("[Server] Warning: Futures feed shutdown error: %v", err)
+		}
+	}
 
 	// 2. Shutdown trader manager (stop accepting new traders)
 	log.Printf("[Server] Shutting down trader manager...")
@@ -367,6 +524,11 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	log.Printf("[Server] Shutting down streaming engine...")
+	if err := s.streamingEngine.Stop(); err != nil {
+		log.Printf("[Server] Warning: Streaming engine shutdown error: %v", err)
+	}
+
 	// 5. Shutdown analysis engine (if available)
 	if s.analysisEngine != nil {
 		log.Printf("[Server] Shutting down analysis engine...")
@@ -416,6 +578,11 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, health)
 }
 
+// handleGetStreams serves the health of each kline stream connection
+func (s *Server) handleGetStreams(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, s.wsClient.GetStats())
+}
+
 func (s *Server) handleGetSymbols(w http.ResponseWriter, r *http.Request) {
 	symbols, err := s.binanceClient.GetTopSymbols(r.Context(), s.config.SymbolCount, s.config.MinVolume)
 	if err != nil {
@@ -439,7 +606,7 @@ func (s *Server) handleGetKlines(w http.ResponseWriter, r *http.Request) {
 		fmt.Sscanf(limitStr, "%d", &limit)
 	}
 
-	klines, err := s.binanceClient.GetKlines(r.Context(), symbol, interval, limit)
+	klines, err := cache.FetchKlines(r.Context(), s.binanceClient.GetKlines, symbol, interval, limit)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch klines", err)
 		return
@@ -453,6 +620,38 @@ func (s *Server) handleGetKlines(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleGetFootprint serves footprint candles from the cache
+// Query: limit (default 100, at most the cached candles), group (levels merged into one, default 1)
+func (s *Server) handleGetFootprint(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	symbol := vars["symbol"]
+	interval := vars["interval"]
+
+	limit := cache.DefaultFootprintCandles
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	group := 1
+	if groupStr := r.URL.Query().Get("group"); groupStr != "" {
+		fmt.Sscanf(groupStr, "%d", &group)
+	}
+	group = max(group, 1)
+
+	footprints, err := s.klineCache.GetFootprints(symbol, interval, limit)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Footprints not available", err)
+		return
+	}
+	footprints = cache.RegroupFootprints(footprints, group)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"symbol":     symbol,
+		"interval":   interval,
+		"footprints": footprints,
+		"count":      len(footprints),
+	})
+}
+
 func (s *Server) handleGetTraders(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("userId")
 
@@ -517,9 +716,13 @@ func (s *Server) handleGetSignals(w http.ResponseWriter, r *http.Request) {
 	respondError(w, http.StatusNotImplemented, "Not implemented", nil)
 }
 
+// executeFilterTimeout bounds a filter run of the execute-filter endpoint
+const executeFilterTimeout = 1 * time.Second
+
 type ExecuteFilterRequest struct {
 	Code       string             `json:"code"`
 	MarketData types.MarketData   `json:"marketData"`
+	Trace      bool               `json:"trace"` // Return helper calls, output and result of the run
 }
 
 func (s *Server) handleExecuteFilter(w http.ResponseWriter, r *http.Request) {
@@ -529,16 +732,146 @@ func (s *Server) handleExecuteFilter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Execute filter with timeout
-	result, err := s.yaegiExecutor.ExecuteFilterWithTimeout(req.Code, &req.MarketData, 1*time.Second)
+	// Stateful filters get a fresh state; nothing persists between requests
+	req.MarketData.State = types.NewSymbolState(0, 0)
+
+	if req.Trace {
+		s.handleTraceFilter(w, r.Context(), req)
+		return
+	}
+
+	// Execute filter with timeout (also stopped if the client goes away)
+	ctx, cancel := context.WithTimeout(r.Context(), executeFilterTimeout)
+	defer cancel()
+
+	result, err := s.yaegiExecutor.EvaluateFilterWithContext(ctx, req.Code, &req.MarketData)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Filter execution failed", err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"matched": result,
+	resp := map[string]interface{}{
+		"matched": result.Matched,
+		"symbol":  req.MarketData.Symbol,
+	}
+
+	// Rich filters also report a score, tags and values
+	if metadata := result.SignalMetadata(); len(metadata) > 0 {
+		resp["metadata"] = metadata
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// handleTraceFilter runs a filter in trace mode
+// Execution errors are part of the trace, so the response is returned either way
+func (s *Server) handleTraceFilter(w http.ResponseWriter, ctx context.Context, req ExecuteFilterRequest) {
+	tracer, err := s.yaegiExecutor.NewTracer(req.Code)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Filter compilation failed", err)
+		return
+	}
+
+	result, _ := tracer.Evaluate(ctx, &req.MarketData, executeFilterTimeout)
+
+	resp := map[string]interface{}{
+		"matched": result.Matched,
 		"symbol":  req.MarketData.Symbol,
+		"trace":   tracer.Traces()[0],
+	}
+	if metadata := result.SignalMetadata(); len(metadata) > 0 {
+		resp["metadata"] = metadata
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// handleGetIndicators publishes the indicator registry: parameters with defaults and
+// bounds, warmup and output lines of every indicator a trader can configure by name
+func (s *Server) handleGetIndicators(w http.ResponseWriter, r *http.Request) {
+	catalog := indicators.IndicatorCatalog()
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"indicators": catalog,
+		"count":      len(catalog),
+	})
+}
+
+// maxPatternLookback caps how many candles back a pattern scan looks
+const maxPatternLookback = 100
+
+// PatternHit is a symbol whose recent klines completed a pattern
+type PatternHit struct {
+	Symbol     string `json:"symbol"`
+	Direction  string `json:"direction"`
+	CandlesAgo int    `json:"candlesAgo"` // 0 when the last closed candle completed the pattern
+	OpenTime   int64  `json:"openTime"`
+}
+
+// handleScanPattern scans the cached klines of every symbol for a candlestick pattern
+// ?lookback=N (at most maxPatternLookback) also reports patterns completed up to N-1
+// candles before the last one. Only cached intervals can be scanned
+func (s *Server) handleScanPattern(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	pattern := vars["pattern"]
+	interval := vars["interval"]
+
+	if !indicators.IsPattern(pattern) {
+		respondError(w, http.StatusBadRequest, "Unknown pattern", fmt.Errorf("pattern %q is not one of %v", pattern, indicators.PatternNames))
+		return
+	}
+	if !slices.Contains(cacheIntervals, interval) {
+		respondError(w, http.StatusBadRequest, "Unsupported interval", fmt.Errorf("interval %q is not one of %v", interval, cacheIntervals))
+		return
+	}
+
+	lookback := 1
+	if lookbackStr := r.URL.Query().Get("lookback"); lookbackStr != "" {
+		fmt.Sscanf(lookbackStr, "%d", &lookback)
+	}
+	if lookback < 1 {
+		lookback = 1
+	}
+	if lookback > maxPatternLookback {
+		lookback = maxPatternLookback
+	}
+
+	// Enough candles for three-candle patterns and the trend before them, plus the
+	// candle that may still be open
+	need := lookback + 2 + indicators.DefaultPatternOptions().TrendLookback + 1
+
+	hits := []PatternHit{}
+	now := time.Now().UnixMilli()
+	symbols := s.klineCache.GetSymbols()
+	for _, symbol := range symbols {
+		klines, err := s.klineCache.Get(symbol, interval, need)
+		if err != nil {
+			continue
+		}
+		// Bootstrapped data ends with the candle that is still open
+		if n := len(klines); n > 0 && klines[n-1].CloseTime >= now {
+			klines = klines[:n-1]
+		}
+		for ago := 0; ago < lookback && ago < len(klines); ago++ {
+			for _, match := range indicators.DetectPatternsAt(klines, len(klines)-1-ago, indicators.PatternOptions{}) {
+				if match.Name != pattern {
+					continue
+				}
+				hits = append(hits, PatternHit{
+					Symbol:     symbol,
+					Direction:  match.Direction,
+					CandlesAgo: ago,
+					OpenTime:   match.OpenTime,
+				})
+			}
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pattern":  pattern,
+		"interval": interval,
+		"matches":  hits,
+		"count":    len(hits),
+		"scanned":  len(symbols),
 	})
 }
 
@@ -553,17 +886,31 @@ func (s *Server) handleValidateCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.yaegiExecutor.ValidateCode(req.Code); err != nil {
-		respondJSON(w, http.StatusOK, map[string]interface{}{
-			"valid":  false,
-			"error":  err.Error(),
-		})
-		return
+	diagnostics := s.yaegiExecutor.AnalyzeCode(req.Code)
+	if diagnostics == nil {
+		diagnostics = []yaegi.Diagnostic{}
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"valid": true,
-	})
+	resp := map[string]interface{}{
+		"valid":       !yaegi.HasErrors(diagnostics),
+		"diagnostics": diagnostics,
+	}
+
+	for _, d := range diagnostics {
+		if d.Severity == yaegi.SeverityError {
+			resp["error"] = fmt.Sprintf("line %d, column %d: %s", d.Line, d.Column, d.Message)
+			break
+		}
+	}
+
+	// Point the editor at each disallowed import
+	var importErr *yaegi.ImportError
+	if errors.As(s.yaegiExecutor.Policy().CheckImports(req.Code), &importErr) {
+		resp["importViolations"] = importErr.Violations
+		resp["allowedImports"] = importErr.Allowed
+	}
+
+	respondJSON(w, http.StatusOK, resp)
 }
 
 // Helper functions
@@ -244,6 +244,8 @@ func (h *TraderHandler) ReloadTrader(w http.ResponseWriter, r *http.Request) {
 }
 
 // ExecuteImmediate handles POST /api/v1/traders/{id}/execute-immediate
+// ?trace=true returns a filter trace for every screened symbol and is a dry run: no
+// signals or filter state are saved
 func (h *TraderHandler) ExecuteImmediate(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	traderID := vars["id"]
@@ -301,7 +303,8 @@ func (h *TraderHandler) ExecuteImmediate(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Execute trader immediately
-	result, err := h.manager.ExecuteImmediate(traderID)
+	trace := r.URL.Query().Get("trace") == "true"
+	result, err := h.manager.ExecuteImmediate(traderID, trace)
 	if err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{
 			"error": err.Error(),
@@ -312,6 +315,86 @@ func (h *TraderHandler) ExecuteImmediate(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, result)
 }
 
+// GetFilterState handles GET /api/v1/traders/{id}/state
+// Returns the key/value state the trader's filter keeps per symbol
+func (h *TraderHandler) GetFilterState(w http.ResponseWriter, r *http.Request) {
+	traderID := mux.Vars(r)["id"]
+	if !h.authorizeTrader(w, r, traderID) {
+		return
+	}
+
+	state, err := h.manager.GetFilterState(traderID)
+	if err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"traderId": traderID,
+		"symbols":  state,
+	})
+}
+
+// ResetFilterState handles DELETE /api/v1/traders/{id}/state
+// ?symbol=BTCUSDT resets one symbol, otherwise the state of every symbol is cleared
+func (h *TraderHandler) ResetFilterState(w http.ResponseWriter, r *http.Request) {
+	traderID := mux.Vars(r)["id"]
+	if !h.authorizeTrader(w, r, traderID) {
+		return
+	}
+
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	if err := h.manager.ResetFilterState(traderID, symbol); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"symbol":  symbol,
+	})
+}
+
+// authorizeTrader loads the trader if needed and checks the user owns it
+// Writes the error response and returns false if not
+func (h *TraderHandler) authorizeTrader(w http.ResponseWriter, r *http.Request, traderID string) bool {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+		return false
+	}
+
+	status, err := h.manager.GetStatus(traderID)
+	if err != nil {
+		if loadErr := h.manager.LoadTraderByID(traderID); loadErr != nil {
+			respondJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Trader not found",
+			})
+			return false
+		}
+		if status, err = h.manager.GetStatus(traderID); err != nil {
+			respondJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": "Failed to load trader",
+			})
+			return false
+		}
+	}
+
+	if status.UserID != userID {
+		respondJSON(w, http.StatusForbidden, map[string]string{
+			"error": "You do not have permission to access this trader",
+		})
+		return false
+	}
+	return true
+}
+
 // AuthMiddleware validates Supabase JWT tokens
 func AuthMiddleware(supabase *supabase.Client) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -0,0 +1,287 @@
+package streaming
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/vyx/go-screener/internal/eventbus"
+	"github.com/vyx/go-screener/internal/scheduler"
+	"github.com/vyx/go-screener/pkg/cache"
+	"github.com/vyx/go-screener/pkg/indicators"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// warmupLimit is the number of cached klines replayed to initialize a stream
+const warmupLimit = 1000
+
+// Spec is an indicator and its parameters
+type Spec struct {
+	Name   string
+	Params []float64
+}
+
+// DefaultSpecs are the indicators streamed on every interval
+var DefaultSpecs = []Spec{
+	{Name: indicators.StreamRSI, Params: []float64{14}},
+	{Name: indicators.StreamEMA, Params: []float64{9}},
+	{Name: indicators.StreamEMA, Params: []float64{20}},
+	{Name: indicators.StreamEMA, Params: []float64{50}},
+	{Name: indicators.StreamEMA, Params: []float64{200}},
+	{Name: indicators.StreamSMA, Params: []float64{20}},
+	{Name: indicators.StreamSMA, Params: []float64{50}},
+	{Name: indicators.StreamMACD, Params: []float64{12, 26, 9}},
+	{Name: indicators.StreamATR, Params: []float64{14}},
+}
+
+// streamID identifies the state of one indicator for a symbol and interval
+type streamID struct {
+	symbol   string
+	interval string
+	key      string // indicators.StreamKey
+}
+
+// symbolStream is the rolling state of one indicator for a symbol and interval
+type symbolStream struct {
+	stream   indicators.Stream
+	openTime int64 // open time of the last kline applied
+}
+
+// Engine keeps indicator state up to date as candles close
+// Indicators are registered per interval and tracked for every symbol that has
+// candles on it. State is created lazily from the kline cache and then updated
+// in O(1) per candle close event
+type Engine struct {
+	eventBus *eventbus.EventBus
+	cache    *cache.KlineCache
+
+	mu      sync.RWMutex
+	specs   map[string]map[string]Spec // interval -> stream key -> spec
+	streams map[streamID]*symbolStream
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewEngine creates a streaming indicator engine
+func NewEngine(eventBus *eventbus.EventBus, klineCache *cache.KlineCache) *Engine {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Engine{
+		eventBus: eventBus,
+		cache:    klineCache,
+		specs:    make(map[string]map[string]Spec),
+		streams:  make(map[streamID]*symbolStream),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start subscribes to candle close events
+func (e *Engine) Start() error {
+	log.Printf("[StreamingEngine] Starting...")
+
+	candleCloseCh := e.eventBus.SubscribeCandleClose()
+
+	e.wg.Add(1)
+	go e.candleCloseEventLoop(candleCloseCh)
+
+	log.Printf("[StreamingEngine] ✅ Started with %d registered indicators", e.registeredCount())
+	return nil
+}
+
+// Stop gracefully shuts down the engine
+func (e *Engine) Stop() error {
+	log.Printf("[StreamingEngine] Shutting down...")
+	e.cancel()
+	e.wg.Wait()
+	log.Printf("[StreamingEngine] ✅ Stopped successfully")
+	return nil
+}
+
+// Register streams an indicator on an interval for all symbols
+// Registering an indicator twice is a no-op
+func (e *Engine) Register(interval string, spec Spec) error {
+	if e == nil {
+		return nil
+	}
+	if _, err := scheduler.ParseInterval(interval); err != nil {
+		return err
+	}
+	if _, err := indicators.NewStream(spec.Name, spec.Params); err != nil {
+		return err
+	}
+
+	key := indicators.StreamKey(spec.Name, spec.Params...)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.specs[interval] == nil {
+		e.specs[interval] = make(map[string]Spec)
+	}
+	if _, ok := e.specs[interval][key]; !ok {
+		e.specs[interval][key] = spec
+		log.Printf("[StreamingEngine] Registered %s on %s", key, interval)
+	}
+	return nil
+}
+
+// RegisterConfig streams a chart indicator on the given intervals if it is
+// available as a stream; other indicators are left to batch computation
+func (e *Engine) RegisterConfig(config types.IndicatorConfig, intervals []string) {
+	name, params, ok := indicators.StreamConfig(config)
+	if !ok {
+		return
+	}
+	for _, interval := range intervals {
+		if err := e.Register(interval, Spec{Name: name, Params: params}); err != nil {
+			log.Printf("[StreamingEngine] Not streaming %s on %s: %v", config.Name, interval, err)
+		}
+	}
+}
+
+// Source returns the indicator source for a symbol's market data
+func (e *Engine) Source(symbol string) types.IndicatorSource {
+	if e == nil {
+		return nil
+	}
+	return &symbolSource{engine: e, symbol: symbol}
+}
+
+// symbolSource serves one symbol's streamed indicators
+type symbolSource struct {
+	engine *Engine
+	symbol string
+}
+
+// Indicator implements types.IndicatorSource
+func (s *symbolSource) Indicator(interval, name string, params ...float64) ([]float64, int64, bool) {
+	return s.engine.latest(streamID{symbol: s.symbol, interval: interval, key: indicators.StreamKey(name, params...)})
+}
+
+// latest returns the values of a registered indicator, creating its state if needed
+func (e *Engine) latest(id streamID) ([]float64, int64, bool) {
+	e.mu.RLock()
+	if state, ok := e.streams[id]; ok {
+		values, ready := state.stream.Values()
+		openTime := state.openTime
+		e.mu.RUnlock()
+		return values, openTime, ready
+	}
+	e.mu.RUnlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	state := e.stream(id)
+	if state == nil {
+		return nil, 0, false
+	}
+	values, ready := state.stream.Values()
+	return values, state.openTime, ready
+}
+
+// stream returns the state of a registered indicator, warming it up from the kline cache
+// Returns nil if the indicator isn't registered. Callers hold e.mu for writing
+func (e *Engine) stream(id streamID) *symbolStream {
+	if state, ok := e.streams[id]; ok {
+		return state
+	}
+	spec, ok := e.specs[id.interval][id.key]
+	if !ok {
+		return nil
+	}
+
+	stream, err := indicators.NewStream(spec.Name, spec.Params)
+	if err != nil {
+		return nil // Validated on registration
+	}
+	state := &symbolStream{stream: stream}
+	e.warmup(state, id.symbol, id.interval)
+	e.streams[id] = state
+	return state
+}
+
+// warmup replays the cached closed klines of a symbol into a stream
+func (e *Engine) warmup(state *symbolStream, symbol, interval string) {
+	klines, err := e.cache.Get(symbol, interval, warmupLimit)
+	if err != nil {
+		return // Nothing cached yet; the stream starts with the next candle
+	}
+
+	now := time.Now().UnixMilli()
+	for _, kline := range klines {
+		// Bootstrapped data ends with the candle that is still open
+		if kline.CloseTime >= now {
+			break
+		}
+		state.stream.Update(kline)
+		state.openTime = kline.OpenTime
+	}
+}
+
+// candleCloseEventLoop applies closed candles to the registered indicators
+func (e *Engine) candleCloseEventLoop(candleCloseCh <-chan *eventbus.CandleCloseEvent) {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+
+		case event, ok := <-candleCloseCh:
+			if !ok {
+				log.Printf("[StreamingEngine] Candle close channel closed")
+				return
+			}
+
+			e.handleCandleClose(event)
+		}
+	}
+}
+
+// handleCandleClose updates every indicator registered on the event's interval
+func (e *Engine) handleCandleClose(event *eventbus.CandleCloseEvent) {
+	step, err := scheduler.ParseInterval(event.Interval)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key := range e.specs[event.Interval] {
+		id := streamID{symbol: event.Symbol, interval: event.Interval, key: key}
+
+		state, ok := e.streams[id]
+		if !ok {
+			// New state is warmed up from the cache, which already has this candle
+			e.stream(id)
+			continue
+		}
+
+		switch {
+		case event.Kline.OpenTime <= state.openTime:
+			// Already applied
+		case state.openTime != 0 && event.Kline.OpenTime != state.openTime+step.Milliseconds():
+			// Candles were missed; rebuild from the cache rather than skip them
+			log.Printf("[StreamingEngine] Gap in %s@%s %s, rebuilding from cache", event.Symbol, event.Interval, key)
+			delete(e.streams, id)
+			e.stream(id)
+		default:
+			state.stream.Update(event.Kline)
+			state.openTime = event.Kline.OpenTime
+		}
+	}
+}
+
+// registeredCount returns the number of registered indicator/interval pairs
+func (e *Engine) registeredCount() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	count := 0
+	for _, specs := range e.specs {
+		count += len(specs)
+	}
+	return count
+}
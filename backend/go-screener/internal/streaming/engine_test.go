@@ -0,0 +1,114 @@
+package streaming
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/vyx/go-screener/internal/eventbus"
+	"github.com/vyx/go-screener/pkg/cache"
+	"github.com/vyx/go-screener/pkg/indicators"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// createKlines returns closed 1m klines ending an hour ago
+func createKlines(count int) []types.Kline {
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute).UnixMilli() - int64(count)*60000
+	klines := make([]types.Kline, count)
+	for i := range klines {
+		price := 100 + 5*math.Sin(float64(i)/3)
+		openTime := start + int64(i)*60000
+		klines[i] = types.Kline{
+			OpenTime:  openTime,
+			Open:      price,
+			High:      price + 1,
+			Low:       price - 1,
+			Close:     price + 0.2,
+			CloseTime: openTime + 59999,
+		}
+	}
+	return klines
+}
+
+func TestEngine_UpdatesOnCandleClose(t *testing.T) {
+	klines := createKlines(101)
+	klineCache := cache.NewKlineCache(500)
+	klineCache.Set("BTCUSDT", "1m", klines[:100])
+
+	engine := NewEngine(eventbus.NewEventBus(), klineCache)
+	if err := engine.Register("1m", Spec{Name: indicators.StreamEMA, Params: []float64{20}}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	source := engine.Source("BTCUSDT")
+	values, openTime, ok := source.Indicator("1m", indicators.StreamEMA, 20)
+	if !ok || openTime != klines[99].OpenTime {
+		t.Fatalf("warmed up stream = %v at %d, want ready at %d", values, openTime, klines[99].OpenTime)
+	}
+	if want := *indicators.CalculateEMA(klines[:100], 20); math.Abs(values[0]-want) > 1e-9 {
+		t.Errorf("warmed up EMA = %v, want %v", values[0], want)
+	}
+
+	// A closed candle is applied once
+	klineCache.Update("BTCUSDT", "1m", klines[100])
+	event := &eventbus.CandleCloseEvent{Symbol: "BTCUSDT", Interval: "1m", Kline: klines[100]}
+	engine.handleCandleClose(event)
+	engine.handleCandleClose(event)
+
+	values, openTime, _ = source.Indicator("1m", indicators.StreamEMA, 20)
+	if openTime != klines[100].OpenTime {
+		t.Errorf("openTime = %d, want %d", openTime, klines[100].OpenTime)
+	}
+	if want := *indicators.CalculateEMA(klines, 20); math.Abs(values[0]-want) > 1e-9 {
+		t.Errorf("EMA after close = %v, want %v", values[0], want)
+	}
+
+	// Unregistered indicators are left to batch computation
+	if _, _, ok := source.Indicator("1m", indicators.StreamRSI, 14); ok {
+		t.Error("unregistered indicator should not be served")
+	}
+	if _, _, ok := source.Indicator("5m", indicators.StreamEMA, 20); ok {
+		t.Error("indicator registered on another interval should not be served")
+	}
+}
+
+func TestEngine_RebuildsAfterGap(t *testing.T) {
+	klines := createKlines(60)
+	klineCache := cache.NewKlineCache(500)
+	klineCache.Set("ETHUSDT", "1m", klines[:50])
+
+	engine := NewEngine(eventbus.NewEventBus(), klineCache)
+	if err := engine.Register("1m", Spec{Name: indicators.StreamSMA, Params: []float64{10}}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	source := engine.Source("ETHUSDT")
+	source.Indicator("1m", indicators.StreamSMA, 10)
+
+	// Candles 50-58 never arrived as events but were backfilled into the cache
+	klineCache.Set("ETHUSDT", "1m", klines)
+	engine.handleCandleClose(&eventbus.CandleCloseEvent{Symbol: "ETHUSDT", Interval: "1m", Kline: klines[59]})
+
+	values, openTime, ok := source.Indicator("1m", indicators.StreamSMA, 10)
+	if !ok || openTime != klines[59].OpenTime {
+		t.Fatalf("stream at %d, want %d", openTime, klines[59].OpenTime)
+	}
+	if want := *indicators.CalculateMA(klines, 10); math.Abs(values[0]-want) > 1e-9 {
+		t.Errorf("SMA after gap = %v, want %v", values[0], want)
+	}
+}
+
+func TestEngine_RegisterRejectsUnknown(t *testing.T) {
+	engine := NewEngine(eventbus.NewEventBus(), cache.NewKlineCache(10))
+
+	if err := engine.Register("1m", Spec{Name: "VWAP"}); err == nil {
+		t.Error("indicator without a stream should be rejected")
+	}
+	if err := engine.Register("7x", Spec{Name: indicators.StreamEMA, Params: []float64{20}}); err == nil {
+		t.Error("unknown interval should be rejected")
+	}
+
+	var missing *Engine
+	if missing.Source("BTCUSDT") != nil {
+		t.Error("nil engine should have no source")
+	}
+}
@@ -0,0 +1,94 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Default resource budgets for a trader's filter and series code
+const (
+	DefaultMaxAllocBytesPerRun  int64 = 64 << 20 // 64 MB
+	DefaultMaxCPUTimePerRun           = 10 * time.Second
+	DefaultMaxAllocBytesPerHour int64 = 2 << 30 // 2 GB
+	DefaultMaxCPUTimePerHour          = 5 * time.Minute
+)
+
+// usageWindow is the period hourly budgets are accounted over
+const usageWindow = time.Hour
+
+// ResourceUsage is the resource consumption charged to a trader's user code
+type ResourceUsage struct {
+	AllocBytes int64         `json:"alloc_bytes"` // Approximate, see usageMeter
+	CPUTime    time.Duration `json:"cpu_time"`
+}
+
+// usageMeter measures the user code cost of a single trader run
+// CPU time is the time workers spend inside filter and series code, which is
+// CPU-bound interpreted code. Go has no per-goroutine allocation counters, and
+// process-wide ones would charge traders for each other's work, so allocations are
+// approximated by the JSON size of what the code hands back: filter results and
+// indicator series. Short-lived garbage isn't counted; the CPU budget bounds it
+type usageMeter struct {
+	allocBytes int64 // atomic
+	cpuNanos   int64 // atomic
+}
+
+// newUsageMeter starts measuring a run
+func newUsageMeter() *usageMeter {
+	return &usageMeter{}
+}
+
+// track runs user code and charges the time spent in it to the run
+func (m *usageMeter) track(fn func()) {
+	start := time.Now()
+	fn()
+	atomic.AddInt64(&m.cpuNanos, int64(time.Since(start)))
+}
+
+// charge charges a value returned by user code to the run's allocations
+func (m *usageMeter) charge(v interface{}) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	atomic.AddInt64(&m.allocBytes, int64(len(encoded)))
+}
+
+// usage returns the resources used by the run so far
+func (m *usageMeter) usage() ResourceUsage {
+	return ResourceUsage{
+		AllocBytes: atomic.LoadInt64(&m.allocBytes),
+		CPUTime:    time.Duration(atomic.LoadInt64(&m.cpuNanos)),
+	}
+}
+
+// checkRunBudget reports the first per-run budget the usage exceeds
+// Returns the budget name (for metrics) and a descriptive error, or an empty name and nil
+func (c *TraderConfig) checkRunBudget(u ResourceUsage) (string, error) {
+	return checkBudget(u, c.MaxAllocBytesPerRun, c.MaxCPUTimePerRun, "run", "in one run")
+}
+
+// checkHourlyBudget reports the first hourly budget the usage exceeds
+func (c *TraderConfig) checkHourlyBudget(u ResourceUsage) (string, error) {
+	return checkBudget(u, c.MaxAllocBytesPerHour, c.MaxCPUTimePerHour, "hour", "in the last hour")
+}
+
+// checkBudget compares usage against limits; zero limits are unlimited
+func checkBudget(u ResourceUsage, maxAlloc int64, maxCPU time.Duration, period, during string) (string, error) {
+	if maxAlloc > 0 && u.AllocBytes > maxAlloc {
+		return "alloc_per_" + period, fmt.Errorf("allocation budget exceeded: user code returned %s of results %s (limit %s)",
+			formatBytes(u.AllocBytes), during, formatBytes(maxAlloc))
+	}
+	if maxCPU > 0 && u.CPUTime > maxCPU {
+		return "cpu_per_" + period, fmt.Errorf("CPU budget exceeded: user code ran for %v %s (limit %v)",
+			u.CPUTime.Round(time.Millisecond), during, maxCPU)
+	}
+	return "", nil
+}
+
+// formatBytes renders a byte count in MB
+func formatBytes(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
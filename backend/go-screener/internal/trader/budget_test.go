@@ -0,0 +1,148 @@
+package trader
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
+	"github.com/vyx/go-screener/pkg/yaegi"
+)
+
+func TestTraderConfig_CheckRunBudget(t *testing.T) {
+	config := &TraderConfig{
+		MaxAllocBytesPerRun: 100 << 20,
+		MaxCPUTimePerRun:    2 * time.Second,
+	}
+
+	tests := []struct {
+		name       string
+		usage      ResourceUsage
+		wantBudget string
+	}{
+		{"within budget", ResourceUsage{AllocBytes: 50 << 20, CPUTime: time.Second}, ""},
+		{"alloc exceeded", ResourceUsage{AllocBytes: 150 << 20, CPUTime: time.Second}, "alloc_per_run"},
+		{"cpu exceeded", ResourceUsage{AllocBytes: 50 << 20, CPUTime: 3 * time.Second}, "cpu_per_run"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget, err := config.checkRunBudget(tt.usage)
+			if budget != tt.wantBudget {
+				t.Errorf("budget = %q, want %q", budget, tt.wantBudget)
+			}
+			if (err != nil) != (tt.wantBudget != "") {
+				t.Errorf("err = %v, want error: %v", err, tt.wantBudget != "")
+			}
+		})
+	}
+
+	// Zero limits are unlimited
+	unlimited := &TraderConfig{}
+	if budget, err := unlimited.checkHourlyBudget(ResourceUsage{AllocBytes: 1 << 40, CPUTime: time.Hour}); err != nil {
+		t.Errorf("unlimited budget exceeded: %s %v", budget, err)
+	}
+}
+
+func TestTrader_RecordUsage(t *testing.T) {
+	trader := NewTrader("test-id", "user-123", "Test", "Test", &TraderConfig{})
+
+	trader.RecordUsage(ResourceUsage{AllocBytes: 10, CPUTime: time.Second})
+	total := trader.RecordUsage(ResourceUsage{AllocBytes: 5, CPUTime: time.Second})
+	if total.AllocBytes != 15 || total.CPUTime != 2*time.Second {
+		t.Errorf("hourly usage = %+v, want 15 bytes / 2s", total)
+	}
+
+	// Usage from an expired window is dropped
+	trader.mu.Lock()
+	trader.usageWindowStart = time.Now().Add(-usageWindow)
+	trader.mu.Unlock()
+
+	total = trader.RecordUsage(ResourceUsage{AllocBytes: 1, CPUTime: time.Millisecond})
+	if total.AllocBytes != 1 || total.CPUTime != time.Millisecond {
+		t.Errorf("hourly usage = %+v, want only the latest run", total)
+	}
+}
+
+func TestUsageMeter(t *testing.T) {
+	meter := newUsageMeter()
+
+	meter.track(func() {
+		time.Sleep(5 * time.Millisecond)
+	})
+	meter.charge(map[string]interface{}{"rsi": []float64{1, 2, 3}})
+
+	usage := meter.usage()
+	if usage.AllocBytes != int64(len(`{"rsi":[1,2,3]}`)) {
+		t.Errorf("AllocBytes = %d, want the encoded result size", usage.AllocBytes)
+	}
+	if usage.CPUTime < 5*time.Millisecond {
+		t.Errorf("CPUTime = %v, want at least 5ms", usage.CPUTime)
+	}
+}
+
+func TestExecutor_EnforceBudgets(t *testing.T) {
+	executor := &Executor{traders: make(map[string]*Trader)}
+	trader := NewTrader("test-id", "user-123", "Test", "Test", &TraderConfig{
+		MaxCPUTimePerRun:  time.Second,
+		MaxCPUTimePerHour: 2500 * time.Millisecond,
+	})
+	_ = trader.TransitionTo(StateStarting)
+	_ = trader.TransitionTo(StateRunning)
+
+	if executor.enforceBudgets(trader, ResourceUsage{CPUTime: 900 * time.Millisecond}) {
+		t.Fatal("trader stopped within budget")
+	}
+	if executor.enforceBudgets(trader, ResourceUsage{CPUTime: 900 * time.Millisecond}) {
+		t.Fatal("trader stopped within budget")
+	}
+
+	// Each run is within its budget, but the hourly total is not
+	if !executor.enforceBudgets(trader, ResourceUsage{CPUTime: 900 * time.Millisecond}) {
+		t.Fatal("trader not stopped after exceeding its budget")
+	}
+
+	if trader.GetState() != StateError {
+		t.Errorf("state = %s, want %s", trader.GetState(), StateError)
+	}
+	if err := trader.GetLastError(); err == nil || !strings.Contains(err.Error(), "in the last hour") {
+		t.Errorf("lastError = %v, want hourly resource budget error", err)
+	}
+}
+
+func TestExecutor_BudgetExceededByFilter(t *testing.T) {
+	yaegiExec, err := yaegi.NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+	executor := NewExecutor(yaegiExec, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	trader := NewTrader("test-id", "user-123", "Test", "Test", &TraderConfig{
+		FilterCode: `import "time"
+	start := time.Now()
+	for time.Since(start) < 30*time.Millisecond {
+	}
+	return true
+`,
+		TimeoutPerRun:    time.Second,
+		MaxCPUTimePerRun: 20 * time.Millisecond,
+	})
+	_ = trader.TransitionTo(StateStarting)
+	_ = trader.TransitionTo(StateRunning)
+
+	meter := newUsageMeter()
+	tickers := map[string]*types.SimplifiedTicker{"BTCUSDT": {LastPrice: 100}}
+	if _, err := executor.processSymbol(context.Background(), "BTCUSDT", trader, nil, tickers, nil, "5m", meter, nil); err != nil {
+		t.Fatalf("processSymbol failed: %v", err)
+	}
+
+	if !executor.enforceBudgets(trader, meter.usage()) {
+		t.Fatal("trader not stopped after its filter exceeded the CPU budget")
+	}
+	if trader.GetState() != StateError {
+		t.Errorf("state = %s, want %s", trader.GetState(), StateError)
+	}
+	if err := trader.GetLastError(); err == nil || !strings.Contains(err.Error(), "CPU budget exceeded") || !strings.Contains(err.Error(), "in one run") {
+		t.Errorf("lastError = %v, want per-run CPU budget error", err)
+	}
+}
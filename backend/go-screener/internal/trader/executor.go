@@ -2,23 +2,34 @@ package trader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/vyx/go-screener/internal/analysis"
 	"github.com/vyx/go-screener/internal/eventbus"
 	"github.com/vyx/go-screener/internal/screener"
+	"github.com/vyx/go-screener/internal/streaming"
 	"github.com/vyx/go-screener/pkg/binance"
 	"github.com/vyx/go-screener/pkg/cache"
+	"github.com/vyx/go-screener/pkg/indicators"
 	"github.com/vyx/go-screener/pkg/supabase"
 	"github.com/vyx/go-screener/pkg/types"
 	"github.com/vyx/go-screener/pkg/yaegi"
 )
 
+// timeoutQuarantineThreshold is the number of consecutive runs with filter timeouts
+// after which a trader is removed from execution and put into the error state
+const timeoutQuarantineThreshold = 3
+
+// seriesTimeout bounds the indicator series calculation for a matched symbol
+const seriesTimeout = 5 * time.Second
+
 // Executor runs trader filter code and generates signals
 // EVENT-DRIVEN: Subscribes to candle events instead of timer-based execution
 type Executor struct {
@@ -29,6 +40,11 @@ type Executor struct {
 	analysisEng  AnalysisEngine
 	eventBus     *eventbus.EventBus
 	cache        *cache.KlineCache // WebSocket-fed kline cache
+	states       *yaegi.StateStore // Per-symbol filter state
+	streams      *streaming.Engine // Streamed indicator state (optional)
+	depth        *binance.DepthStream // Order book features (optional)
+	tape         *binance.Tape        // Large trades (optional)
+	futures      *binance.FuturesFeed // Futures positioning (optional)
 
 	ctx          context.Context
 	cancel       context.CancelFunc
@@ -52,11 +68,16 @@ func NewExecutor(
 	analysisEng AnalysisEngine,
 	eventBus *eventbus.EventBus,
 	cache *cache.KlineCache,
+	states *yaegi.StateStore,
+	streams *streaming.Engine,
+	depth *binance.DepthStream,
+	tape *binance.Tape,
+	futures *binance.FuturesFeed,
 ) *Executor {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Create series executor with 5 second timeout
-	seriesExec := screener.NewSeriesExecutor(5 * time.Second)
+	// Create series executor, sandboxed like filter code
+	seriesExec := screener.NewSeriesExecutorWithPolicy(seriesTimeout, yaegi.Policy())
 
 	return &Executor{
 		yaegi:       yaegi,
@@ -66,6 +87,11 @@ func NewExecutor(
 		analysisEng: analysisEng,
 		eventBus:    eventBus,
 		cache:       cache,
+		states:      states,
+		streams:     streams,
+		depth:       depth,
+		tape:        tape,
+		futures:     futures,
 		ctx:         ctx,
 		cancel:      cancel,
 		traders:     make(map[string]*Trader),
@@ -113,29 +139,111 @@ func (e *Executor) AddTrader(trader *Trader) error {
 		return fmt.Errorf("trader filter code is empty")
 	}
 
-	// Validate filter code before adding
-	if err := e.yaegi.ValidateCode(trader.Config.FilterCode); err != nil {
+	// Compile filter code before adding (validates it and warms the compiled filter cache)
+	if _, err := e.yaegi.CompileFilter(trader.Config.FilterCode); err != nil {
 		return fmt.Errorf("invalid filter code: %w", err)
 	}
 
-	// Add to active traders
+	// Pick up the filter state persisted by previous runs
+	if err := e.states.Restore(e.ctx, trader.ID); err != nil {
+		log.Printf("[Executor] Trader %s: %v", trader.ID, err)
+	}
+
+	// Stream the trader's indicators so runs read them instead of recomputing
+	for _, indicator := range trader.Config.Indicators {
+		e.streams.RegisterConfig(indicator, trader.Config.Timeframes)
+	}
+
+	e.deriveTimeframes(trader)
+
+	// Add to active traders, replacing any previous version
 	e.tradersMu.Lock()
+	previous, replaced := e.traders[trader.ID]
 	e.traders[trader.ID] = trader
 	e.tradersMu.Unlock()
 
+	if replaced && previous != trader && previous.Config.FilterCode != trader.Config.FilterCode {
+		e.releaseFilter(previous.Config.FilterCode)
+	}
+
 	log.Printf("[Executor] Added trader %s", trader.ID)
 	return nil
 }
 
+// deriveTimeframes has the cache resample the trader's timeframes, and the sources of
+// its bar timeframes, from its base interval so runs read them from the cache instead
+// of fetching them over REST
+func (e *Executor) deriveTimeframes(trader *Trader) {
+	if e.cache == nil {
+		return
+	}
+	for _, tf := range trader.Config.Timeframes {
+		interval := tf
+		if cache.IsBarInterval(tf) {
+			spec, err := cache.ParseBarInterval(tf)
+			if err != nil {
+				continue
+			}
+			interval = spec.Source
+		}
+		if err := e.cache.Derive(interval); err != nil {
+			log.Printf("[Executor] Trader %s: %s klines are fetched over REST: %v", trader.ID, interval, err)
+		}
+	}
+}
+
 // RemoveTrader removes a trader from the executor
 func (e *Executor) RemoveTrader(traderID string) {
 	e.tradersMu.Lock()
+	trader, exists := e.traders[traderID]
 	delete(e.traders, traderID)
 	e.tradersMu.Unlock()
 
+	if exists {
+		e.releaseFilter(trader.Config.FilterCode)
+	}
+
+	// Persist what the filter remembered, then free the memory
+	e.flushState(traderID)
+	e.states.Drop(traderID)
+
 	log.Printf("[Executor] Removed trader %s", traderID)
 }
 
+// flushState persists the trader's changed filter state
+func (e *Executor) flushState(traderID string) {
+	if err := e.states.Flush(e.ctx, traderID); err != nil {
+		log.Printf("[Executor] Trader %s: %v", traderID, err)
+	}
+}
+
+// FilterState returns the trader's filter state by symbol
+func (e *Executor) FilterState(traderID string) map[string]map[string]interface{} {
+	if err := e.states.Restore(e.ctx, traderID); err != nil {
+		log.Printf("[Executor] Trader %s: %v", traderID, err)
+	}
+	return e.states.Snapshot(traderID)
+}
+
+// ResetFilterState clears the trader's filter state for a symbol, or all symbols if symbol is empty
+func (e *Executor) ResetFilterState(traderID, symbol string) error {
+	return e.states.Reset(e.ctx, traderID, symbol)
+}
+
+// releaseFilter drops a compiled filter from the cache unless another active trader still uses it
+func (e *Executor) releaseFilter(code string) {
+	e.tradersMu.RLock()
+	for _, t := range e.traders {
+		if t.Config.FilterCode == code {
+			e.tradersMu.RUnlock()
+			return
+		}
+	}
+	e.tradersMu.RUnlock()
+
+	e.yaegi.InvalidateFilter(code)
+}
+
 // candleEventLoop processes candle events and triggers traders
 func (e *Executor) candleEventLoop(candleCh <-chan *eventbus.CandleEvent) {
 	defer e.wg.Done()
@@ -162,14 +270,17 @@ func (e *Executor) candleEventLoop(candleCh <-chan *eventbus.CandleEvent) {
 // handleCandleEvent processes a single candle event
 func (e *Executor) handleCandleEvent(event *eventbus.CandleEvent) {
 	// Find traders matching this interval
+	// Traders on alternative bars (ha:5m, renko:atr14) run on their source interval
 	e.tradersMu.RLock()
 	matchingTraders := make([]*Trader, 0)
+	triggerIntervals := make([]string, 0)
 	for _, trader := range e.traders {
 		// Match traders that use this interval
 		if trader.Config.Timeframes != nil {
 			for _, tf := range trader.Config.Timeframes {
-				if tf == event.Interval {
+				if tf == event.Interval || barSource(tf) == event.Interval {
 					matchingTraders = append(matchingTraders, trader)
+					triggerIntervals = append(triggerIntervals, tf)
 					break
 				}
 			}
@@ -185,10 +296,22 @@ func (e *Executor) handleCandleEvent(event *eventbus.CandleEvent) {
 		event.Interval, len(matchingTraders))
 
 	// Execute each matching trader
-	for _, trader := range matchingTraders {
+	for i, trader := range matchingTraders {
 		// Execute in goroutine to avoid blocking
-		go e.executeTrader(trader, event.Interval)
+		go e.executeTrader(trader, triggerIntervals[i])
+	}
+}
+
+// barSource returns the source interval of an alternative bar timeframe, "" for klines
+func barSource(timeframe string) string {
+	if !cache.IsBarInterval(timeframe) {
+		return ""
+	}
+	spec, err := cache.ParseBarInterval(timeframe)
+	if err != nil {
+		return ""
 	}
+	return spec.Source
 }
 
 // executeTrader executes a single trader's filter
@@ -258,6 +381,12 @@ func (e *Executor) executeTrader(trader *Trader, triggerInterval string) {
 	workerCtx, workerCancel := context.WithCancel(e.ctx)
 	defer workerCancel()
 
+	// Count filter timeouts for quarantine decisions
+	var timeouts int64
+
+	// Measure user code resource usage against the trader's budgets
+	meter := newUsageMeter()
+
 	// Start worker pool
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
@@ -273,12 +402,24 @@ func (e *Executor) executeTrader(trader *Trader, triggerInterval string) {
 				default:
 				}
 
+				// Stop early once the run has exceeded its resource budget
+				if budget, _ := trader.Config.checkRunBudget(meter.usage()); budget != "" {
+					workerCancel()
+					return
+				}
+
 				log.Printf("[Executor] Worker %d processing symbol %s", workerID, symbol)
 
 				// Process symbol
-				signal, err := e.processSymbol(workerCtx, symbol, trader, klineData, tickerData, timeframes, triggerInterval)
+				signal, err := e.processSymbol(workerCtx, symbol, trader, klineData, tickerData, timeframes, triggerInterval, meter, nil)
+				if errors.Is(err, context.Canceled) {
+					return // The run ended early
+				}
 				if err != nil {
 					log.Printf("[Executor] Worker %d: Error processing %s: %v", workerID, symbol, err)
+					if errors.Is(err, yaegi.ErrExecutionTimeout) {
+						atomic.AddInt64(&timeouts, 1)
+					}
 					errorCh <- err
 					continue
 				}
@@ -335,6 +476,19 @@ func (e *Executor) executeTrader(trader *Trader, triggerInterval string) {
 
 	log.Printf("[Executor] 🔍 Step 4: Parallel processing complete, generated %d signals", len(signals))
 
+	// Persist state written by the filter during this run
+	e.flushState(trader.ID)
+
+	// Quarantine traders whose filter keeps timing out
+	if e.recordTimeouts(trader, atomic.LoadInt64(&timeouts)) {
+		return
+	}
+
+	// Stop traders whose code exceeds its CPU or memory budget
+	if e.enforceBudgets(trader, meter.usage()) {
+		return
+	}
+
 	// Process signals: save to DB and queue for analysis
 	log.Printf("[Executor] 🔍 Step 4.1: Checking signal count: %d", len(signals))
 	if len(signals) > 0 {
@@ -367,19 +521,22 @@ func (e *Executor) executeTrader(trader *Trader, triggerInterval string) {
 
 // ExecutionResult holds the result of immediate trader execution
 type ExecutionResult struct {
-	TraderID       string    `json:"traderId"`
-	Timestamp      time.Time `json:"timestamp"`
-	TotalSymbols   int       `json:"totalSymbols"`
-	MatchCount     int       `json:"matchCount"`
-	Signals        []Signal  `json:"signals"`
-	ExecutionTime  int64     `json:"executionTimeMs"`
-	CacheHits      int       `json:"cacheHits"`
-	CacheMisses    int       `json:"cacheMisses"`
+	TraderID      string        `json:"traderId"`
+	Timestamp     time.Time     `json:"timestamp"`
+	TotalSymbols  int           `json:"totalSymbols"`
+	MatchCount    int           `json:"matchCount"`
+	Signals       []Signal      `json:"signals"`
+	ExecutionTime int64         `json:"executionTimeMs"`
+	CacheHits     int           `json:"cacheHits"`
+	CacheMisses   int           `json:"cacheMisses"`
+	Traces        []yaegi.Trace `json:"traces,omitempty"` // Per-symbol filter traces of traced runs
 }
 
 // ExecuteImmediate executes a trader immediately using cached candle data
 // This is used for immediate signal generation after trader creation
-func (e *Executor) ExecuteImmediate(traderID string) (*ExecutionResult, error) {
+// With trace set, the filter runs in trace mode and the result includes a trace per symbol;
+// traced runs are dry runs that save no signals or state and never quarantine the trader
+func (e *Executor) ExecuteImmediate(traderID string, trace bool) (*ExecutionResult, error) {
 	startTime := time.Now()
 	log.Printf("[Executor] ExecuteImmediate: Starting immediate execution for trader %s", traderID)
 
@@ -444,6 +601,18 @@ func (e *Executor) ExecuteImmediate(traderID string) (*ExecutionResult, error) {
 	workerCtx, workerCancel := context.WithCancel(e.ctx)
 	defer workerCancel()
 
+	var timeouts int64
+	meter := newUsageMeter()
+
+	// Traced runs use a separately compiled, instrumented filter
+	var tracer *yaegi.Tracer
+	if trace {
+		tracer, err = e.yaegi.NewTracer(trader.Config.FilterCode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile filter for tracing: %w", err)
+		}
+	}
+
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
@@ -456,9 +625,21 @@ func (e *Executor) ExecuteImmediate(traderID string) (*ExecutionResult, error) {
 				default:
 				}
 
+				// Traced runs wait their turn inside the tracer, so only real runs are budgeted
+				if budget, _ := trader.Config.checkRunBudget(meter.usage()); budget != "" && !trace {
+					workerCancel()
+					return
+				}
+
 				// Process symbol using existing method
-				signal, err := e.processSymbol(workerCtx, symbol, trader, klineData, tickerData, timeframes, triggerInterval)
+				signal, err := e.processSymbol(workerCtx, symbol, trader, klineData, tickerData, timeframes, triggerInterval, meter, tracer)
+				if errors.Is(err, context.Canceled) {
+					return
+				}
 				if err != nil {
+					if errors.Is(err, yaegi.ErrExecutionTimeout) {
+						atomic.AddInt64(&timeouts, 1)
+					}
 					errorCh <- err
 					continue
 				}
@@ -488,8 +669,21 @@ func (e *Executor) ExecuteImmediate(traderID string) (*ExecutionResult, error) {
 	}
 	log.Printf("[Executor] ExecuteImmediate: Generated %d signals", len(signals))
 
+	// Traced runs persist nothing and aren't budgeted; their signals and timeouts are only reported
+	if !trace {
+		e.flushState(trader.ID)
+
+		if e.recordTimeouts(trader, timeouts) {
+			return nil, fmt.Errorf("trader %s quarantined: %w", traderID, trader.GetLastError())
+		}
+
+		if e.enforceBudgets(trader, meter.usage()) {
+			return nil, fmt.Errorf("trader %s stopped: %w", traderID, trader.GetLastError())
+		}
+	}
+
 	// Save signals to database (triggers AI analysis via DB trigger)
-	if len(signals) > 0 {
+	if len(signals) > 0 && !trace {
 		log.Printf("[Executor] ExecuteImmediate: Saving %d signals to database", len(signals))
 		if err := e.saveSignals(signals); err != nil {
 			return nil, fmt.Errorf("failed to save signals: %w", err)
@@ -501,7 +695,7 @@ func (e *Executor) ExecuteImmediate(traderID string) (*ExecutionResult, error) {
 	log.Printf("[Executor] ExecuteImmediate: Completed in %dms", executionTime)
 
 	// Return execution result
-	return &ExecutionResult{
+	result := &ExecutionResult{
 		TraderID:      traderID,
 		Timestamp:     startTime,
 		TotalSymbols:  len(symbols),
@@ -510,7 +704,64 @@ func (e *Executor) ExecuteImmediate(traderID string) (*ExecutionResult, error) {
 		ExecutionTime: executionTime,
 		CacheHits:     0, // TODO: Track cache hits if needed
 		CacheMisses:   0, // TODO: Track cache misses if needed
-	}, nil
+	}
+	if tracer != nil {
+		result.Traces = tracer.Traces()
+	}
+	return result, nil
+}
+
+// recordTimeouts tracks runs in which the trader's filter timed out and quarantines
+// the trader once it has timed out in timeoutQuarantineThreshold consecutive runs
+// Returns true if the trader was quarantined
+func (e *Executor) recordTimeouts(trader *Trader, timeouts int64) bool {
+	if timeouts > 0 {
+		log.Printf("[Executor] Trader %s: %d filter executions timed out", trader.ID, timeouts)
+		RecordExecutionError(trader.ID, "timeout")
+	}
+
+	strikes := trader.RecordTimeoutRun(timeouts > 0)
+	if strikes < timeoutQuarantineThreshold {
+		return false
+	}
+
+	err := fmt.Errorf("quarantined: filter code timed out in %d consecutive runs (timeout %v)", strikes, trader.Config.TimeoutPerRun)
+	log.Printf("[Executor] ⛔ Trader %s %v", trader.ID, err)
+
+	e.RemoveTrader(trader.ID)
+	RecordExecutionError(trader.ID, "quarantined")
+	if setErr := trader.SetError(err); setErr != nil {
+		log.Printf("[Executor] Trader %s: %v", trader.ID, setErr)
+	}
+
+	return true
+}
+
+// enforceBudgets records a run's user code resource usage and moves the trader to
+// the error state if it exceeded its per-run or hourly budget
+// Returns true if the trader was stopped
+func (e *Executor) enforceBudgets(trader *Trader, usage ResourceUsage) bool {
+	RecordResourceUsage(trader.ID, usage)
+	hourly := trader.RecordUsage(usage)
+
+	budget, err := trader.Config.checkRunBudget(usage)
+	if err == nil {
+		budget, err = trader.Config.checkHourlyBudget(hourly)
+	}
+	if err == nil {
+		return false
+	}
+
+	log.Printf("[Executor] ⛔ Trader %s %v", trader.ID, err)
+
+	e.RemoveTrader(trader.ID)
+	RecordBudgetExceeded(trader.ID, budget)
+	RecordExecutionError(trader.ID, "budget_exceeded")
+	if setErr := trader.SetError(err); setErr != nil {
+		log.Printf("[Executor] Trader %s: %v", trader.ID, setErr)
+	}
+
+	return true
 }
 
 // queueSignalsForAnalysis queues signals for AI analysis
@@ -561,7 +812,7 @@ func (e *Executor) queueSignalsForAnalysis(trader *Trader, signals []Signal) err
 			if err != nil {
 				// Cache miss - fallback to REST API
 				log.Printf("[Executor] Cache miss for %s@%s in queueSignalsForAnalysis, falling back to REST", signal.Symbol, tf)
-				klines, err = e.binance.GetKlines(e.ctx, signal.Symbol, tf, 100)
+				klines, err = cache.FetchKlines(e.ctx, e.binance.GetKlines, signal.Symbol, tf, 100)
 				if err != nil {
 					log.Printf("[Executor] Failed to fetch klines for %s@%s: %v", signal.Symbol, tf, err)
 					continue
@@ -583,10 +834,13 @@ func (e *Executor) queueSignalsForAnalysis(trader *Trader, signals []Signal) err
 
 		log.Printf("[Executor] 🔍 queueSignalsForAnalysis: Creating marketData struct...")
 		marketData := &types.MarketData{
-			Symbol:    signal.Symbol,
-			Ticker:    simplifiedTicker,
-			Klines:    klinesMap,
-			Timestamp: time.Now(),
+			Symbol:     signal.Symbol,
+			Ticker:     simplifiedTicker,
+			Klines:     klinesMap,
+			Timestamp:  time.Now(),
+			Indicators: e.streams.Source(signal.Symbol),
+			Book:       e.depth.Features(signal.Symbol),
+			Futures:    e.futures.Context(signal.Symbol),
 		}
 		log.Printf("[Executor] 🔍 queueSignalsForAnalysis: marketData created successfully")
 
@@ -605,6 +859,7 @@ func (e *Executor) queueSignalsForAnalysis(trader *Trader, signals []Signal) err
 			Symbol:       signal.Symbol,
 			Interval:     timeframes[0], // Primary interval
 			MarketData:   marketData,
+			Metadata:     signal.Metadata,
 			Trader:       traderRecord,
 			IsReanalysis: false,
 			QueuedAt:     time.Now(),
@@ -670,7 +925,7 @@ func (e *Executor) fetchKlineData(symbols []string, timeframes []string) (map[st
 				cacheMisses++
 				log.Printf("[Executor] Cache miss for %s@%s, falling back to REST", symbol, timeframe)
 
-				klines, err = e.binance.GetKlines(e.ctx, symbol, timeframe, limit)
+				klines, err = cache.FetchKlines(e.ctx, e.binance.GetKlines, symbol, timeframe, limit)
 				if err != nil {
 					log.Printf("[Executor] Failed to fetch klines for %s@%s: %v", symbol, timeframe, err)
 					continue
@@ -691,9 +946,30 @@ func (e *Executor) fetchKlineData(symbols []string, timeframes []string) (map[st
 	return result, nil
 }
 
+// footprints returns the symbol's footprint candles on the given timeframes, nil if none are built
+func (e *Executor) footprints(symbol string, timeframes []string) map[string][]types.Footprint {
+	if e.cache == nil {
+		return nil
+	}
+
+	var result map[string][]types.Footprint
+	for _, tf := range timeframes {
+		footprints, err := e.cache.GetFootprints(symbol, tf, cache.DefaultFootprintCandles)
+		if err != nil {
+			continue
+		}
+		if result == nil {
+			result = make(map[string][]types.Footprint)
+		}
+		result[tf] = footprints
+	}
+	return result
+}
+
 // processSymbol processes a single symbol through the filter
+// Time spent in filter and series code, and the size of their results, are charged to meter
 // Returns a signal if the filter matches, nil otherwise
-func (e *Executor) processSymbol(ctx context.Context, symbol string, trader *Trader, klineData map[string]map[string][]types.Kline, tickerData map[string]*types.SimplifiedTicker, timeframes []string, triggerInterval string) (*Signal, error) {
+func (e *Executor) processSymbol(ctx context.Context, symbol string, trader *Trader, klineData map[string]map[string][]types.Kline, tickerData map[string]*types.SimplifiedTicker, timeframes []string, triggerInterval string, meter *usageMeter, tracer *yaegi.Tracer) (*Signal, error) {
 	// Check context cancellation
 	select {
 	case <-ctx.Done():
@@ -722,11 +998,23 @@ func (e *Executor) processSymbol(ctx context.Context, symbol string, trader *Tra
 		}
 	}
 
+	// Traced runs are dry runs: the filter sees its state but changes are discarded
+	state := e.states.Get(trader.ID, symbol)
+	if tracer != nil {
+		state = e.states.Copy(trader.ID, symbol)
+	}
+
 	marketData := &types.MarketData{
-		Symbol:    symbol,
-		Ticker:    ticker,
-		Klines:    klinesMap,
-		Timestamp: time.Now(),
+		Symbol:     symbol,
+		Ticker:     ticker,
+		Klines:     klinesMap,
+		Timestamp:  time.Now(),
+		State:      state,
+		Indicators: e.streams.Source(symbol),
+		Book:       e.depth.Features(symbol),
+		Footprints: e.footprints(symbol, timeframes),
+		Tape:       e.tape.Source(symbol),
+		Futures:    e.futures.Context(symbol),
 	}
 
 	// Execute filter with timeout
@@ -735,13 +1023,28 @@ func (e *Executor) processSymbol(ctx context.Context, symbol string, trader *Tra
 		timeout = 1 * time.Second // Default: 1 second
 	}
 
-	matches, err := e.yaegi.ExecuteFilterWithTimeout(trader.Config.FilterCode, marketData, timeout)
+	var result types.FilterResult
+	var err error
+	if tracer != nil {
+		// Traced runs are serialized, so the timeout starts once the tracer is free
+		meter.track(func() {
+			result, err = tracer.Evaluate(ctx, marketData, timeout)
+		})
+	} else {
+		// Context-aware execution: runaway code is stopped when the timeout expires
+		filterCtx, filterCancel := context.WithTimeout(ctx, timeout)
+		meter.track(func() {
+			result, err = e.yaegi.EvaluateFilterWithContext(filterCtx, trader.Config.FilterCode, marketData)
+		})
+		filterCancel()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("filter execution failed: %w", err)
 	}
+	meter.charge(result)
 
 	// If matches, create signal
-	if matches {
+	if result.Matched {
 		signal := &Signal{
 			ID:          uuid.New().String(),
 			TraderID:    trader.ID,
@@ -751,15 +1054,23 @@ func (e *Executor) processSymbol(ctx context.Context, symbol string, trader *Tra
 			TriggeredAt: time.Now(),
 			Price:       ticker.LastPrice,
 			Volume:      ticker.QuoteVolume,
-			Metadata:    make(map[string]interface{}),
+			Metadata:    result.SignalMetadata(), // Score, tags and values from rich filters
 			CreatedAt:   time.Now(),
 		}
 
 		// Execute series code if available (for indicator visualization)
-		if trader.Config.SeriesCode != "" {
+		// Registry indicators without series code are calculated directly
+		if e.hasSeries(trader) || hasRegistryIndicators(trader) {
 			log.Printf("[Executor] Executing series code for %s", symbol)
 
-			indicatorData, err := e.seriesExec.ExecuteSeriesCode(ctx, trader.Config.SeriesCode, marketData)
+			var indicatorData map[string]interface{}
+			meter.track(func() {
+				indicatorData, err = e.executeSeries(ctx, trader, marketData)
+				if err == nil {
+					addRegistrySeries(indicatorData, trader.Config.Indicators, marketData.Klines[triggerInterval])
+				}
+			})
+			meter.charge(indicatorData)
 			if err != nil {
 				// Log error but don't fail signal creation (graceful degradation)
 				log.Printf("[Executor] Series code execution failed for %s: %v", symbol, err)
@@ -792,6 +1103,65 @@ func (e *Executor) processSymbol(ctx context.Context, symbol string, trader *Tra
 	return nil, nil
 }
 
+// hasSeries reports whether the trader produces indicator series for its signals
+func (e *Executor) hasSeries(trader *Trader) bool {
+	if trader.Config.SeriesCode != "" {
+		return true
+	}
+	if !yaegi.IsModule(trader.Config.FilterCode) {
+		return false
+	}
+	ok, err := e.yaegi.HasSeries(trader.Config.FilterCode)
+	return err == nil && ok
+}
+
+// executeSeries calculates indicator series for a matched symbol
+// Strategy modules with calculateSeries run it on the same compilation and market
+// data as the filter, reusing what the filter computed; otherwise SeriesCode runs
+func (e *Executor) executeSeries(ctx context.Context, trader *Trader, marketData *types.MarketData) (map[string]interface{}, error) {
+	if yaegi.IsModule(trader.Config.FilterCode) {
+		seriesCtx, cancel := context.WithTimeout(ctx, seriesTimeout)
+		defer cancel()
+
+		indicatorData, err := e.yaegi.CalculateSeriesWithContext(seriesCtx, trader.Config.FilterCode, marketData)
+		if !errors.Is(err, yaegi.ErrNoSeriesFunc) {
+			return indicatorData, err
+		}
+	}
+	if trader.Config.SeriesCode == "" {
+		return map[string]interface{}{}, nil
+	}
+	return e.seriesExec.ExecuteSeriesCode(ctx, trader.Config.SeriesCode, marketData)
+}
+
+// hasRegistryIndicators reports whether any chart indicator of the trader is in the indicator registry
+func hasRegistryIndicators(trader *Trader) bool {
+	for _, config := range trader.Config.Indicators {
+		if _, ok := indicators.LookupIndicator(config.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// addRegistrySeries calculates the registry indicators that series code didn't output
+func addRegistrySeries(indicatorData map[string]interface{}, configs []types.IndicatorConfig, klines []types.Kline) {
+	for _, config := range configs {
+		if _, exists := indicatorData[config.ID]; exists {
+			continue
+		}
+		if _, ok := indicators.LookupIndicator(config.Name); !ok {
+			continue
+		}
+		points, err := indicators.IndicatorChartSeries(config, klines, 150)
+		if err != nil {
+			log.Printf("[Executor] Failed to calculate %s series: %v", config.ID, err)
+			continue
+		}
+		indicatorData[config.ID] = points
+	}
+}
+
 // saveSignals saves signals to the database using batch insert
 func (e *Executor) saveSignals(signals []Signal) error {
 	log.Printf("[Executor] Saving %d signals in batch", len(signals))
@@ -830,6 +1200,7 @@ func (e *Executor) saveSignals(signals []Signal) error {
 			Source:                "cloud",
 			FlyAppID:              flyAppID, // user_fly_apps.id for dedicated Fly apps
 			IndicatorData:         signal.IndicatorData, // NEW: Include indicator visualization data
+			Metadata:              signal.Metadata,
 		})
 	}
 
@@ -0,0 +1,38 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/cache"
+	"github.com/vyx/go-screener/pkg/yaegi"
+)
+
+func TestExecutor_AddTraderDerivesTimeframes(t *testing.T) {
+	yaegiExec, err := yaegi.NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+	klineCache := cache.NewKlineCache(500)
+	if err := klineCache.SetBaseInterval("1m"); err != nil {
+		t.Fatalf("SetBaseInterval failed: %v", err)
+	}
+	klineCache.SetStreamed([]string{"1m", "1d"})
+
+	executor := NewExecutor(yaegiExec, nil, nil, nil, nil, klineCache, nil, nil, nil, nil, nil)
+	trader := NewTrader("test-id", "user-123", "Test", "Test", &TraderConfig{
+		FilterCode: "return true",
+		Timeframes: []string{"3m", "ha:30m", "1d"},
+	})
+	if err := executor.AddTrader(trader); err != nil {
+		t.Fatalf("AddTrader failed: %v", err)
+	}
+
+	for _, interval := range []string{"3m", "30m"} {
+		if !klineCache.IsDerived(interval) {
+			t.Errorf("expected %s to be derived", interval)
+		}
+	}
+	if klineCache.IsDerived("1d") {
+		t.Error("expected the streamed 1d interval not to be derived")
+	}
+}
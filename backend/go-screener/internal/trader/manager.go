@@ -8,7 +8,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/vyx/go-screener/pkg/cache"
 	"github.com/vyx/go-screener/pkg/config"
+	"github.com/vyx/go-screener/pkg/indicators"
 	"github.com/vyx/go-screener/pkg/supabase"
 	"github.com/vyx/go-screener/pkg/types"
 	"github.com/vyx/go-screener/pkg/yaegi"
@@ -200,7 +202,8 @@ func (m *Manager) GetStatus(traderID string) (*TraderStatus, error) {
 
 // ExecuteImmediate executes a trader immediately using cached data
 // Returns execution results without waiting for next candle close event
-func (m *Manager) ExecuteImmediate(traderID string) (*ExecutionResult, error) {
+// With trace set, the results include a filter trace per symbol
+func (m *Manager) ExecuteImmediate(traderID string, trace bool) (*ExecutionResult, error) {
 	// Get trader from registry
 	trader, exists := m.registry.Get(traderID)
 	if !exists {
@@ -214,7 +217,7 @@ func (m *Manager) ExecuteImmediate(traderID string) (*ExecutionResult, error) {
 	}
 
 	// Execute immediately via executor
-	result, err := m.executor.ExecuteImmediate(traderID)
+	result, err := m.executor.ExecuteImmediate(traderID, trace)
 	if err != nil {
 		return nil, fmt.Errorf("immediate execution failed: %w", err)
 	}
@@ -222,6 +225,22 @@ func (m *Manager) ExecuteImmediate(traderID string) (*ExecutionResult, error) {
 	return result, nil
 }
 
+// GetFilterState returns the trader's filter state by symbol
+func (m *Manager) GetFilterState(traderID string) (map[string]map[string]interface{}, error) {
+	if _, exists := m.registry.Get(traderID); !exists {
+		return nil, fmt.Errorf("trader %s not found in registry", traderID)
+	}
+	return m.executor.FilterState(traderID), nil
+}
+
+// ResetFilterState clears the trader's filter state for a symbol, or all symbols if symbol is empty
+func (m *Manager) ResetFilterState(traderID, symbol string) error {
+	if _, exists := m.registry.Get(traderID); !exists {
+		return fmt.Errorf("trader %s not found in registry", traderID)
+	}
+	return m.executor.ResetFilterState(traderID, symbol)
+}
+
 // ListActive returns all active traders (running or starting)
 func (m *Manager) ListActive() []*Trader {
 	running := m.registry.GetByState(StateRunning)
@@ -251,10 +270,12 @@ func (m *Manager) GetMetrics() map[string]interface{} {
 	UpdatePoolMetrics(float64(m.poolSize), float64(activeCount))
 
 	metrics := map[string]interface{}{
-		"registry":  registryMetrics,
-		"quotas":    quotaMetrics,
-		"pool_size": m.poolSize,
-		"pool_used": activeCount,
+		"registry":     registryMetrics,
+		"quotas":       quotaMetrics,
+		"pool_size":    m.poolSize,
+		"pool_used":    activeCount,
+		"filter_cache": m.yaegi.CacheStats(),
+		"abandoned":    yaegi.GetAbandonedStats(),
 	}
 
 	return metrics
@@ -420,6 +441,17 @@ func (m *Manager) LoadTraderByID(traderID string) error {
 		return fmt.Errorf("invalid filter code: %w", err)
 	}
 
+	// Reload: drop the previous version (and its compiled filter) before registering the new one
+	wasRunning := false
+	if previous, exists := m.registry.Get(traderID); exists {
+		log.Printf("[Manager] Trader %s already registered, reloading", traderID)
+		wasRunning = previous.IsRunning()
+		m.executor.RemoveTrader(traderID)
+		if err := m.UnregisterTrader(traderID); err != nil {
+			return fmt.Errorf("failed to unregister previous version: %w", err)
+		}
+	}
+
 	// Register trader in registry
 	if err := m.RegisterTrader(trader); err != nil {
 		return fmt.Errorf("failed to register trader: %w", err)
@@ -430,6 +462,13 @@ func (m *Manager) LoadTraderByID(traderID string) error {
 		return fmt.Errorf("failed to add trader to executor: %w", err)
 	}
 
+	// Unregistering stopped the previous version, so the reloaded one picks up where it left off
+	if wasRunning {
+		if err := m.Start(traderID); err != nil {
+			return fmt.Errorf("failed to restart reloaded trader: %w", err)
+		}
+	}
+
 	log.Printf("[Manager] ✅ Loaded trader: %s (%s)", trader.ID, trader.Name)
 	return nil
 }
@@ -654,6 +693,25 @@ func convertDBTraderToRuntime(dbTrader *types.Trader) (*Trader, error) {
 		return nil, fmt.Errorf("filter code is empty")
 	}
 
+	// Validate alternative bar timeframes (ha:5m, renko:atr14, range:25:15m)
+	for _, tf := range filter.RequiredTimeframes {
+		if cache.IsBarInterval(tf) {
+			if _, err := cache.ParseBarInterval(tf); err != nil {
+				return nil, fmt.Errorf("invalid timeframe: %w", err)
+			}
+		}
+	}
+
+	// Validate indicators against the registry (custom series-code indicators pass)
+	// Parameters the registry no longer knows are ignored so the trader still loads
+	unknown, err := indicators.ValidateStoredIndicatorConfigs(filter.Indicators)
+	if len(unknown) > 0 {
+		log.Printf("[Manager] Trader %s: ignoring unknown indicator parameters %v", dbTrader.ID, unknown)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid indicators: %w", err)
+	}
+
 	// Create TraderConfig from filter
 	config := &TraderConfig{
 		FilterCode:        filter.Code,
@@ -664,6 +722,11 @@ func convertDBTraderToRuntime(dbTrader *types.Trader) (*Trader, error) {
 		Indicators:        filter.Indicators, // No conversion needed - same type
 		MaxSignalsPerRun:  10,                // Default limit
 		TimeoutPerRun:     1 * time.Second,   // Default timeout
+
+		MaxAllocBytesPerRun:  DefaultMaxAllocBytesPerRun,
+		MaxCPUTimePerRun:     DefaultMaxCPUTimePerRun,
+		MaxAllocBytesPerHour: DefaultMaxAllocBytesPerHour,
+		MaxCPUTimePerHour:    DefaultMaxCPUTimePerHour,
 	}
 
 	log.Printf("[Manager] DEBUG: Trader %s (%s) - Timeframes: %v", dbTrader.ID, dbTrader.Name, config.Timeframes)
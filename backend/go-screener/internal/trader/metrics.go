@@ -66,6 +66,33 @@ var (
 		[]string{"trader_id", "error_type"},
 	)
 
+	// User code resource budget metrics
+	TraderRunAllocBytes = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "trader_run_alloc_bytes",
+			Help:    "Approximate bytes allocated by a trader run's user code, measured as the size of its results",
+			Buckets: prometheus.ExponentialBuckets(1<<10, 4, 10), // 1KB .. 256MB
+		},
+		[]string{"trader_id"},
+	)
+
+	TraderRunCPUSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "trader_run_cpu_seconds",
+			Help:    "CPU time spent in a trader run's filter and series code",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"trader_id"},
+	)
+
+	TraderBudgetExceeded = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trader_budget_exceeded_total",
+			Help: "Total number of traders stopped for exceeding a resource budget",
+		},
+		[]string{"trader_id", "budget"},
+	)
+
 	// Signal metrics
 	SignalsGenerated = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -209,6 +236,17 @@ func RecordExecutionError(traderID, errorType string) {
 	TraderExecutionErrors.WithLabelValues(traderID, errorType).Inc()
 }
 
+// RecordResourceUsage records a run's user code resource usage
+func RecordResourceUsage(traderID string, usage ResourceUsage) {
+	TraderRunAllocBytes.WithLabelValues(traderID).Observe(float64(usage.AllocBytes))
+	TraderRunCPUSeconds.WithLabelValues(traderID).Observe(usage.CPUTime.Seconds())
+}
+
+// RecordBudgetExceeded records a trader stopped for exceeding a resource budget
+func RecordBudgetExceeded(traderID, budget string) {
+	TraderBudgetExceeded.WithLabelValues(traderID, budget).Inc()
+}
+
 // RecordSignal records a signal generation metric
 func RecordSignal(traderID, symbol string) {
 	SignalsGenerated.WithLabelValues(traderID, symbol).Inc()
@@ -472,3 +472,22 @@ func TestTrader_Context(t *testing.T) {
 		t.Error("Context should be cancelled after Cancel()")
 	}
 }
+
+func TestTrader_RecordTimeoutRun(t *testing.T) {
+	trader := NewTrader("test-id", "user-123", "Test", "Test", &TraderConfig{})
+
+	if strikes := trader.RecordTimeoutRun(true); strikes != 1 {
+		t.Errorf("strikes = %d, want 1", strikes)
+	}
+	if strikes := trader.RecordTimeoutRun(true); strikes != 2 {
+		t.Errorf("strikes = %d, want 2", strikes)
+	}
+
+	// A clean run resets the streak
+	if strikes := trader.RecordTimeoutRun(false); strikes != 0 {
+		t.Errorf("strikes = %d, want 0 after clean run", strikes)
+	}
+	if strikes := trader.RecordTimeoutRun(true); strikes != 1 {
+		t.Errorf("strikes = %d, want 1", strikes)
+	}
+}
@@ -56,6 +56,12 @@ type TraderConfig struct {
 	// Resource limits
 	MaxSignalsPerRun  int                 `json:"max_signals_per_run"`
 	TimeoutPerRun     time.Duration       `json:"timeout_per_run"`
+
+	// Resource budgets for filter and series code (zero means unlimited)
+	MaxAllocBytesPerRun  int64         `json:"max_alloc_bytes_per_run"`
+	MaxCPUTimePerRun     time.Duration `json:"max_cpu_time_per_run"`
+	MaxAllocBytesPerHour int64         `json:"max_alloc_bytes_per_hour"`
+	MaxCPUTimePerHour    time.Duration `json:"max_cpu_time_per_hour"`
 }
 
 // Trader represents a running trading strategy instance
@@ -75,6 +81,13 @@ type Trader struct {
 	signalCount int64         `json:"signal_count"`
 	lastRunAt   time.Time     `json:"last_run_at,omitempty"`
 
+	// Consecutive runs in which the filter timed out (for quarantine)
+	timeoutStrikes int
+
+	// User code resource usage in the current hourly budget window
+	usageWindowStart time.Time
+	usageInWindow    ResourceUsage
+
 	// Runtime context (for cancellation)
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -185,6 +198,44 @@ func (t *Trader) UpdateLastRunAt() {
 	t.lastRunAt = time.Now()
 }
 
+// RecordTimeoutRun records whether the latest run had filter timeouts (thread-safe)
+// Returns the number of consecutive runs with timeouts
+func (t *Trader) RecordTimeoutRun(timedOut bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if timedOut {
+		t.timeoutStrikes++
+	} else {
+		t.timeoutStrikes = 0
+	}
+	return t.timeoutStrikes
+}
+
+// RecordUsage adds a run's user code resource usage to the hourly budget window (thread-safe)
+// Returns the usage accumulated in the current window
+func (t *Trader) RecordUsage(usage ResourceUsage) ResourceUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.usageWindowStart) >= usageWindow {
+		t.usageWindowStart = now
+		t.usageInWindow = ResourceUsage{}
+	}
+
+	t.usageInWindow.AllocBytes += usage.AllocBytes
+	t.usageInWindow.CPUTime += usage.CPUTime
+	return t.usageInWindow
+}
+
+// GetLastError returns the last recorded error (thread-safe)
+func (t *Trader) GetLastError() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.lastError
+}
+
 // Context returns the trader's cancellation context
 func (t *Trader) Context() context.Context {
 	return t.ctx
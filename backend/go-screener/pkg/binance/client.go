@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
@@ -149,7 +150,7 @@ func (c *Client) GetKlines(ctx context.Context, symbol string, interval string,
 	// Convert to our Kline type
 	klines := make([]types.Kline, len(rawKlines))
 	for i, raw := range rawKlines {
-		kline, err := parseKline(raw)
+		kline, err := types.ParseKline(raw)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse kline at index %d: %w", i, err)
 		}
@@ -159,6 +160,99 @@ func (c *Client) GetKlines(ctx context.Context, symbol string, interval string,
 	return klines, nil
 }
 
+// GetDepthSnapshot fetches an order book snapshot with up to limit levels per side
+func (c *Client) GetDepthSnapshot(ctx context.Context, symbol string, limit int) (*DepthSnapshot, error) {
+	url := fmt.Sprintf("%s/api/v3/depth?symbol=%s&limit=%d", c.apiURL, symbol, limit)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch depth: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("binance API error: %s - %s", resp.Status, string(body))
+	}
+
+	var raw struct {
+		LastUpdateID int64       `json:"lastUpdateId"`
+		Bids         [][2]string `json:"bids"`
+		Asks         [][2]string `json:"asks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode depth: %w", err)
+	}
+
+	return &DepthSnapshot{
+		LastUpdateID: raw.LastUpdateID,
+		Bids:         parseLevels(raw.Bids),
+		Asks:         parseLevels(raw.Asks),
+	}, nil
+}
+
+// parseLevels converts [price, quantity] string pairs to price levels
+func parseLevels(raw [][2]string) []PriceLevel {
+	levels := make([]PriceLevel, len(raw))
+	for i, level := range raw {
+		levels[i] = PriceLevel{Price: parseFloat(level[0]), Quantity: parseFloat(level[1])}
+	}
+	return levels
+}
+
+// GetTickSizes fetches the price tick size of each symbol from exchange info
+func (c *Client) GetTickSizes(ctx context.Context, symbols []string) (map[string]float64, error) {
+	list, err := json.Marshal(symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode symbols: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/api/v3/exchangeInfo?symbols=%s", c.apiURL, url.QueryEscape(string(list)))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("binance API error: %s - %s", resp.Status, string(body))
+	}
+
+	var info struct {
+		Symbols []struct {
+			Symbol  string `json:"symbol"`
+			Filters []struct {
+				FilterType string `json:"filterType"`
+				TickSize   string `json:"tickSize"`
+			} `json:"filters"`
+		} `json:"symbols"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode exchange info: %w", err)
+	}
+
+	ticks := make(map[string]float64, len(info.Symbols))
+	for _, symbol := range info.Symbols {
+		for _, filter := range symbol.Filters {
+			if filter.FilterType == "PRICE_FILTER" {
+				ticks[symbol.Symbol] = parseFloat(filter.TickSize)
+			}
+		}
+	}
+	return ticks, nil
+}
+
 // GetTicker fetches current ticker data for a symbol and returns simplified ticker
 func (c *Client) GetTicker(ctx context.Context, symbol string) (*types.SimplifiedTicker, error) {
 	url := fmt.Sprintf("%s/api/v3/ticker/24hr?symbol=%s", c.apiURL, symbol)
@@ -295,115 +389,3 @@ func (c *Client) GetMultipleKlines(ctx context.Context, symbols []string, interv
 
 	return results, nil
 }
-
-// parseKline converts a raw Binance kline to our Kline type
-// Format: [openTime, open, high, low, close, volume, closeTime, quoteAssetVolume, numberOfTrades, takerBuyBaseAssetVolume, takerBuyQuoteAssetVolume, ignore]
-func parseKline(raw []interface{}) (types.Kline, error) {
-	if len(raw) < 12 {
-		return types.Kline{}, fmt.Errorf("invalid kline data: expected 12 fields, got %d", len(raw))
-	}
-
-	parseFloat := func(v interface{}) (float64, error) {
-		switch val := v.(type) {
-		case float64:
-			return val, nil
-		case string:
-			return strconv.ParseFloat(val, 64)
-		default:
-			return 0, fmt.Errorf("cannot parse float from %T", v)
-		}
-	}
-
-	parseInt := func(v interface{}) (int64, error) {
-		switch val := v.(type) {
-		case float64:
-			return int64(val), nil
-		case int64:
-			return val, nil
-		case string:
-			return strconv.ParseInt(val, 10, 64)
-		default:
-			return 0, fmt.Errorf("cannot parse int from %T", v)
-		}
-	}
-
-	openTime, err := parseInt(raw[0])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse openTime: %w", err)
-	}
-
-	open, err := parseFloat(raw[1])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse open: %w", err)
-	}
-
-	high, err := parseFloat(raw[2])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse high: %w", err)
-	}
-
-	low, err := parseFloat(raw[3])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse low: %w", err)
-	}
-
-	close, err := parseFloat(raw[4])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse close: %w", err)
-	}
-
-	volume, err := parseFloat(raw[5])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse volume: %w", err)
-	}
-
-	closeTime, err := parseInt(raw[6])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse closeTime: %w", err)
-	}
-
-	quoteAssetVolume, err := parseFloat(raw[7])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse quoteAssetVolume: %w", err)
-	}
-
-	numberOfTrades, err := parseInt(raw[8])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse numberOfTrades: %w", err)
-	}
-
-	takerBuyBaseAssetVolume, err := parseFloat(raw[9])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse takerBuyBaseAssetVolume: %w", err)
-	}
-
-	takerBuyQuoteAssetVolume, err := parseFloat(raw[10])
-	if err != nil {
-		return types.Kline{}, fmt.Errorf("failed to parse takerBuyQuoteAssetVolume: %w", err)
-	}
-
-	// Compute volume enrichment fields
-	buyVolume := takerBuyBaseAssetVolume
-	sellVolume := volume - buyVolume
-	volumeDelta := buyVolume - sellVolume
-
-	return types.Kline{
-		OpenTime:    openTime,
-		Open:        open,
-		High:        high,
-		Low:         low,
-		Close:       close,
-		Volume:      volume,
-		BuyVolume:   buyVolume,
-		SellVolume:  sellVolume,
-		VolumeDelta: volumeDelta,
-		QuoteVolume: quoteAssetVolume,
-		Trades:      int(numberOfTrades),
-		CloseTime:   closeTime,
-
-		// Legacy fields (internal use only)
-		TakerBuyBaseAssetVolume:  takerBuyBaseAssetVolume,
-		TakerBuyQuoteAssetVolume: takerBuyQuoteAssetVolume,
-		NumberOfTrades:           int(numberOfTrades),
-	}, nil
-}
@@ -0,0 +1,332 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/vyx/go-screener/internal/eventbus"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// maxBufferedUpdates caps the diffs kept per symbol while waiting for a snapshot
+const maxBufferedUpdates = 1000
+
+// DepthConfig configures order book tracking
+type DepthConfig struct {
+	Levels        int           // Levels per side for depth and imbalance
+	WallDepth     int           // Levels per side scanned for walls
+	WallMultiple  float64       // Multiple of the average level quantity that makes a wall
+	Interval      time.Duration // How often features are computed and published
+	SnapshotLimit int           // Levels per side fetched in REST snapshots, 100 or less costs weight 5
+	SnapshotPause time.Duration // Pause between snapshots, to stay under the rate limit
+}
+
+// DefaultDepthConfig returns the default order book configuration
+func DefaultDepthConfig() DepthConfig {
+	return DepthConfig{
+		Levels:        10,
+		WallDepth:     50,
+		WallMultiple:  5,
+		Interval:      1 * time.Second,
+		SnapshotLimit: 100,
+		SnapshotPause: 250 * time.Millisecond,
+	}
+}
+
+// DepthStream keeps local order books in sync from the @depth diff stream and REST
+// snapshots, and publishes their features and wall changes on the event bus
+type DepthStream struct {
+	wsURL    string
+	client   *Client
+	eventBus *eventbus.EventBus
+	config   DepthConfig
+
+	mu       sync.RWMutex
+	books    map[string]*depthBook
+	resyncCh chan string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// depthBook is the local order book of one symbol with its sync state
+type depthBook struct {
+	book      *OrderBook
+	buffer    []*DepthUpdate      // Diffs received while waiting for a snapshot
+	resyncing bool                // A snapshot is queued or being fetched
+	features  *types.BookFeatures // Latest features, for wall changes
+}
+
+// depthStreamMessage wraps a diff from combined depth streams
+type depthStreamMessage struct {
+	Stream string `json:"stream"`
+	Data   struct {
+		EventType     string      `json:"e"`
+		EventTime     int64       `json:"E"`
+		Symbol        string      `json:"s"`
+		FirstUpdateID int64       `json:"U"`
+		FinalUpdateID int64       `json:"u"`
+		Bids          [][2]string `json:"b"`
+		Asks          [][2]string `json:"a"`
+	} `json:"data"`
+}
+
+// NewDepthStream creates an order book stream
+func NewDepthStream(wsURL string, client *Client, eventBus *eventbus.EventBus, config DepthConfig) *DepthStream {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &DepthStream{
+		wsURL:    wsURL,
+		client:   client,
+		eventBus: eventBus,
+		config:   config,
+		books:    make(map[string]*depthBook),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins tracking the order books of the given symbols
+func (d *DepthStream) Start(symbols []string) {
+	d.mu.Lock()
+	for _, symbol := range symbols {
+		d.books[symbol] = &depthBook{book: NewOrderBook(symbol)}
+	}
+	d.resyncCh = make(chan string, len(symbols))
+	d.mu.Unlock()
+
+	log.Printf("[DepthStream] Tracking order books for %d symbols", len(symbols))
+
+	d.wg.Add(3)
+	go d.run(symbols)
+	go d.resyncWorker()
+	go d.featureLoop()
+}
+
+// run keeps the diff stream connected
+func (d *DepthStream) run(symbols []string) {
+	defer d.wg.Done()
+	url := combinedStreamURL(d.wsURL, symbols, "depth@100ms")
+	runStream(d.ctx, "DepthStream", url, d.resetBooks, d.handleMessage)
+}
+
+// resetBooks unsyncs every book on a new connection
+// Diffs missed while disconnected can't be recovered, so every book is resynced
+func (d *DepthStream) resetBooks() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for symbol, db := range d.books {
+		d.books[symbol] = &depthBook{book: NewOrderBook(symbol), features: db.features}
+	}
+}
+
+// handleMessage parses a diff from the combined stream
+func (d *DepthStream) handleMessage(message []byte) {
+	var msg depthStreamMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("[DepthStream] Error unmarshaling depth update: %v", err)
+		return
+	}
+	d.handleUpdate(&DepthUpdate{
+		Symbol:        msg.Data.Symbol,
+		EventTime:     msg.Data.EventTime,
+		FirstUpdateID: msg.Data.FirstUpdateID,
+		FinalUpdateID: msg.Data.FinalUpdateID,
+		Bids:          parseLevels(msg.Data.Bids),
+		Asks:          parseLevels(msg.Data.Asks),
+	})
+}
+
+// handleUpdate applies a diff, or buffers it and requests a snapshot if the book is out of sync
+func (d *DepthStream) handleUpdate(update *DepthUpdate) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	db, ok := d.books[update.Symbol]
+	if !ok {
+		return
+	}
+
+	if db.book.Synced() {
+		err := db.book.ApplyUpdate(update)
+		if err == nil {
+			return
+		}
+		log.Printf("[DepthStream] Resyncing: %v", err)
+	}
+
+	db.buffer = append(db.buffer, update)
+	if len(db.buffer) > maxBufferedUpdates {
+		db.buffer = db.buffer[len(db.buffer)-maxBufferedUpdates:]
+	}
+	d.requestResync(update.Symbol, db)
+}
+
+// requestResync queues a snapshot for a book unless one is already queued
+// Must be called with d.mu held
+func (d *DepthStream) requestResync(symbol string, db *depthBook) {
+	if db.resyncing {
+		return
+	}
+	select {
+	case d.resyncCh <- symbol:
+		db.resyncing = true
+	default:
+		// Queue full, the next diff asks again
+	}
+}
+
+// resyncWorker fetches snapshots one at a time and replays buffered diffs on top
+func (d *DepthStream) resyncWorker() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case symbol := <-d.resyncCh:
+			d.resync(symbol)
+
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-time.After(d.config.SnapshotPause):
+			}
+		}
+	}
+}
+
+// resync brings one book back in sync from a fresh snapshot
+func (d *DepthStream) resync(symbol string) {
+	ctx, cancel := context.WithTimeout(d.ctx, 10*time.Second)
+	snapshot, err := d.client.GetDepthSnapshot(ctx, symbol, d.config.SnapshotLimit)
+	cancel()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	db, ok := d.books[symbol]
+	if !ok {
+		return
+	}
+	db.resyncing = false
+
+	if err != nil {
+		if d.ctx.Err() == nil {
+			log.Printf("[DepthStream] Failed to fetch %s snapshot: %v", symbol, err)
+			d.requestResync(symbol, db)
+		}
+		return
+	}
+
+	db.book.ApplySnapshot(snapshot)
+	for i, update := range db.buffer {
+		if err := db.book.ApplyUpdate(update); err != nil {
+			// The snapshot is older than the buffered diffs, try again
+			db.buffer = db.buffer[i:]
+			d.requestResync(symbol, db)
+			return
+		}
+	}
+	db.buffer = nil
+}
+
+// wallEventTypes maps wall changes to depth event types
+var wallEventTypes = map[string]string{
+	WallAppeared: eventbus.DepthWallAppeared,
+	WallPulled:   eventbus.DepthWallPulled,
+	WallFilled:   eventbus.DepthWallFilled,
+}
+
+// featureLoop computes and publishes features of every synced book
+func (d *DepthStream) featureLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case now := <-ticker.C:
+			events := d.updateFeatures(now)
+			if d.eventBus == nil {
+				continue
+			}
+			for _, event := range events {
+				d.eventBus.PublishDepthEvent(event)
+			}
+		}
+	}
+}
+
+// updateFeatures computes the features of every synced book and returns the events to publish
+func (d *DepthStream) updateFeatures(now time.Time) []*eventbus.DepthEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var events []*eventbus.DepthEvent
+	for symbol, db := range d.books {
+		if !db.book.Synced() {
+			continue
+		}
+
+		features := db.book.Features(d.config.Levels, d.config.WallDepth, d.config.WallMultiple)
+		features.Time = now.UnixMilli()
+
+		if db.features != nil {
+			for _, change := range CompareWalls(db.book, *db.features, features) {
+				wall := change.Wall
+				events = append(events, &eventbus.DepthEvent{
+					Symbol:   symbol,
+					Type:     wallEventTypes[change.Type],
+					Features: features,
+					Wall:     &wall,
+					Time:     now,
+				})
+			}
+		}
+		db.features = &features
+
+		events = append(events, &eventbus.DepthEvent{
+			Symbol:   symbol,
+			Type:     eventbus.DepthFeatures,
+			Features: features,
+			Time:     now,
+		})
+	}
+	return events
+}
+
+// Features returns the latest order book features of a symbol, nil if its book isn't synced
+func (d *DepthStream) Features(symbol string) *types.BookFeatures {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	db, ok := d.books[symbol]
+	if !ok || !db.book.Synced() || db.features == nil {
+		return nil
+	}
+	features := *db.features
+	return &features
+}
+
+// Close stops the stream
+func (d *DepthStream) Close() error {
+	log.Println("[DepthStream] Closing depth stream")
+
+	d.cancel()
+	d.wg.Wait()
+
+	log.Println("[DepthStream] Closed successfully")
+	return nil
+}
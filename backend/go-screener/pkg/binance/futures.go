@@ -0,0 +1,314 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Liquidated position sides
+const (
+	LiquidatedLong  = "long"
+	LiquidatedShort = "short"
+)
+
+// FuturesConfig configures futures market tracking
+type FuturesConfig struct {
+	FundingInterval    time.Duration // How often mark prices and funding rates are polled
+	PositionInterval   time.Duration // How often open interest and long/short ratios are polled
+	Period             string        // Period of the open interest and long/short history
+	HistoryLimit       int           // Periods of history kept
+	RequestPause       time.Duration // Pause between per-symbol requests, to stay under the rate limit
+	LiquidationHistory time.Duration // How long liquidations are kept
+	MaxLiquidations    int           // Liquidations kept per symbol
+}
+
+// DefaultFuturesConfig returns the default futures configuration
+func DefaultFuturesConfig() FuturesConfig {
+	return FuturesConfig{
+		FundingInterval:    1 * time.Minute,
+		PositionInterval:   5 * time.Minute,
+		Period:             "5m",
+		HistoryLimit:       30,
+		RequestPause:       100 * time.Millisecond,
+		LiquidationHistory: 1 * time.Hour,
+		MaxLiquidations:    200,
+	}
+}
+
+// FuturesFeed keeps the funding, open interest, top trader long/short ratio and
+// liquidations of the screened symbols' perpetuals. Funding and positioning are
+// polled over REST and liquidations come from the all-market forced order stream
+type FuturesFeed struct {
+	wsURL  string
+	client *FuturesClient
+	config FuturesConfig
+
+	mu       sync.RWMutex
+	tracked  map[string]bool
+	contexts map[string]*types.FuturesContext // Symbols with a perpetual
+	now      func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// forceOrderMessage is a liquidation from the forced order stream
+type forceOrderMessage struct {
+	EventType string `json:"e"`
+	EventTime int64  `json:"E"`
+	Order     struct {
+		Symbol       string `json:"s"`
+		Side         string `json:"S"`
+		Price        string `json:"p"`
+		AveragePrice string `json:"ap"`
+		Quantity     string `json:"q"`
+		Filled       string `json:"z"`
+		TradeTime    int64  `json:"T"`
+	} `json:"o"`
+}
+
+// NewFuturesFeed creates a futures feed
+func NewFuturesFeed(wsURL string, client *FuturesClient, config FuturesConfig) *FuturesFeed {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &FuturesFeed{
+		wsURL:    wsURL,
+		client:   client,
+		config:   config,
+		tracked:  make(map[string]bool),
+		contexts: make(map[string]*types.FuturesContext),
+		now:      time.Now,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins tracking the perpetuals of the given symbols
+// Symbols without a perpetual are ignored
+func (f *FuturesFeed) Start(symbols []string) {
+	f.mu.Lock()
+	for _, symbol := range symbols {
+		f.tracked[symbol] = true
+	}
+	f.mu.Unlock()
+
+	log.Printf("[FuturesFeed] Tracking futures for %d symbols", len(symbols))
+
+	f.wg.Add(2)
+	go f.pollLoop()
+	go func() {
+		defer f.wg.Done()
+		runStream(f.ctx, "FuturesFeed", f.wsURL+"/ws/!forceOrder@arr", nil, f.handleMessage)
+	}()
+}
+
+// pollLoop polls funding and positioning until the feed is closed
+func (f *FuturesFeed) pollLoop() {
+	defer f.wg.Done()
+
+	f.pollFunding()
+	f.pollPositions()
+
+	funding := time.NewTicker(f.config.FundingInterval)
+	defer funding.Stop()
+	positions := time.NewTicker(f.config.PositionInterval)
+	defer positions.Stop()
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-funding.C:
+			f.pollFunding()
+		case <-positions.C:
+			f.pollPositions()
+		}
+	}
+}
+
+// pollFunding updates mark prices and funding rates, finding which symbols have a perpetual
+func (f *FuturesFeed) pollFunding() {
+	ctx, cancel := context.WithTimeout(f.ctx, 10*time.Second)
+	defer cancel()
+
+	indexes, err := f.client.GetPremiumIndexes(ctx)
+	if err != nil {
+		if f.ctx.Err() == nil {
+			log.Printf("[FuturesFeed] Failed to poll funding: %v", err)
+		}
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	first := len(f.contexts) == 0
+	for symbol := range f.tracked {
+		index, ok := indexes[symbol]
+		if !ok {
+			continue
+		}
+		fc, ok := f.contexts[symbol]
+		if !ok {
+			fc = &types.FuturesContext{}
+			f.contexts[symbol] = fc
+		}
+		fc.MarkPrice = index.MarkPrice
+		fc.IndexPrice = index.IndexPrice
+		fc.FundingRate = index.FundingRate
+		fc.NextFundingTime = index.NextFundingTime
+		fc.UpdatedAt = f.now().UnixMilli()
+	}
+	if first {
+		log.Printf("[FuturesFeed] %d of %d symbols have a perpetual", len(f.contexts), len(f.tracked))
+	}
+}
+
+// pollPositions updates the open interest and long/short history of every perpetual
+func (f *FuturesFeed) pollPositions() {
+	f.mu.RLock()
+	symbols := make([]string, 0, len(f.contexts))
+	for symbol := range f.contexts {
+		symbols = append(symbols, symbol)
+	}
+	f.mu.RUnlock()
+
+	for _, symbol := range symbols {
+		openInterest, longShort, err := f.fetchPositions(symbol)
+		if f.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("[FuturesFeed] Failed to poll %s positioning: %v", symbol, err)
+			continue
+		}
+
+		f.mu.Lock()
+		fc := f.contexts[symbol]
+		fc.OpenInterestHist = openInterest
+		if n := len(openInterest); n > 0 {
+			fc.OpenInterest = openInterest[n-1].Value
+			fc.OpenInterestValue = openInterest[n-1].Quote
+		}
+		fc.LongShortHist = longShort
+		if n := len(longShort); n > 0 {
+			fc.LongShortRatio = longShort[n-1].Ratio
+		}
+		f.mu.Unlock()
+	}
+}
+
+// fetchPositions fetches a symbol's open interest and long/short history, pausing after each request
+func (f *FuturesFeed) fetchPositions(symbol string) ([]types.OpenInterestPoint, []types.LongShortPoint, error) {
+	ctx, cancel := context.WithTimeout(f.ctx, 10*time.Second)
+	defer cancel()
+
+	openInterest, err := f.client.GetOpenInterestHistory(ctx, symbol, f.config.Period, f.config.HistoryLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	f.pause()
+
+	longShort, err := f.client.GetTopLongShortRatio(ctx, symbol, f.config.Period, f.config.HistoryLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	f.pause()
+
+	return openInterest, longShort, nil
+}
+
+// pause waits between requests unless the feed is closed
+func (f *FuturesFeed) pause() {
+	select {
+	case <-f.ctx.Done():
+	case <-time.After(f.config.RequestPause):
+	}
+}
+
+// handleMessage records a liquidation of a tracked perpetual
+func (f *FuturesFeed) handleMessage(message []byte) {
+	var msg forceOrderMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("[FuturesFeed] Error unmarshaling liquidation: %v", err)
+		return
+	}
+
+	order := msg.Order
+	liquidation := types.Liquidation{
+		Side:     LiquidatedLong,
+		Price:    parseFloat(order.AveragePrice),
+		Quantity: parseFloat(order.Filled),
+		Time:     order.TradeTime,
+	}
+	// A buy order closes a short
+	if order.Side == "BUY" {
+		liquidation.Side = LiquidatedShort
+	}
+	if liquidation.Price == 0 {
+		liquidation.Price = parseFloat(order.Price)
+	}
+	if liquidation.Quantity == 0 {
+		liquidation.Quantity = parseFloat(order.Quantity)
+	}
+	liquidation.Notional = liquidation.Price * liquidation.Quantity
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fc, ok := f.contexts[order.Symbol]
+	if !ok {
+		return
+	}
+	liquidations := append(fc.Liquidations, liquidation)
+
+	// Drop liquidations that are too old or too many
+	before := liquidation.Time - f.config.LiquidationHistory.Milliseconds()
+	i := 0
+	for i < len(liquidations) && liquidations[i].Time < before {
+		i++
+	}
+	if limit := f.config.MaxLiquidations; limit > 0 && len(liquidations)-i > limit {
+		i = len(liquidations) - limit
+	}
+	if i > 0 {
+		liquidations = append([]types.Liquidation(nil), liquidations[i:]...)
+	}
+	fc.Liquidations = liquidations
+}
+
+// Context returns the futures context of a symbol, nil if it has no tracked perpetual
+func (f *FuturesFeed) Context(symbol string) *types.FuturesContext {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	fc, ok := f.contexts[symbol]
+	if !ok {
+		return nil
+	}
+	// Histories are replaced on every poll, liquidations are appended to
+	result := *fc
+	result.Liquidations = append([]types.Liquidation(nil), fc.Liquidations...)
+	return &result
+}
+
+// Close stops the feed
+func (f *FuturesFeed) Close() error {
+	log.Println("[FuturesFeed] Closing futures feed")
+
+	f.cancel()
+	f.wg.Wait()
+
+	log.Println("[FuturesFeed] Closed successfully")
+	return nil
+}
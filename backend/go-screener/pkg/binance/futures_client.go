@@ -0,0 +1,151 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// FuturesClient handles Binance USDT-margined futures API interactions
+type FuturesClient struct {
+	apiURL     string
+	httpClient *http.Client
+}
+
+// PremiumIndex is the mark price and funding of a perpetual
+type PremiumIndex struct {
+	Symbol          string
+	MarkPrice       float64
+	IndexPrice      float64
+	FundingRate     float64
+	NextFundingTime int64
+	Time            int64
+}
+
+// NewFuturesClient creates a new Binance futures API client
+func NewFuturesClient(apiURL string) *FuturesClient {
+	return &FuturesClient{
+		apiURL: apiURL,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// GetPremiumIndexes fetches the mark price and funding of every perpetual, by symbol
+func (c *FuturesClient) GetPremiumIndexes(ctx context.Context) (map[string]*PremiumIndex, error) {
+	var raw []struct {
+		Symbol          string `json:"symbol"`
+		MarkPrice       string `json:"markPrice"`
+		IndexPrice      string `json:"indexPrice"`
+		LastFundingRate string `json:"lastFundingRate"`
+		NextFundingTime int64  `json:"nextFundingTime"`
+		Time            int64  `json:"time"`
+	}
+	if err := c.get(ctx, "/fapi/v1/premiumIndex", nil, &raw); err != nil {
+		return nil, fmt.Errorf("failed to fetch premium index: %w", err)
+	}
+
+	indexes := make(map[string]*PremiumIndex, len(raw))
+	for _, r := range raw {
+		indexes[r.Symbol] = &PremiumIndex{
+			Symbol:          r.Symbol,
+			MarkPrice:       parseFloat(r.MarkPrice),
+			IndexPrice:      parseFloat(r.IndexPrice),
+			FundingRate:     parseFloat(r.LastFundingRate),
+			NextFundingTime: r.NextFundingTime,
+			Time:            r.Time,
+		}
+	}
+	return indexes, nil
+}
+
+// GetOpenInterestHistory fetches the open interest of the last limit periods, oldest first
+func (c *FuturesClient) GetOpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]types.OpenInterestPoint, error) {
+	var raw []struct {
+		SumOpenInterest      string `json:"sumOpenInterest"`
+		SumOpenInterestValue string `json:"sumOpenInterestValue"`
+		Timestamp            int64  `json:"timestamp"`
+	}
+	if err := c.get(ctx, "/futures/data/openInterestHist", historyParams(symbol, period, limit), &raw); err != nil {
+		return nil, fmt.Errorf("failed to fetch open interest: %w", err)
+	}
+
+	points := make([]types.OpenInterestPoint, len(raw))
+	for i, r := range raw {
+		points[i] = types.OpenInterestPoint{
+			Time:  r.Timestamp,
+			Value: parseFloat(r.SumOpenInterest),
+			Quote: parseFloat(r.SumOpenInterestValue),
+		}
+	}
+	return points, nil
+}
+
+// GetTopLongShortRatio fetches the top trader long/short position ratio of the last limit periods, oldest first
+func (c *FuturesClient) GetTopLongShortRatio(ctx context.Context, symbol, period string, limit int) ([]types.LongShortPoint, error) {
+	var raw []struct {
+		LongShortRatio string `json:"longShortRatio"`
+		LongAccount    string `json:"longAccount"`
+		ShortAccount   string `json:"shortAccount"`
+		Timestamp      int64  `json:"timestamp"`
+	}
+	if err := c.get(ctx, "/futures/data/topLongShortPositionRatio", historyParams(symbol, period, limit), &raw); err != nil {
+		return nil, fmt.Errorf("failed to fetch long/short ratio: %w", err)
+	}
+
+	points := make([]types.LongShortPoint, len(raw))
+	for i, r := range raw {
+		points[i] = types.LongShortPoint{
+			Time:  r.Timestamp,
+			Ratio: parseFloat(r.LongShortRatio),
+			Long:  parseFloat(r.LongAccount),
+			Short: parseFloat(r.ShortAccount),
+		}
+	}
+	return points, nil
+}
+
+// historyParams returns the query of the futures data endpoints
+func historyParams(symbol, period string, limit int) url.Values {
+	return url.Values{
+		"symbol": {symbol},
+		"period": {period},
+		"limit":  {fmt.Sprintf("%d", limit)},
+	}
+}
+
+// get decodes the JSON response of a GET request into out
+func (c *FuturesClient) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	endpoint := c.apiURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("binance API error: %s - %s", resp.Status, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package binance
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// newFuturesServer serves funding for BTCUSDT and ETHUSDT and positioning for any symbol
+func newFuturesServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fapi/v1/premiumIndex", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"symbol":"BTCUSDT","markPrice":"60010.5","indexPrice":"60000.0","lastFundingRate":"0.00012","interestRate":"0.0001","nextFundingTime":1700006400000,"time":1700000000000},
+			{"symbol":"ETHUSDT","markPrice":"3000.1","indexPrice":"3000.0","lastFundingRate":"-0.0002","interestRate":"0.0001","nextFundingTime":1700006400000,"time":1700000000000}
+		]`)
+	})
+	mux.HandleFunc("/futures/data/openInterestHist", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("period") != "5m" || r.URL.Query().Get("limit") != "2" {
+			t.Errorf("unexpected open interest query %s", r.URL.RawQuery)
+		}
+		fmt.Fprintf(w, `[
+			{"symbol":"%[1]s","sumOpenInterest":"100","sumOpenInterestValue":"6000000","timestamp":1699999700000},
+			{"symbol":"%[1]s","sumOpenInterest":"110","sumOpenInterestValue":"6600000","timestamp":1700000000000}
+		]`, r.URL.Query().Get("symbol"))
+	})
+	mux.HandleFunc("/futures/data/topLongShortPositionRatio", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[
+			{"symbol":"%s","longShortRatio":"1.5","longAccount":"0.6","shortAccount":"0.4","timestamp":1700000000000}
+		]`, r.URL.Query().Get("symbol"))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFuturesFeed_Poll(t *testing.T) {
+	server := newFuturesServer(t)
+	config := DefaultFuturesConfig()
+	config.HistoryLimit = 2
+	config.RequestPause = 0
+	feed := NewFuturesFeed("", NewFuturesClient(server.URL), config)
+	feed.now = func() time.Time { return time.UnixMilli(1700000001000) }
+	feed.tracked = map[string]bool{"BTCUSDT": true, "DOGEUSDT": true}
+
+	feed.pollFunding()
+	feed.pollPositions()
+
+	fc := feed.Context("BTCUSDT")
+	if fc == nil {
+		t.Fatal("expected a futures context for BTCUSDT")
+	}
+	if fc.MarkPrice != 60010.5 || fc.FundingRate != 0.00012 || fc.NextFundingTime != 1700006400000 || fc.UpdatedAt != 1700000001000 {
+		t.Errorf("unexpected funding %+v", fc)
+	}
+	if len(fc.OpenInterestHist) != 2 || fc.OpenInterest != 110 || fc.OpenInterestValue != 6600000 {
+		t.Errorf("unexpected open interest %+v", fc)
+	}
+	if len(fc.LongShortHist) != 1 || fc.LongShortRatio != 1.5 || fc.LongShortHist[0].Long != 0.6 {
+		t.Errorf("unexpected long/short ratio %+v", fc)
+	}
+
+	// Untracked symbols and symbols without a perpetual have no context
+	if feed.Context("ETHUSDT") != nil || feed.Context("DOGEUSDT") != nil {
+		t.Error("expected no context for ETHUSDT or DOGEUSDT")
+	}
+	var disabled *FuturesFeed
+	if disabled.Context("BTCUSDT") != nil {
+		t.Error("expected no context from a nil feed")
+	}
+}
+
+func TestFuturesFeed_Liquidations(t *testing.T) {
+	config := DefaultFuturesConfig()
+	config.MaxLiquidations = 2
+	feed := NewFuturesFeed("", nil, config)
+	feed.contexts["BTCUSDT"] = &types.FuturesContext{MarkPrice: 60000}
+
+	message := `{"e":"forceOrder","E":%[1]d,"o":{"s":"%[2]s","S":"%[3]s","o":"LIMIT","f":"IOC","q":"0.5","p":"59000","ap":"%[4]s","X":"FILLED","l":"0.5","z":"0.5","T":%[1]d}}`
+	feed.handleMessage([]byte(fmt.Sprintf(message, 1700000000000, "BTCUSDT", "SELL", "59500")))
+	feed.handleMessage([]byte(fmt.Sprintf(message, 1700000060000, "ETHUSDT", "SELL", "2900")))
+	feed.handleMessage([]byte(fmt.Sprintf(message, 1700000120000, "BTCUSDT", "BUY", "0")))
+	feed.handleMessage([]byte(`not json`))
+
+	liquidations := feed.Context("BTCUSDT").Liquidations
+	if len(liquidations) != 2 {
+		t.Fatalf("expected 2 liquidations, got %+v", liquidations)
+	}
+	if l := liquidations[0]; l.Side != LiquidatedLong || l.Price != 59500 || l.Notional != 29750 {
+		t.Errorf("unexpected long liquidation %+v", l)
+	}
+	// Without an average price the order price is used
+	if l := liquidations[1]; l.Side != LiquidatedShort || l.Price != 59000 {
+		t.Errorf("unexpected short liquidation %+v", l)
+	}
+
+	// Old and excess liquidations are dropped
+	feed.handleMessage([]byte(fmt.Sprintf(message, 1700000180000, "BTCUSDT", "SELL", "59500")))
+	if liquidations = feed.Context("BTCUSDT").Liquidations; len(liquidations) != 2 || liquidations[0].Time != 1700000120000 {
+		t.Errorf("expected the last 2 liquidations, got %+v", liquidations)
+	}
+	feed.handleMessage([]byte(fmt.Sprintf(message, 1700003790000, "BTCUSDT", "SELL", "59500")))
+	if liquidations = feed.Context("BTCUSDT").Liquidations; len(liquidations) != 1 {
+		t.Errorf("expected liquidations over an hour old to be dropped, got %+v", liquidations)
+	}
+}
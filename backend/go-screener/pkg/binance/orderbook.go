@@ -0,0 +1,277 @@
+package binance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Local order book
+//
+// A local book starts from a REST snapshot and applies the @depth diff stream on
+// top. Binance numbers every change: a diff covers update IDs U to u, diffs ending
+// at or before the snapshot's lastUpdateId are stale, the first one applied must
+// straddle lastUpdateId+1 and every later one must start right after the previous
+// one ended. Anything else means a diff was missed and the book must be resynced
+
+// ErrBookNotSynced is returned for diffs applied to a book without a snapshot
+var ErrBookNotSynced = errors.New("order book not synced")
+
+// ErrBookGap is returned when a diff doesn't follow the previous update
+var ErrBookGap = errors.New("order book update gap")
+
+// PriceLevel is a price and the quantity resting at it
+type PriceLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// DepthSnapshot is a REST order book snapshot
+type DepthSnapshot struct {
+	LastUpdateID int64
+	Bids         []PriceLevel
+	Asks         []PriceLevel
+}
+
+// DepthUpdate is a diff from the @depth stream
+// A zero quantity removes the level
+type DepthUpdate struct {
+	Symbol        string
+	EventTime     int64
+	FirstUpdateID int64 // U
+	FinalUpdateID int64 // u
+	Bids          []PriceLevel
+	Asks          []PriceLevel
+}
+
+// OrderBook is a local order book kept in sync with snapshots and diffs
+// It is not safe for concurrent use
+type OrderBook struct {
+	Symbol string
+
+	bids, asks   map[float64]float64
+	lastUpdateID int64
+	synced       bool
+	fresh        bool // No diff applied since the snapshot
+}
+
+// NewOrderBook creates an empty, unsynced order book
+func NewOrderBook(symbol string) *OrderBook {
+	return &OrderBook{
+		Symbol: symbol,
+		bids:   make(map[float64]float64),
+		asks:   make(map[float64]float64),
+	}
+}
+
+// ApplySnapshot replaces the book with a snapshot
+func (b *OrderBook) ApplySnapshot(snapshot *DepthSnapshot) {
+	b.bids = make(map[float64]float64, len(snapshot.Bids))
+	b.asks = make(map[float64]float64, len(snapshot.Asks))
+	setLevels(b.bids, snapshot.Bids)
+	setLevels(b.asks, snapshot.Asks)
+	b.lastUpdateID = snapshot.LastUpdateID
+	b.synced = true
+	b.fresh = true
+}
+
+// ApplyUpdate applies a diff. Stale diffs are ignored; a diff that doesn't follow
+// the last update unsyncs the book and returns ErrBookGap
+func (b *OrderBook) ApplyUpdate(update *DepthUpdate) error {
+	if !b.synced {
+		return ErrBookNotSynced
+	}
+	if update.FinalUpdateID <= b.lastUpdateID {
+		return nil // Already in the book
+	}
+
+	next := b.lastUpdateID + 1
+	if (b.fresh && update.FirstUpdateID > next) || (!b.fresh && update.FirstUpdateID != next) {
+		b.synced = false
+		return fmt.Errorf("%w: %s expected update %d, got %d-%d", ErrBookGap, b.Symbol, next, update.FirstUpdateID, update.FinalUpdateID)
+	}
+
+	setLevels(b.bids, update.Bids)
+	setLevels(b.asks, update.Asks)
+	b.lastUpdateID = update.FinalUpdateID
+	b.fresh = false
+	return nil
+}
+
+// setLevels sets or, for zero quantities, removes price levels
+func setLevels(side map[float64]float64, levels []PriceLevel) {
+	for _, level := range levels {
+		if level.Quantity == 0 {
+			delete(side, level.Price)
+		} else {
+			side[level.Price] = level.Quantity
+		}
+	}
+}
+
+// Synced reports whether the book is in sync with the exchange
+func (b *OrderBook) Synced() bool {
+	return b.synced
+}
+
+// LastUpdateID returns the last update applied to the book
+func (b *OrderBook) LastUpdateID() int64 {
+	return b.lastUpdateID
+}
+
+// Bids returns the best n bid levels, highest price first
+func (b *OrderBook) Bids(n int) []PriceLevel {
+	return topLevels(b.bids, n, func(a, c float64) bool { return a > c })
+}
+
+// Asks returns the best n ask levels, lowest price first
+func (b *OrderBook) Asks(n int) []PriceLevel {
+	return topLevels(b.asks, n, func(a, c float64) bool { return a < c })
+}
+
+func topLevels(side map[float64]float64, n int, better func(a, c float64) bool) []PriceLevel {
+	levels := make([]PriceLevel, 0, len(side))
+	for price, quantity := range side {
+		levels = append(levels, PriceLevel{Price: price, Quantity: quantity})
+	}
+	sort.Slice(levels, func(i, j int) bool { return better(levels[i].Price, levels[j].Price) })
+	if n > 0 && len(levels) > n {
+		levels = levels[:n]
+	}
+	return levels
+}
+
+// Quantity returns the quantity resting at a price on a side ("bid" or "ask")
+func (b *OrderBook) Quantity(side string, price float64) float64 {
+	if side == SideBid {
+		return b.bids[price]
+	}
+	return b.asks[price]
+}
+
+// Book sides
+const (
+	SideBid = "bid"
+	SideAsk = "ask"
+)
+
+// Features computes order book features: spread, depth and imbalance over the top
+// levels, and walls among the top wallDepth levels holding at least wallMultiple
+// times the average quantity of those levels
+func (b *OrderBook) Features(levels, wallDepth int, wallMultiple float64) types.BookFeatures {
+	depth := wallDepth
+	if levels > depth {
+		depth = levels
+	}
+	bids, asks := b.Bids(depth), b.Asks(depth)
+
+	features := types.BookFeatures{Levels: levels, UpdateID: b.lastUpdateID}
+	if len(bids) == 0 || len(asks) == 0 {
+		return features
+	}
+
+	features.BestBid = bids[0].Price
+	features.BestAsk = asks[0].Price
+	features.Mid = (features.BestBid + features.BestAsk) / 2
+	features.Spread = features.BestAsk - features.BestBid
+	features.SpreadBps = features.Spread / features.Mid * 10000
+
+	features.BidDepth = sumQuantity(bids, levels)
+	features.AskDepth = sumQuantity(asks, levels)
+	if total := features.BidDepth + features.AskDepth; total > 0 {
+		features.Imbalance = (features.BidDepth - features.AskDepth) / total
+	}
+
+	features.Walls = append(findWalls(SideBid, bids, wallDepth, wallMultiple, features.Mid),
+		findWalls(SideAsk, asks, wallDepth, wallMultiple, features.Mid)...)
+	sort.SliceStable(features.Walls, func(i, j int) bool { return features.Walls[i].Distance < features.Walls[j].Distance })
+	return features
+}
+
+// sumQuantity sums the quantity of the first n levels
+func sumQuantity(levels []PriceLevel, n int) float64 {
+	var sum float64
+	for i := 0; i < n && i < len(levels); i++ {
+		sum += levels[i].Quantity
+	}
+	return sum
+}
+
+// findWalls returns the levels among the first depth holding at least multiple
+// times their average quantity
+func findWalls(side string, levels []PriceLevel, depth int, multiple, mid float64) []types.BookWall {
+	if depth > len(levels) {
+		depth = len(levels)
+	}
+	if depth < 2 || multiple <= 0 {
+		return nil
+	}
+
+	average := sumQuantity(levels, depth) / float64(depth)
+	var walls []types.BookWall
+	for _, level := range levels[:depth] {
+		if level.Quantity >= multiple*average {
+			walls = append(walls, types.BookWall{
+				Side:     side,
+				Price:    level.Price,
+				Quantity: level.Quantity,
+				Multiple: level.Quantity / average,
+				Distance: math.Abs(level.Price-mid) / mid * 100,
+			})
+		}
+	}
+	return walls
+}
+
+// WallChange is a wall that appeared, was pulled or was filled between two feature snapshots
+type WallChange struct {
+	Type string // "appeared", "pulled" or "filled"
+	Wall types.BookWall
+}
+
+// Wall change types
+const (
+	WallAppeared = "appeared"
+	WallPulled   = "pulled"
+	WallFilled   = "filled"
+)
+
+// CompareWalls reports the walls that appeared between two feature snapshots, and
+// those that disappeared: filled if price traded through them, pulled if most of
+// their quantity left the book without it. Walls that only drifted out of the
+// scanned levels or below the threshold are not reported
+func CompareWalls(book *OrderBook, before, after types.BookFeatures) []WallChange {
+	key := func(w types.BookWall) string { return fmt.Sprintf("%s:%g", w.Side, w.Price) }
+
+	current := make(map[string]bool, len(after.Walls))
+	for _, wall := range after.Walls {
+		current[key(wall)] = true
+	}
+	previous := make(map[string]bool, len(before.Walls))
+	for _, wall := range before.Walls {
+		previous[key(wall)] = true
+	}
+
+	var changes []WallChange
+	for _, wall := range after.Walls {
+		if !previous[key(wall)] {
+			changes = append(changes, WallChange{Type: WallAppeared, Wall: wall})
+		}
+	}
+	for _, wall := range before.Walls {
+		if current[key(wall)] {
+			continue
+		}
+		switch {
+		case wall.Side == SideBid && after.BestBid < wall.Price,
+			wall.Side == SideAsk && after.BestAsk > wall.Price:
+			changes = append(changes, WallChange{Type: WallFilled, Wall: wall})
+		case book.Quantity(wall.Side, wall.Price) < wall.Quantity/2:
+			changes = append(changes, WallChange{Type: WallPulled, Wall: wall})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,148 @@
+package binance
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// bookSnapshot returns a snapshot at update 100 with five levels a side, 1 apart
+// around a mid of 100, each holding 1
+func bookSnapshot() *DepthSnapshot {
+	snapshot := &DepthSnapshot{LastUpdateID: 100}
+	for i := 0; i < 5; i++ {
+		snapshot.Bids = append(snapshot.Bids, PriceLevel{Price: 99.5 - float64(i), Quantity: 1})
+		snapshot.Asks = append(snapshot.Asks, PriceLevel{Price: 100.5 + float64(i), Quantity: 1})
+	}
+	return snapshot
+}
+
+func TestOrderBook_ApplyUpdate(t *testing.T) {
+	book := NewOrderBook("BTCUSDT")
+	if err := book.ApplyUpdate(&DepthUpdate{FirstUpdateID: 1, FinalUpdateID: 2}); !errors.Is(err, ErrBookNotSynced) {
+		t.Fatalf("expected ErrBookNotSynced before a snapshot, got %v", err)
+	}
+
+	book.ApplySnapshot(bookSnapshot())
+
+	// Stale diffs are ignored
+	if err := book.ApplyUpdate(&DepthUpdate{FirstUpdateID: 90, FinalUpdateID: 100, Bids: []PriceLevel{{99.5, 0}}}); err != nil {
+		t.Fatalf("unexpected error for a stale diff: %v", err)
+	}
+	if book.Quantity(SideBid, 99.5) != 1 {
+		t.Error("expected a stale diff not to change the book")
+	}
+
+	// The first diff straddles the snapshot
+	err := book.ApplyUpdate(&DepthUpdate{
+		FirstUpdateID: 95,
+		FinalUpdateID: 105,
+		Bids:          []PriceLevel{{99.5, 0}, {99.8, 2}},
+		Asks:          []PriceLevel{{100.5, 3}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error for the first diff: %v", err)
+	}
+	if book.LastUpdateID() != 105 {
+		t.Errorf("expected last update 105, got %d", book.LastUpdateID())
+	}
+	bids := book.Bids(2)
+	if len(bids) != 2 || bids[0] != (PriceLevel{99.8, 2}) || bids[1] != (PriceLevel{98.5, 1}) {
+		t.Errorf("unexpected bids %v", bids)
+	}
+	if book.Quantity(SideAsk, 100.5) != 3 {
+		t.Errorf("expected ask 100.5 updated to 3, got %v", book.Quantity(SideAsk, 100.5))
+	}
+
+	// Later diffs must follow on
+	if err := book.ApplyUpdate(&DepthUpdate{FirstUpdateID: 106, FinalUpdateID: 110}); err != nil {
+		t.Fatalf("unexpected error for a following diff: %v", err)
+	}
+	if err := book.ApplyUpdate(&DepthUpdate{FirstUpdateID: 112, FinalUpdateID: 115}); !errors.Is(err, ErrBookGap) {
+		t.Fatalf("expected ErrBookGap, got %v", err)
+	}
+	if book.Synced() {
+		t.Error("expected a gap to unsync the book")
+	}
+}
+
+func TestOrderBook_FirstUpdateGap(t *testing.T) {
+	book := NewOrderBook("BTCUSDT")
+	book.ApplySnapshot(bookSnapshot())
+
+	// The snapshot is older than the diff stream
+	if err := book.ApplyUpdate(&DepthUpdate{FirstUpdateID: 102, FinalUpdateID: 105}); !errors.Is(err, ErrBookGap) {
+		t.Errorf("expected ErrBookGap, got %v", err)
+	}
+}
+
+func TestOrderBook_Features(t *testing.T) {
+	book := NewOrderBook("BTCUSDT")
+	book.ApplySnapshot(bookSnapshot())
+	book.ApplyUpdate(&DepthUpdate{
+		FirstUpdateID: 101,
+		FinalUpdateID: 101,
+		Bids:          []PriceLevel{{97.5, 21}}, // 21 of 25 on the top five bids
+	})
+
+	f := book.Features(3, 5, 3)
+	if f.BestBid != 99.5 || f.BestAsk != 100.5 || f.Mid != 100 || f.Spread != 1 || f.SpreadBps != 100 {
+		t.Errorf("unexpected prices %+v", f)
+	}
+	if f.BidDepth != 23 || f.AskDepth != 3 {
+		t.Errorf("expected depths 23 and 3, got %v and %v", f.BidDepth, f.AskDepth)
+	}
+	if math.Abs(f.Imbalance-20.0/26) > 1e-9 {
+		t.Errorf("expected imbalance %v, got %v", 20.0/26, f.Imbalance)
+	}
+	if f.UpdateID != 101 {
+		t.Errorf("expected update 101, got %d", f.UpdateID)
+	}
+
+	if len(f.Walls) != 1 {
+		t.Fatalf("expected one wall, got %+v", f.Walls)
+	}
+	wall := f.Walls[0]
+	if wall.Side != SideBid || wall.Price != 97.5 || wall.Quantity != 21 || wall.Multiple != 4.2 || wall.Distance != 2.5 {
+		t.Errorf("unexpected wall %+v", wall)
+	}
+
+	if empty := NewOrderBook("ETHUSDT").Features(3, 5, 3); empty.Mid != 0 || len(empty.Walls) != 0 {
+		t.Errorf("expected zero features for an empty book, got %+v", empty)
+	}
+}
+
+func TestCompareWalls(t *testing.T) {
+	bidWall := types.BookWall{Side: SideBid, Price: 97.5, Quantity: 20}
+	askWall := types.BookWall{Side: SideAsk, Price: 102.5, Quantity: 20}
+	newWall := types.BookWall{Side: SideAsk, Price: 104.5, Quantity: 30}
+
+	book := NewOrderBook("BTCUSDT")
+	book.ApplySnapshot(bookSnapshot())
+
+	before := types.BookFeatures{BestBid: 99.5, BestAsk: 100.5, Walls: []types.BookWall{bidWall, askWall}}
+
+	// The bid wall is cancelled, price trades through the ask wall and a new one appears
+	after := types.BookFeatures{BestBid: 102.5, BestAsk: 103.5, Walls: []types.BookWall{newWall}}
+	book.ApplyUpdate(&DepthUpdate{FirstUpdateID: 101, FinalUpdateID: 101, Bids: []PriceLevel{{97.5, 0}}})
+
+	changes := CompareWalls(book, before, after)
+	want := map[string]float64{WallAppeared: 104.5, WallPulled: 97.5, WallFilled: 102.5}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for _, change := range changes {
+		if want[change.Type] != change.Wall.Price {
+			t.Errorf("unexpected %s wall at %v", change.Type, change.Wall.Price)
+		}
+	}
+
+	// A wall that only fell below the threshold isn't reported
+	before = types.BookFeatures{BestBid: 99.5, BestAsk: 100.5, Walls: []types.BookWall{{Side: SideBid, Price: 98.5, Quantity: 1.5}}}
+	after = types.BookFeatures{BestBid: 99.5, BestAsk: 100.5}
+	if changes := CompareWalls(book, before, after); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
@@ -0,0 +1,107 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// combinedStreamURL returns the URL of a combined stream of every symbol's stream
+func combinedStreamURL(wsURL string, symbols []string, stream string) string {
+	streams := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		streams[i] = fmt.Sprintf("%s@%s", strings.ToLower(symbol), stream)
+	}
+	return fmt.Sprintf("%s/stream?streams=%s", wsURL, strings.Join(streams, "/"))
+}
+
+// runStream keeps a WebSocket stream connected until ctx is done, reconnecting with
+// exponential backoff. onConnect runs for every new connection before its first
+// message is handled; handle runs for every message
+func runStream(ctx context.Context, name, url string, onConnect func(), handle func(message []byte)) {
+	var connected func(conn *websocket.Conn) error
+	if onConnect != nil {
+		connected = func(*websocket.Conn) error {
+			onConnect()
+			return nil
+		}
+	}
+	runStreamConn(ctx, name, url, connected, nil, handle)
+}
+
+// runStreamConn is runStream for streams that write to their connection. An error from
+// onConnect drops the connection; onDisconnect runs when a connection is lost or fails
+func runStreamConn(ctx context.Context, name, url string, onConnect func(conn *websocket.Conn) error, onDisconnect func(err error), handle func(message []byte)) {
+	backoff := 1 * time.Second
+	maxBackoff := 60 * time.Second
+
+	for {
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+		if err == nil {
+			log.Printf("[%s] Connected", name)
+			backoff = 1 * time.Second
+			if onConnect != nil {
+				err = onConnect(conn)
+			}
+			if err == nil {
+				err = readStream(ctx, name, conn, handle)
+			} else {
+				log.Printf("[%s] Failed to set up connection: %v", name, err)
+				conn.Close()
+			}
+		} else if ctx.Err() == nil {
+			log.Printf("[%s] Failed to connect: %v", name, err)
+		}
+
+		if onDisconnect != nil {
+			onDisconnect(err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("[%s] Reconnecting in %v", name, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// readStream hands messages to handle until the connection fails or ctx is done
+// Returns the read error, nil if ctx is done
+func readStream(ctx context.Context, name string, conn *websocket.Conn, handle func(message []byte)) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	// Unblock the read on shutdown
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	defer conn.Close()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Printf("[%s] Error reading message: %v", name, err)
+			return err
+		}
+		handle(message)
+	}
+}
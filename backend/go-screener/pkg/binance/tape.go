@@ -0,0 +1,197 @@
+package binance
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Large trades
+//
+// The tape keeps each symbol's recent trade notionals in a ring buffer and flags a
+// trade as large when it reaches a high percentile of them, or a fixed notional
+// whatever the symbol. The percentile is recomputed every recomputeEvery trades
+// rather than per trade, which is cheap and moves slowly enough not to matter
+
+// recomputeEvery is the number of trades between percentile updates
+const recomputeEvery = 500
+
+// TapeConfig configures large trade detection
+type TapeConfig struct {
+	Window      int           // Recent trades in each symbol's size distribution
+	MinSamples  int           // Trades needed before sizes are judged against the distribution
+	Percentile  float64       // Percentile of the distribution a large trade reaches, 0 to disable
+	MinNotional float64       // Notional that is always large, 0 to disable
+	History     time.Duration // How long large trades are kept
+	MaxHistory  int           // Large trades kept per symbol
+}
+
+// DefaultTapeConfig returns the default large trade configuration
+func DefaultTapeConfig() TapeConfig {
+	return TapeConfig{
+		Window:      5000,
+		MinSamples:  1000,
+		Percentile:  99.9,
+		MinNotional: 250000,
+		History:     1 * time.Hour,
+		MaxHistory:  500,
+	}
+}
+
+// Tape flags large trades and keeps each symbol's recent ones
+type Tape struct {
+	config  TapeConfig
+	mu      sync.RWMutex
+	symbols map[string]*symbolTape
+	now     func() time.Time
+}
+
+// symbolTape is one symbol's trade size distribution and large trades
+type symbolTape struct {
+	sizes     []float64 // Ring buffer of recent notionals
+	next      int
+	count     int
+	pending   int     // Trades since the percentile was computed
+	ready     bool    // The percentile has been computed
+	threshold float64 // Notional at the configured percentile, 0 if disabled
+	median    float64
+	large     []types.LargeTrade
+}
+
+// NewTape creates a large trade tape
+func NewTape(config TapeConfig) *Tape {
+	return &Tape{
+		config:  config,
+		symbols: make(map[string]*symbolTape),
+		now:     time.Now,
+	}
+}
+
+// Add judges a trade against the symbol's recent trades and records it if it is large
+// Returns the large trade, or nil
+func (t *Tape) Add(symbol string, trade types.AggTrade) *types.LargeTrade {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.symbols[symbol]
+	if !ok {
+		st = &symbolTape{sizes: make([]float64, t.config.Window)}
+		t.symbols[symbol] = st
+	}
+
+	notional := trade.Price * trade.Quantity
+	large := (t.config.MinNotional > 0 && notional >= t.config.MinNotional) ||
+		(st.ready && st.threshold > 0 && notional >= st.threshold)
+	st.observe(notional, t.config)
+
+	if !large {
+		return nil
+	}
+
+	lt := types.LargeTrade{
+		ID:       trade.ID,
+		Price:    trade.Price,
+		Quantity: trade.Quantity,
+		Notional: notional,
+		Side:     types.TakerBuy,
+		Time:     trade.Time,
+	}
+	if trade.IsBuyerMaker {
+		lt.Side = types.TakerSell
+	}
+	if st.median > 0 {
+		lt.Multiple = notional / st.median
+	}
+
+	st.large = append(st.large, lt)
+	st.prune(trade.Time-t.config.History.Milliseconds(), t.config.MaxHistory)
+	return &lt
+}
+
+// observe adds a notional to the distribution, recomputing the percentile when due
+func (st *symbolTape) observe(notional float64, config TapeConfig) {
+	if len(st.sizes) == 0 {
+		return
+	}
+
+	st.sizes[st.next] = notional
+	st.next = (st.next + 1) % len(st.sizes)
+	if st.count < len(st.sizes) {
+		st.count++
+	}
+
+	st.pending++
+	if st.count < config.MinSamples || (st.ready && st.pending < recomputeEvery) {
+		return
+	}
+	st.pending = 0
+	st.ready = true
+
+	sorted := make([]float64, st.count)
+	copy(sorted, st.sizes[:st.count])
+	sort.Float64s(sorted)
+	st.median = sorted[len(sorted)/2]
+	if config.Percentile > 0 {
+		st.threshold = sorted[int(config.Percentile/100*float64(len(sorted)-1))]
+	}
+}
+
+// prune drops large trades before a time and beyond a count
+func (st *symbolTape) prune(before int64, limit int) {
+	i := sort.Search(len(st.large), func(i int) bool { return st.large[i].Time >= before })
+	if limit > 0 && len(st.large)-i > limit {
+		i = len(st.large) - limit
+	}
+	if i > 0 {
+		st.large = append([]types.LargeTrade(nil), st.large[i:]...)
+	}
+}
+
+// LargeTrades returns a symbol's large trades since a time (ms), oldest first
+func (t *Tape) LargeTrades(symbol string, since int64) []types.LargeTrade {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	st, ok := t.symbols[symbol]
+	if !ok {
+		return nil
+	}
+	i := sort.Search(len(st.large), func(i int) bool { return st.large[i].Time >= since })
+	return append([]types.LargeTrade(nil), st.large[i:]...)
+}
+
+// Source returns the trade tape for a symbol's market data, nil if its trades aren't tracked
+func (t *Tape) Source(symbol string) types.TradeTape {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if _, ok := t.symbols[symbol]; !ok {
+		return nil
+	}
+	return &symbolTapeSource{tape: t, symbol: symbol}
+}
+
+// symbolTapeSource serves one symbol's large trades
+type symbolTapeSource struct {
+	tape   *Tape
+	symbol string
+}
+
+// LargeTrades implements types.TradeTape
+func (s *symbolTapeSource) LargeTrades(minutes int) []types.LargeTrade {
+	since := s.tape.now().Add(-time.Duration(minutes) * time.Minute).UnixMilli()
+	return s.tape.LargeTrades(s.symbol, since)
+}
@@ -0,0 +1,87 @@
+package binance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// tapeTrade returns a trade of a notional at price 100, seconds after start
+func tapeTrade(id int64, notional float64, seconds int, buyerMaker bool) types.AggTrade {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	return types.AggTrade{ID: id, Price: 100, Quantity: notional / 100, Time: start + int64(seconds)*1000, IsBuyerMaker: buyerMaker}
+}
+
+func TestTape_Percentile(t *testing.T) {
+	tape := NewTape(TapeConfig{Window: 100, MinSamples: 100, Percentile: 99, History: time.Hour})
+
+	// Not judged against the distribution until it has enough trades
+	for i := 0; i < 100; i++ {
+		notional := 1000.0
+		if i == 50 {
+			notional = 5000
+		}
+		if large := tape.Add("BTCUSDT", tapeTrade(int64(i), notional, i, false)); large != nil {
+			t.Fatalf("unexpected large trade %+v before the distribution is known", large)
+		}
+	}
+
+	// The 99th percentile of 99 trades of 1000 and one of 5000 is 1000
+	large := tape.Add("BTCUSDT", tapeTrade(100, 3000, 100, true))
+	if large == nil {
+		t.Fatal("expected a trade at the percentile to be large")
+	}
+	if large.Side != types.TakerSell || large.Notional != 3000 || large.Multiple != 3 {
+		t.Errorf("unexpected large trade %+v", large)
+	}
+	if large := tape.Add("BTCUSDT", tapeTrade(101, 900, 101, false)); large != nil {
+		t.Errorf("expected a small trade not to be large, got %+v", large)
+	}
+}
+
+func TestTape_NotionalAndHistory(t *testing.T) {
+	tape := NewTape(TapeConfig{MinNotional: 10000, History: 10 * time.Minute, MaxHistory: 3})
+
+	tape.Add("BTCUSDT", tapeTrade(1, 15000, 0, false))
+	tape.Add("BTCUSDT", tapeTrade(2, 5000, 60, false))
+	tape.Add("BTCUSDT", tapeTrade(3, 12000, 120, true))
+	if trades := tape.LargeTrades("BTCUSDT", 0); len(trades) != 2 || trades[0].ID != 1 || trades[1].ID != 3 {
+		t.Fatalf("expected trades 1 and 3, got %+v", trades)
+	}
+
+	// Trades older than the history are dropped, and beyond the count
+	tape.Add("BTCUSDT", tapeTrade(4, 20000, 11*60, false))
+	if trades := tape.LargeTrades("BTCUSDT", 0); len(trades) != 2 || trades[0].ID != 3 {
+		t.Fatalf("expected trades 3 and 4, got %+v", trades)
+	}
+	for id := int64(5); id < 8; id++ {
+		tape.Add("BTCUSDT", tapeTrade(id, 20000, 12*60, false))
+	}
+	if trades := tape.LargeTrades("BTCUSDT", 0); len(trades) != 3 || trades[0].ID != 5 {
+		t.Errorf("expected the last 3 trades, got %+v", trades)
+	}
+}
+
+func TestTape_Source(t *testing.T) {
+	var nilTape *Tape
+	if nilTape.Source("BTCUSDT") != nil || nilTape.Add("BTCUSDT", tapeTrade(1, 1, 0, false)) != nil {
+		t.Error("expected a nil tape to track nothing")
+	}
+
+	tape := NewTape(TapeConfig{MinNotional: 10000, History: time.Hour})
+	tape.Add("BTCUSDT", tapeTrade(1, 15000, 0, false))
+	tape.Add("BTCUSDT", tapeTrade(2, 15000, 600, true))
+	tape.now = func() time.Time { return time.UnixMilli(tapeTrade(0, 0, 900, false).Time) }
+
+	if tape.Source("ETHUSDT") != nil {
+		t.Error("expected no source for a symbol without trades")
+	}
+	source := tape.Source("BTCUSDT")
+	if trades := source.LargeTrades(10); len(trades) != 1 || trades[0].ID != 2 {
+		t.Errorf("expected the trade of the last 10 minutes, got %+v", trades)
+	}
+	if trades := source.LargeTrades(30); len(trades) != 2 {
+		t.Errorf("expected both trades in the last 30 minutes, got %+v", trades)
+	}
+}
@@ -0,0 +1,120 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/vyx/go-screener/internal/eventbus"
+	"github.com/vyx/go-screener/pkg/cache"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// TradeStream ingests the @aggTrade stream into the footprint candles of the kline
+// cache and the large trade tape, publishing large trades on the event bus
+type TradeStream struct {
+	wsURL    string
+	client   *Client
+	cache    *cache.KlineCache
+	tape     *Tape
+	eventBus *eventbus.EventBus
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// aggTradeStreamMessage wraps a trade from combined aggTrade streams
+type aggTradeStreamMessage struct {
+	Stream string `json:"stream"`
+	Data   struct {
+		EventType    string `json:"e"`
+		EventTime    int64  `json:"E"`
+		Symbol       string `json:"s"`
+		TradeID      int64  `json:"a"`
+		Price        string `json:"p"`
+		Quantity     string `json:"q"`
+		TradeTime    int64  `json:"T"`
+		IsBuyerMaker bool   `json:"m"`
+		Ignore       bool   `json:"M"` // Keeps "M" from matching "m" case-insensitively
+	} `json:"data"`
+}
+
+// NewTradeStream creates an aggregate trade stream feeding the cache and tape
+func NewTradeStream(wsURL string, client *Client, cache *cache.KlineCache, tape *Tape, eventBus *eventbus.EventBus) *TradeStream {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &TradeStream{
+		wsURL:    wsURL,
+		client:   client,
+		cache:    cache,
+		tape:     tape,
+		eventBus: eventBus,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins ingesting the trades of the given symbols
+// Tick sizes are fetched first to size footprint levels; without them levels are sized from price
+func (t *TradeStream) Start(symbols []string) {
+	ctx, cancel := context.WithTimeout(t.ctx, 10*time.Second)
+	ticks, err := t.client.GetTickSizes(ctx, symbols)
+	cancel()
+	if err != nil {
+		log.Printf("[TradeStream] Failed to fetch tick sizes, sizing footprint levels from price: %v", err)
+	}
+	for symbol, tick := range ticks {
+		t.cache.SetTickSize(symbol, tick)
+	}
+
+	log.Printf("[TradeStream] Ingesting trades for %d symbols", len(symbols))
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		runStream(t.ctx, "TradeStream", combinedStreamURL(t.wsURL, symbols, "aggTrade"), nil, t.handleMessage)
+	}()
+}
+
+// handleMessage adds a trade from the combined stream to the cache and tape
+func (t *TradeStream) handleMessage(message []byte) {
+	var msg aggTradeStreamMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("[TradeStream] Error unmarshaling trade: %v", err)
+		return
+	}
+
+	symbol := msg.Data.Symbol
+	trade := types.AggTrade{
+		ID:           msg.Data.TradeID,
+		Price:        parseFloat(msg.Data.Price),
+		Quantity:     parseFloat(msg.Data.Quantity),
+		Time:         msg.Data.TradeTime,
+		IsBuyerMaker: msg.Data.IsBuyerMaker,
+	}
+	t.cache.AddTrade(symbol, trade)
+
+	large := t.tape.Add(symbol, trade)
+	if large == nil || t.eventBus == nil {
+		return
+	}
+	t.eventBus.PublishLargeTradeEvent(&eventbus.LargeTradeEvent{
+		Symbol: symbol,
+		Trade:  *large,
+		Time:   time.Now(),
+	})
+}
+
+// Close stops the stream
+func (t *TradeStream) Close() error {
+	log.Println("[TradeStream] Closing trade stream")
+
+	t.cancel()
+	t.wg.Wait()
+
+	log.Println("[TradeStream] Closed successfully")
+	return nil
+}
@@ -0,0 +1,57 @@
+package binance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vyx/go-screener/internal/eventbus"
+	"github.com/vyx/go-screener/pkg/cache"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+func TestTradeStream_HandleMessage(t *testing.T) {
+	klineCache := cache.NewKlineCache(500)
+	if err := klineCache.EnableFootprints(cache.FootprintConfig{Intervals: []string{"1m"}, TicksPerLevel: 10}); err != nil {
+		t.Fatalf("EnableFootprints failed: %v", err)
+	}
+	klineCache.SetTickSize("BTCUSDT", 0.01)
+	tape := NewTape(TapeConfig{MinNotional: 20000, History: time.Hour})
+	bus := eventbus.NewEventBus()
+	largeTrades := bus.SubscribeLargeTrades()
+	stream := NewTradeStream("", nil, klineCache, tape, bus)
+
+	stream.handleMessage([]byte(`{"stream":"btcusdt@aggTrade","data":{"e":"aggTrade","E":1700000000100,"s":"BTCUSDT","a":12345,"p":"60000.15","q":"0.50000000","f":100,"l":105,"T":1700000000050,"m":true,"M":true}}`))
+	stream.handleMessage([]byte(`{"stream":"btcusdt@aggTrade","data":{"e":"aggTrade","E":1700000000200,"s":"BTCUSDT","a":12346,"p":"60000.19","q":"0.25000000","f":106,"l":106,"T":1700000000150,"m":false,"M":true}}`))
+	stream.handleMessage([]byte(`not json`))
+
+	footprints, err := klineCache.GetFootprints("BTCUSDT", "1m", 10)
+	if err != nil {
+		t.Fatalf("GetFootprints failed: %v", err)
+	}
+	if len(footprints) != 1 || len(footprints[0].Levels) != 1 {
+		t.Fatalf("expected one candle with one level, got %+v", footprints)
+	}
+	level := footprints[0].Levels[0]
+	if level.Price != 60000.1 || level.BidVolume != 0.5 || level.AskVolume != 0.25 || level.Trades != 2 {
+		t.Errorf("unexpected level %+v", level)
+	}
+	if footprints[0].OpenTime != 1700000000050-1700000000050%60000 {
+		t.Errorf("unexpected open time %d", footprints[0].OpenTime)
+	}
+
+	// Only the first trade is worth over 20000
+	select {
+	case event := <-largeTrades:
+		if event.Symbol != "BTCUSDT" || event.Trade.ID != 12345 || event.Trade.Side != types.TakerSell {
+			t.Errorf("unexpected large trade event %+v", event)
+		}
+	default:
+		t.Fatal("expected a large trade event")
+	}
+	if len(largeTrades) != 0 {
+		t.Errorf("expected one large trade event, got %d more", len(largeTrades))
+	}
+	if trades := tape.LargeTrades("BTCUSDT", 0); len(trades) != 1 {
+		t.Errorf("expected one large trade on the tape, got %d", len(trades))
+	}
+}
@@ -3,35 +3,135 @@ package binance
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/vyx/go-screener/internal/eventbus"
+	"github.com/vyx/go-screener/internal/scheduler"
 	"github.com/vyx/go-screener/pkg/cache"
 	"github.com/vyx/go-screener/pkg/types"
 )
 
+// streamsPerRequest caps the streams of one SUBSCRIBE or UNSUBSCRIBE request
+const streamsPerRequest = 100
+
+// maxBackfillKlines caps the klines of one backfill request, Binance's kline limit
+const maxBackfillKlines = 1000
+
+// WSConfig configures the kline stream connection pool
+type WSConfig struct {
+	StreamsPerShard int           // Streams per connection, up to Binance's limit of 1024
+	RequestPause    time.Duration // Pause between requests on a connection, Binance allows 5 messages per second
+	ConnectTimeout  time.Duration // How long Connect waits for new connections
+	BackfillPause   time.Duration // Pause between backfill requests after a reconnect
+}
+
+// DefaultWSConfig returns the default connection pool configuration
+func DefaultWSConfig() WSConfig {
+	return WSConfig{
+		StreamsPerShard: 200,
+		RequestPause:    250 * time.Millisecond,
+		ConnectTimeout:  10 * time.Second,
+		BackfillPause:   100 * time.Millisecond,
+	}
+}
+
 // WSClient handles WebSocket connections to Binance for kline streams
-// Uses a single connection with combined streams for all symbol+interval pairs
+// Streams are sharded across a pool of connections holding up to StreamsPerShard
+// streams each, and added or removed at runtime with SUBSCRIBE/UNSUBSCRIBE requests.
+// Candles missed while a connection was down are backfilled over REST
 type WSClient struct {
-	wsURL              string
-	conn               *websocket.Conn
-	mu                 sync.RWMutex
-	cache              *cache.KlineCache
-	eventBus           *eventbus.EventBus
-	symbols            []string
-	intervals          []string
-	ctx                context.Context
-	cancel             context.CancelFunc
-	reconnectCh        chan struct{}
-	doneCh             chan struct{}
-	isConnected        bool
-	lastClosedCandles  map[string]int64  // key: "BTCUSDT-1m", value: closeTime (deduplication)
-	lastClosedMu       sync.RWMutex
+	wsURL    string
+	client   *Client // Backfills gaps; nil only records them
+	cache    *cache.KlineCache
+	eventBus *eventbus.EventBus
+	config   WSConfig
+
+	mu           sync.RWMutex
+	symbols      []string
+	intervals    []string
+	shards       []*wsShard
+	streamShards map[string]*wsShard // stream -> shard subscribed to it
+	nextShardID  int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lastClosedCandles map[string]int64 // key: "BTCUSDT-1m", value: closeTime (deduplication)
+	lastClosedMu      sync.RWMutex
+
+	sequencesMu sync.Mutex
+	sequences   map[string]*symbolSequence // symbol -> its closed klines' sequence
+	workers     sync.WaitGroup             // Symbol workers with queued klines or backfills
+
+	gapsMu sync.Mutex
+	gaps   GapStats
+	now    func() time.Time
+}
+
+// symbolSequence serializes the closed klines of a symbol, live or backfilled, so the
+// cache and candle close events get them in order
+// A symbol is owned by a read loop holding mu while its queue is idle, or else by its
+// worker, which works through the queue without holding mu so REST calls don't block
+// the read loops
+type symbolSequence struct {
+	mu      sync.Mutex
+	queue   []func() // Work waiting for the worker, changed with mu held
+	running bool     // A worker owns the symbol until its queue is empty, set with mu held
+
+	backfilling bool // Candles closing now are backfilled, set by the symbol's owner
+}
+
+// wsShard is one connection of the pool
+// streams changes with both the client's and the shard's lock held, so either allows reading it
+type wsShard struct {
+	id        int
+	client    *WSClient
+	ctx       context.Context
+	cancel    context.CancelFunc
+	ready     chan struct{} // Closed once first connected
+	readyOnce sync.Once
+	done      chan struct{} // Closed when the shard stops
+	backfills sync.WaitGroup
+
+	writeMu   sync.Mutex // Serializes and paces requests
+	lastWrite time.Time
+	nextID    int64
+
+	mu          sync.Mutex
+	conn        *websocket.Conn // nil while disconnected
+	streams     map[string]bool
+	connections int
+	messages    int64
+	lastMessage time.Time
+	lastError   string
+}
+
+// wsRequest subscribes to or unsubscribes from streams
+type wsRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int64    `json:"id"`
+}
+
+// wsResponse answers a request; failures carry an error object or a top-level code and msg
+type wsResponse struct {
+	ID    int64    `json:"id"`
+	Code  int      `json:"code"`
+	Msg   string   `json:"msg"`
+	Error *wsError `json:"error"`
+}
+
+type wsError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
 }
 
 // KlineEvent represents a Binance kline WebSocket event
@@ -40,145 +140,417 @@ type KlineEvent struct {
 	EventTime int64  `json:"E"`
 	Symbol    string `json:"s"`
 	Kline     struct {
-		StartTime            int64  `json:"t"`
-		CloseTime            int64  `json:"T"`
-		Symbol               string `json:"s"`
-		Interval             string `json:"i"`
-		FirstTradeID         int64  `json:"f"`
-		LastTradeID          int64  `json:"L"`
-		Open                 string `json:"o"`
-		Close                string `json:"c"`
-		High                 string `json:"h"`
-		Low                  string `json:"l"`
-		Volume               string `json:"v"`
-		TradeCount           int    `json:"n"`
-		IsClosed             bool   `json:"x"`
-		QuoteVolume          string `json:"q"`
-		TakerBuyBaseVolume   string `json:"V"`
-		TakerBuyQuoteVolume  string `json:"Q"`
-		Ignore               string `json:"B"`
+		StartTime           int64  `json:"t"`
+		CloseTime           int64  `json:"T"`
+		Symbol              string `json:"s"`
+		Interval            string `json:"i"`
+		FirstTradeID        int64  `json:"f"`
+		LastTradeID         int64  `json:"L"`
+		Open                string `json:"o"`
+		Close               string `json:"c"`
+		High                string `json:"h"`
+		Low                 string `json:"l"`
+		Volume              string `json:"v"`
+		TradeCount          int    `json:"n"`
+		IsClosed            bool   `json:"x"`
+		QuoteVolume         string `json:"q"`
+		TakerBuyBaseVolume  string `json:"V"`
+		TakerBuyQuoteVolume string `json:"Q"`
+		Ignore              string `json:"B"`
 	} `json:"k"`
 }
 
 // StreamMessage wraps the kline event from combined streams
 type StreamMessage struct {
-	Stream string      `json:"stream"`
-	Data   KlineEvent  `json:"data"`
+	Stream string     `json:"stream"`
+	Data   KlineEvent `json:"data"`
 }
 
 // NewWSClient creates a new WebSocket client for Binance kline streams
-func NewWSClient(wsURL string, cache *cache.KlineCache, eventBus *eventbus.EventBus) *WSClient {
+// The REST client backfills candles the streams miss
+func NewWSClient(wsURL string, client *Client, cache *cache.KlineCache, eventBus *eventbus.EventBus, config WSConfig) *WSClient {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &WSClient{
+	w := &WSClient{
 		wsURL:             wsURL,
+		client:            client,
 		cache:             cache,
 		eventBus:          eventBus,
+		config:            config,
+		streamShards:      make(map[string]*wsShard),
 		ctx:               ctx,
 		cancel:            cancel,
-		reconnectCh:       make(chan struct{}, 1),
-		doneCh:            make(chan struct{}),
 		lastClosedCandles: make(map[string]int64),
+		sequences:         make(map[string]*symbolSequence),
+		now:               time.Now,
 	}
+
+	// Candles the cache resamples from streamed klines close like streamed ones
+	if cache != nil {
+		cache.OnClose(w.publishCandleClose)
+	}
+
+	return w
 }
 
-// Connect establishes WebSocket connection and subscribes to kline streams
+// Connect subscribes to the kline streams of every symbol and interval and waits for
+// the connections to come up. Dropped connections reconnect and resubscribe on their own
 func (w *WSClient) Connect(symbols []string, intervals []string) error {
 	w.mu.Lock()
-	w.symbols = symbols
 	w.intervals = intervals
 	w.mu.Unlock()
 
-	// Build stream names for all symbol+interval combinations
-	streams := make([]string, 0, len(symbols)*len(intervals))
-	for _, symbol := range symbols {
-		for _, interval := range intervals {
-			// Convert to lowercase for Binance WebSocket
-			stream := fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
-			streams = append(streams, stream)
+	log.Printf("[WSClient] Subscribing to %d symbols × %d intervals = %d streams", len(symbols), len(intervals), len(symbols)*len(intervals))
+	if err := w.Subscribe(symbols); err != nil {
+		return err
+	}
+
+	w.mu.RLock()
+	shards := append([]*wsShard(nil), w.shards...)
+	w.mu.RUnlock()
+
+	timeout := time.After(w.config.ConnectTimeout)
+	for _, shard := range shards {
+		select {
+		case <-shard.ready:
+		case <-timeout:
+			return fmt.Errorf("failed to connect to WebSocket: shard %d: %s", shard.id, shard.stats().LastError)
 		}
 	}
 
-	// Build WebSocket URL with combined streams
-	streamParam := strings.Join(streams, "/")
-	url := fmt.Sprintf("%s/stream?streams=%s", w.wsURL, streamParam)
+	log.Printf("[WSClient] Connected successfully (%d connections)", len(shards))
+	return nil
+}
 
-	log.Printf("[WSClient] Connecting to WebSocket...")
-	log.Printf("[WSClient] Subscribing to %d symbols × %d intervals = %d streams", len(symbols), len(intervals), len(streams))
+// Subscribe adds the kline streams of symbols on the connected intervals
+// Streams go to shards with room, opening connections as needed
+func (w *WSClient) Subscribe(symbols []string) error {
+	w.mu.Lock()
+	added := make(map[*wsShard][]string)
+	var opened []*wsShard
+	for _, symbol := range symbols {
+		if !slices.Contains(w.symbols, symbol) {
+			w.symbols = append(w.symbols, symbol)
+		}
+		for _, interval := range w.intervals {
+			stream := klineStream(symbol, interval)
+			if _, ok := w.streamShards[stream]; ok {
+				continue
+			}
 
-	// Connect
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+			shard := w.shardWithRoom()
+			if shard == nil {
+				shard = w.newShard()
+				opened = append(opened, shard)
+			}
+			shard.mu.Lock()
+			shard.streams[stream] = true
+			shard.mu.Unlock()
+			w.streamShards[stream] = shard
+			added[shard] = append(added[shard], stream)
+		}
+	}
+	// New shards subscribe to their streams once connected
+	for _, shard := range opened {
+		shard.start()
+		delete(added, shard)
 	}
+	w.mu.Unlock()
 
+	var errs []error
+	for shard, streams := range added {
+		if err := shard.send("SUBSCRIBE", streams); err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", shard.id, err))
+		}
+	}
+	if len(opened) > 0 {
+		log.Printf("[WSClient] Opened %d connections", len(opened))
+	}
+	return errors.Join(errs...)
+}
+
+// Unsubscribe removes the kline streams of symbols, closing connections left without streams
+func (w *WSClient) Unsubscribe(symbols []string) error {
 	w.mu.Lock()
-	w.conn = conn
-	w.isConnected = true
-	w.mu.Unlock()
+	removed := make(map[*wsShard][]string)
+	for _, symbol := range symbols {
+		w.symbols = slices.DeleteFunc(w.symbols, func(s string) bool { return s == symbol })
+		for _, interval := range w.intervals {
+			stream := klineStream(symbol, interval)
+			shard, ok := w.streamShards[stream]
+			if !ok {
+				continue
+			}
+
+			delete(w.streamShards, stream)
+			shard.mu.Lock()
+			delete(shard.streams, stream)
+			shard.mu.Unlock()
+			removed[shard] = append(removed[shard], stream)
+		}
+	}
 
-	log.Printf("[WSClient] Connected successfully")
+	var kept, closed []*wsShard
+	for _, shard := range w.shards {
+		if len(shard.streams) == 0 {
+			closed = append(closed, shard)
+			delete(removed, shard)
+		} else {
+			kept = append(kept, shard)
+		}
+	}
+	w.shards = kept
+	w.mu.Unlock()
 
-	// Start message handler in goroutine
-	go w.handleMessages()
+	for _, shard := range closed {
+		shard.stop()
+	}
+	if len(closed) > 0 {
+		log.Printf("[WSClient] Closed %d connections left without streams", len(closed))
+	}
 
-	// Start reconnection handler
-	go w.reconnectHandler()
+	var errs []error
+	for shard, streams := range removed {
+		if err := shard.send("UNSUBSCRIBE", streams); err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", shard.id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
 
+// shardWithRoom returns the first shard below StreamsPerShard streams, nil if all are full
+// Callers hold w.mu
+func (w *WSClient) shardWithRoom() *wsShard {
+	for _, shard := range w.shards {
+		if len(shard.streams) < w.config.StreamsPerShard {
+			return shard
+		}
+	}
 	return nil
 }
 
-// handleMessages processes incoming WebSocket messages
-func (w *WSClient) handleMessages() {
-	defer func() {
-		w.mu.Lock()
-		w.isConnected = false
-		w.mu.Unlock()
-		close(w.doneCh)
+// newShard adds a shard to the pool; it connects once started
+// Callers hold w.mu
+func (w *WSClient) newShard() *wsShard {
+	ctx, cancel := context.WithCancel(w.ctx)
+	w.nextShardID++
+	shard := &wsShard{
+		id:      w.nextShardID,
+		client:  w,
+		ctx:     ctx,
+		cancel:  cancel,
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+		streams: make(map[string]bool),
+	}
+	w.shards = append(w.shards, shard)
+	return shard
+}
+
+// klineStream returns the name of a kline stream
+func klineStream(symbol, interval string) string {
+	return fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
+}
+
+// start keeps the shard connected until it is stopped
+func (s *wsShard) start() {
+	go func() {
+		defer close(s.done)
+		name := fmt.Sprintf("WSClient shard %d", s.id)
+		runStreamConn(s.ctx, name, s.client.wsURL+"/stream", s.onConnect, s.onDisconnect, s.handleMessage)
+		s.backfills.Wait()
 	}()
+}
 
-	for {
-		select {
-		case <-w.ctx.Done():
-			return
-		default:
-			w.mu.RLock()
-			conn := w.conn
-			w.mu.RUnlock()
+// stop closes the shard's connection and waits for it to stop
+func (s *wsShard) stop() {
+	s.cancel()
+	<-s.done
+}
 
-			if conn == nil {
-				time.Sleep(1 * time.Second)
-				continue
+// onConnect subscribes a new connection to the shard's streams
+func (s *wsShard) onConnect(conn *websocket.Conn) error {
+	// Streams subscribed from now on are sent on the new connection directly
+	s.mu.Lock()
+	s.conn = conn
+	s.connections++
+	reconnected := s.connections > 1
+	streams := make([]string, 0, len(s.streams))
+	for stream := range s.streams {
+		streams = append(streams, stream)
+	}
+	s.mu.Unlock()
+	sort.Strings(streams)
+
+	if err := s.write(conn, "SUBSCRIBE", streams); err != nil {
+		return err
+	}
+	s.readyOnce.Do(func() { close(s.ready) })
+
+	// Candles that closed while disconnected are missing from the cache
+	if reconnected {
+		s.backfill()
+	}
+	return nil
+}
+
+// backfill checks every stream of the shard for missed candles in the background
+func (s *wsShard) backfill() {
+	w := s.client
+	type stream struct{ symbol, interval string }
+	var streams []stream
+	w.mu.RLock()
+	for _, symbol := range w.symbols {
+		for _, interval := range w.intervals {
+			if w.streamShards[klineStream(symbol, interval)] == s {
+				streams = append(streams, stream{symbol, interval})
 			}
+		}
+	}
+	w.mu.RUnlock()
 
-			// Read message
-			_, message, err := conn.ReadMessage()
-			if err != nil {
-				log.Printf("[WSClient] Error reading message: %v", err)
-				w.triggerReconnect()
+	s.backfills.Add(1)
+	go func() {
+		defer s.backfills.Done()
+		for _, st := range streams {
+			if s.ctx.Err() != nil {
 				return
 			}
-
-			// Parse and handle kline event
-			if err := w.handleKlineEvent(message); err != nil {
-				log.Printf("[WSClient] Error handling kline event: %v", err)
+			// The gap is filled on the symbol's worker, in order with its live candles
+			seq := w.sequence(st.symbol)
+			done := make(chan bool, 1)
+			seq.mu.Lock()
+			w.enqueue(seq, func() { done <- w.fillGap(s.ctx, seq, st.symbol, st.interval, 0) })
+			seq.mu.Unlock()
+			fetched := <-done
+
+			// Pace requests under the REST rate limit
+			if fetched {
+				select {
+				case <-s.ctx.Done():
+				case <-time.After(w.config.BackfillPause):
+				}
 			}
 		}
+	}()
+}
+
+// onDisconnect records a lost or failed connection
+func (s *wsShard) onDisconnect(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn = nil
+	if err != nil {
+		s.lastError = err.Error()
 	}
 }
 
-// handleKlineEvent parses and processes a kline event
-func (w *WSClient) handleKlineEvent(message []byte) error {
-	var streamMsg StreamMessage
-	if err := json.Unmarshal(message, &streamMsg); err != nil {
-		return fmt.Errorf("failed to unmarshal stream message: %w", err)
+// send makes a request on the current connection
+// While disconnected nothing is sent: the shard's streams are subscribed on reconnect
+func (s *wsShard) send(method string, streams []string) error {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	if conn == nil {
+		return nil
 	}
+	return s.write(conn, method, streams)
+}
 
-	event := streamMsg.Data
+// write makes requests of up to streamsPerRequest streams, pacing them under the message rate limit
+func (s *wsShard) write(conn *websocket.Conn, method string, streams []string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
+	for start := 0; start < len(streams); start += streamsPerRequest {
+		if wait := s.client.config.RequestPause - time.Since(s.lastWrite); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		s.nextID++
+		request := wsRequest{
+			Method: method,
+			Params: streams[start:min(start+streamsPerRequest, len(streams))],
+			ID:     s.nextID,
+		}
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		err := conn.WriteJSON(request)
+		s.lastWrite = time.Now()
+		if err != nil {
+			return fmt.Errorf("failed to %s: %w", strings.ToLower(method), err)
+		}
+	}
+	return nil
+}
+
+// handleMessage processes a kline event or a request response
+func (s *wsShard) handleMessage(message []byte) {
+	var msg StreamMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("[WSClient] Error handling kline event: failed to unmarshal stream message: %v", err)
+		return
+	}
+	if msg.Stream == "" {
+		s.handleResponse(message)
+		return
+	}
+
+	s.mu.Lock()
+	s.messages++
+	s.lastMessage = time.Now()
+	subscribed := s.streams[msg.Stream]
+	s.mu.Unlock()
+
+	// Events can trail an unsubscribe
+	if subscribed {
+		s.client.handleKlineEvent(s.ctx, &msg.Data)
+	}
+}
+
+// handleResponse records failed requests
+func (s *wsShard) handleResponse(message []byte) {
+	var resp wsResponse
+	if err := json.Unmarshal(message, &resp); err != nil {
+		return
+	}
+	if resp.Error == nil && resp.Msg == "" {
+		return
+	}
+
+	failure := resp.Error
+	if failure == nil {
+		failure = &wsError{Code: resp.Code, Msg: resp.Msg}
+	}
+	log.Printf("[WSClient] Shard %d request %d failed: %d %s", s.id, resp.ID, failure.Code, failure.Msg)
+
+	s.mu.Lock()
+	s.lastError = failure.Msg
+	s.mu.Unlock()
+}
+
+// stats returns the shard's health
+func (s *wsShard) stats() ShardStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return ShardStats{
+		ID:          s.id,
+		Connected:   s.conn != nil,
+		Streams:     len(s.streams),
+		Messages:    s.messages,
+		Reconnects:  max(s.connections-1, 0),
+		LastMessage: s.lastMessage,
+		LastError:   s.lastError,
+	}
+}
+
+// handleKlineEvent adds a closed kline to the cache and publishes its close
+// Candles missing before it are backfilled first on the symbol's worker, which holds the
+// symbol's later candles until the backfill is done while the shard reads on
+func (w *WSClient) handleKlineEvent(ctx context.Context, event *KlineEvent) {
 	// Only update cache if kline is closed (complete candle)
 	if !event.Kline.IsClosed {
-		return nil // Skip incomplete candles
+		return // Skip incomplete candles
 	}
 
 	// Convert to our Kline type with volume enrichment
@@ -207,157 +579,313 @@ func (w *WSClient) handleKlineEvent(message []byte) error {
 		NumberOfTrades:           event.Kline.TradeCount,
 	}
 
+	symbol, interval := event.Symbol, event.Kline.Interval
+	seq := w.sequence(symbol)
+	seq.mu.Lock()
+	defer seq.mu.Unlock()
+
+	// Candles behind queued work or a gap to backfill wait for the symbol's worker
+	if _, gap := w.gap(symbol, interval, kline.OpenTime); seq.running || gap && w.client != nil {
+		w.enqueue(seq, func() { w.addClosedKline(ctx, seq, symbol, interval, kline) })
+		return
+	}
+	w.addClosedKline(ctx, seq, symbol, interval, kline)
+}
+
+// addClosedKline backfills the candles missing before a closed kline, then adds it to
+// the cache and publishes its close. Callers own the symbol's sequence
+func (w *WSClient) addClosedKline(ctx context.Context, seq *symbolSequence, symbol, interval string, kline types.Kline) {
+	// The cache only appends, so candles older than the latest are dropped
+	if latest, err := w.cache.GetLatestKline(symbol, interval); err == nil && kline.OpenTime < latest.OpenTime {
+		log.Printf("[WSClient] Dropped out-of-order candle %s-%s opened at %d", symbol, interval, kline.OpenTime)
+		return
+	}
+	w.fillGap(ctx, seq, symbol, interval, kline.OpenTime)
+
 	// Update cache
-	w.cache.Update(event.Symbol, event.Kline.Interval, kline)
-
-	// Emit candle close event (with deduplication)
-	if w.eventBus != nil {
-		key := fmt.Sprintf("%s-%s", event.Symbol, event.Kline.Interval)
-
-		// Check if we already processed this candle
-		w.lastClosedMu.RLock()
-		lastCloseTime := w.lastClosedCandles[key]
-		w.lastClosedMu.RUnlock()
-
-		if lastCloseTime != event.Kline.CloseTime {
-			// Update last closed candle time
-			w.lastClosedMu.Lock()
-			w.lastClosedCandles[key] = event.Kline.CloseTime
-			w.lastClosedMu.Unlock()
-
-			// Emit event
-			closeTime := time.Unix(event.Kline.CloseTime/1000, 0)
-			w.eventBus.PublishCandleCloseEvent(&eventbus.CandleCloseEvent{
-				Symbol:    event.Symbol,
-				Interval:  event.Kline.Interval,
-				Kline:     kline,
-				CloseTime: closeTime,
-			})
-
-			log.Printf("[WSClient] Candle closed: %s-%s at %s", event.Symbol, event.Kline.Interval, closeTime.Format("15:04:05"))
+	w.cache.Update(symbol, interval, kline)
+
+	// Emit candle close event
+	w.publishCandleClose(symbol, interval, kline)
+}
+
+// enqueue queues work for a symbol's worker, starting the worker if it is idle
+// Callers hold seq.mu
+func (w *WSClient) enqueue(seq *symbolSequence, work func()) {
+	seq.queue = append(seq.queue, work)
+	if seq.running {
+		return
+	}
+	seq.running = true
+	w.workers.Add(1)
+	go w.work(seq)
+}
+
+// work does a symbol's queued work in order, owning the symbol until the queue is empty
+func (w *WSClient) work(seq *symbolSequence) {
+	defer w.workers.Done()
+	for {
+		seq.mu.Lock()
+		if len(seq.queue) == 0 {
+			seq.running = false
+			seq.mu.Unlock()
+			return
 		}
+		work := seq.queue[0]
+		seq.queue = seq.queue[1:]
+		seq.mu.Unlock()
+
+		work()
 	}
+}
 
-	return nil
+// sequence returns the sequence of a symbol's closed klines
+func (w *WSClient) sequence(symbol string) *symbolSequence {
+	w.sequencesMu.Lock()
+	defer w.sequencesMu.Unlock()
+
+	seq, ok := w.sequences[symbol]
+	if !ok {
+		seq = &symbolSequence{}
+		w.sequences[symbol] = seq
+	}
+	return seq
 }
 
-// triggerReconnect signals that a reconnection is needed
-func (w *WSClient) triggerReconnect() {
-	select {
-	case w.reconnectCh <- struct{}{}:
-	default:
-		// Channel already has a reconnect signal
+// klineGap spans the candles missing from the cache, opening from from until until
+type klineGap struct {
+	from, until, step int64
+}
+
+// gap returns the candles missing from the cache before the one opening at until, or
+// before the open candle if until is 0, and whether any are
+func (w *WSClient) gap(symbol, interval string, until int64) (klineGap, bool) {
+	// Without a cached candle there is nothing to compare with
+	last, err := w.cache.GetLatestKline(symbol, interval)
+	if err != nil {
+		return klineGap{}, false
 	}
+	duration, err := scheduler.ParseInterval(interval)
+	if err != nil {
+		return klineGap{}, false
+	}
+	step := duration.Milliseconds()
+	if until == 0 {
+		until = last.OpenTime + (w.now().UnixMilli()-last.OpenTime)/step*step
+	}
+
+	// A cached candle whose close was never seen, such as the open candle of the
+	// bootstrap, is stale and backfilled too
+	from := last.OpenTime + step
+	if w.lastCloseTime(symbol, interval) != last.CloseTime {
+		from = last.OpenTime
+	}
+	if until <= from {
+		return klineGap{}, false
+	}
+	return klineGap{from: from, until: until, step: step}, true
 }
 
-// reconnectHandler handles automatic reconnection with exponential backoff
-func (w *WSClient) reconnectHandler() {
-	backoff := 1 * time.Second
-	maxBackoff := 60 * time.Second
+// fillGap backfills the candles missing from the cache before the one opening at until,
+// or before the open candle if until is 0, and reports whether it made a request.
+// Callers own the symbol's sequence
+func (w *WSClient) fillGap(ctx context.Context, seq *symbolSequence, symbol, interval string, until int64) bool {
+	gap, ok := w.gap(symbol, interval, until)
+	if !ok {
+		return false
+	}
+	from, until, step := gap.from, gap.until, gap.step
+	now := w.now().UnixMilli()
+
+	missing := (until - from) / step
+	w.gapsMu.Lock()
+	w.gaps.Detected++
+	w.gaps.Missing += missing
+	w.gaps.LastStream = klineStream(symbol, interval)
+	w.gaps.LastGap = w.now()
+	w.gapsMu.Unlock()
+	log.Printf("[WSClient] Gap in %s-%s: %d candles missing since %s",
+		symbol, interval, missing, time.UnixMilli(from).UTC().Format("15:04:05"))
+
+	if w.client == nil {
+		return false
+	}
 
-	for {
-		select {
-		case <-w.ctx.Done():
-			return
-		case <-w.reconnectCh:
-			log.Printf("[WSClient] Reconnection triggered, waiting %v", backoff)
-			time.Sleep(backoff)
-
-			// Close old connection
-			w.mu.Lock()
-			if w.conn != nil {
-				w.conn.Close()
-				w.conn = nil
-			}
-			w.isConnected = false
-			w.mu.Unlock()
-
-			// Attempt to reconnect
-			w.mu.RLock()
-			symbols := w.symbols
-			intervals := w.intervals
-			w.mu.RUnlock()
-
-			if err := w.Connect(symbols, intervals); err != nil {
-				log.Printf("[WSClient] Reconnection failed: %v", err)
-
-				// Increase backoff
-				backoff *= 2
-				if backoff > maxBackoff {
-					backoff = maxBackoff
-				}
+	// Klines are fetched from the latest back, the open one included
+	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	klines, err := w.client.GetKlines(fetchCtx, symbol, interval, min(int((now-from)/step)+2, maxBackfillKlines))
+	if err != nil {
+		w.gapsMu.Lock()
+		w.gaps.Failures++
+		w.gapsMu.Unlock()
+		log.Printf("[WSClient] Failed to backfill %s-%s: %v", symbol, interval, err)
+		return true
+	}
 
-				// Trigger another reconnect attempt
-				w.triggerReconnect()
-			} else {
-				log.Printf("[WSClient] Reconnected successfully")
-				// Reset backoff on successful connection
-				backoff = 1 * time.Second
-			}
+	seq.backfilling = true
+	defer func() { seq.backfilling = false }()
+
+	var backfilled int64
+	for _, kline := range klines {
+		if kline.OpenTime < from || kline.OpenTime >= until || kline.CloseTime >= now {
+			continue
 		}
+		w.cache.Update(symbol, interval, kline)
+		w.publishCandleClose(symbol, interval, kline)
+		backfilled++
 	}
+
+	w.gapsMu.Lock()
+	w.gaps.Backfilled += backfilled
+	w.gapsMu.Unlock()
+	log.Printf("[WSClient] Backfilled %d of %d candles for %s-%s", backfilled, missing, symbol, interval)
+	return true
 }
 
-// Close gracefully closes the WebSocket connection
+// lastCloseTime returns the close time of the last candle closed for a symbol/interval
+func (w *WSClient) lastCloseTime(symbol, interval string) int64 {
+	w.lastClosedMu.RLock()
+	defer w.lastClosedMu.RUnlock()
+	return w.lastClosedCandles[fmt.Sprintf("%s-%s", symbol, interval)]
+}
+
+// publishCandleClose emits a candle close event (with deduplication)
+// Candles closed while their symbol is backfilling are flagged as backfilled
+func (w *WSClient) publishCandleClose(symbol, interval string, kline types.Kline) {
+	key := fmt.Sprintf("%s-%s", symbol, interval)
+
+	// Check if we already processed this candle
+	w.lastClosedMu.RLock()
+	lastCloseTime := w.lastClosedCandles[key]
+	w.lastClosedMu.RUnlock()
+
+	if lastCloseTime == kline.CloseTime {
+		return
+	}
+
+	// Update last closed candle time
+	w.lastClosedMu.Lock()
+	w.lastClosedCandles[key] = kline.CloseTime
+	w.lastClosedMu.Unlock()
+
+	if w.eventBus == nil {
+		return
+	}
+
+	// Emit event
+	closeTime := time.Unix(kline.CloseTime/1000, 0)
+	backfill := w.sequence(symbol).backfilling
+	w.eventBus.PublishCandleCloseEvent(&eventbus.CandleCloseEvent{
+		Symbol:    symbol,
+		Interval:  interval,
+		Kline:     kline,
+		CloseTime: closeTime,
+		Backfill:  backfill,
+	})
+
+	if backfill {
+		log.Printf("[WSClient] Candle backfilled: %s-%s at %s", symbol, interval, closeTime.Format("15:04:05"))
+		return
+	}
+	log.Printf("[WSClient] Candle closed: %s-%s at %s", symbol, interval, closeTime.Format("15:04:05"))
+}
+
+// Close gracefully closes every connection
 func (w *WSClient) Close() error {
-	log.Println("[WSClient] Closing WebSocket connection")
+	log.Println("[WSClient] Closing WebSocket connections")
 
-	// Cancel context to stop all goroutines
+	// Cancel context to stop all shards
 	w.cancel()
 
-	// Close WebSocket connection
-	w.mu.Lock()
-	if w.conn != nil {
-		err := w.conn.WriteMessage(
-			websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
-		)
-		if err != nil {
-			log.Printf("[WSClient] Error sending close message: %v", err)
+	w.mu.RLock()
+	shards := append([]*wsShard(nil), w.shards...)
+	w.mu.RUnlock()
+
+	// Wait for the shards to finish, then for the klines they queued
+	timeout := time.After(5 * time.Second)
+	for _, shard := range shards {
+		select {
+		case <-shard.done:
+		case <-timeout:
+			log.Println("[WSClient] Timeout waiting for connections to close")
+			return nil
 		}
-		w.conn.Close()
-		w.conn = nil
 	}
-	w.isConnected = false
-	w.mu.Unlock()
-
-	// Wait for message handler to finish
+	workers := make(chan struct{})
+	go func() {
+		w.workers.Wait()
+		close(workers)
+	}()
 	select {
-	case <-w.doneCh:
-	case <-time.After(5 * time.Second):
-		log.Println("[WSClient] Timeout waiting for message handler to stop")
+	case <-workers:
+	case <-timeout:
+		log.Println("[WSClient] Timeout waiting for queued klines")
+		return nil
 	}
 
 	log.Println("[WSClient] Closed successfully")
 	return nil
 }
 
-// IsConnected returns whether the WebSocket is currently connected
+// IsConnected returns whether every connection of the pool is up
 func (w *WSClient) IsConnected() bool {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-	return w.isConnected
+	return w.GetStats().IsConnected
 }
 
-// GetStats returns statistics about the WebSocket connection
+// GetStats returns statistics about the WebSocket connections
 func (w *WSClient) GetStats() WSStats {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
-	return WSStats{
-		IsConnected:   w.isConnected,
+	stats := WSStats{
+		IsConnected:   len(w.shards) > 0,
 		SymbolCount:   len(w.symbols),
 		IntervalCount: len(w.intervals),
 		Intervals:     w.intervals,
+		StreamCount:   len(w.streamShards),
+		Shards:        make([]ShardStats, len(w.shards)),
 		CacheStats:    w.cache.Stats(),
 	}
+	w.gapsMu.Lock()
+	stats.Gaps = w.gaps
+	w.gapsMu.Unlock()
+	for i, shard := range w.shards {
+		stats.Shards[i] = shard.stats()
+		stats.IsConnected = stats.IsConnected && stats.Shards[i].Connected
+	}
+	return stats
 }
 
 // WSStats holds WebSocket statistics
 type WSStats struct {
-	IsConnected   bool
-	SymbolCount   int
-	IntervalCount int
-	Intervals     []string
-	CacheStats    cache.CacheStats
+	IsConnected   bool             `json:"isConnected"` // Every connection is up
+	SymbolCount   int              `json:"symbolCount"`
+	IntervalCount int              `json:"intervalCount"`
+	Intervals     []string         `json:"intervals"`
+	StreamCount   int              `json:"streamCount"`
+	Shards        []ShardStats     `json:"shards"`
+	Gaps          GapStats         `json:"gaps"`
+	CacheStats    cache.CacheStats `json:"cacheStats"`
+}
+
+// GapStats counts the candles the streams missed and how many were backfilled
+type GapStats struct {
+	Detected   int64     `json:"detected"`   // Gaps found after reconnects or between streamed candles
+	Missing    int64     `json:"missing"`    // Candles missing from those gaps
+	Backfilled int64     `json:"backfilled"` // Candles recovered over REST
+	Failures   int64     `json:"failures"`   // Backfill requests that failed
+	LastStream string    `json:"lastStream,omitempty"`
+	LastGap    time.Time `json:"lastGap"`
+}
+
+// ShardStats holds the health of one connection
+type ShardStats struct {
+	ID          int       `json:"id"`
+	Connected   bool      `json:"connected"`
+	Streams     int       `json:"streams"`
+	Messages    int64     `json:"messages"` // Stream messages received
+	Reconnects  int       `json:"reconnects"`
+	LastMessage time.Time `json:"lastMessage"`
+	LastError   string    `json:"lastError,omitempty"` // Latest connection or request error
 }
@@ -0,0 +1,268 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vyx/go-screener/internal/eventbus"
+	"github.com/vyx/go-screener/pkg/cache"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// fakeStreamServer accepts stream connections, records their requests and answers them
+type fakeStreamServer struct {
+	*httptest.Server
+	mu       sync.Mutex // Guards conns and writes to them
+	conns    []*websocket.Conn
+	requests chan wsRequest
+}
+
+func newFakeStreamServer(t *testing.T) *fakeStreamServer {
+	t.Helper()
+	f := &fakeStreamServer{requests: make(chan wsRequest, 100)}
+	upgrader := websocket.Upgrader{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stream" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conns = append(f.conns, conn)
+		f.mu.Unlock()
+
+		for {
+			var request wsRequest
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+			f.requests <- request
+			f.mu.Lock()
+			conn.WriteJSON(map[string]interface{}{"result": nil, "id": request.ID})
+			f.mu.Unlock()
+		}
+	}))
+	t.Cleanup(f.Server.Close)
+	return f
+}
+
+// wsURL returns the server's WebSocket URL
+func (f *fakeStreamServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(f.URL, "http")
+}
+
+// broadcast sends a message on every connection
+func (f *fakeStreamServer) broadcast(message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.conns {
+		conn.WriteMessage(websocket.TextMessage, []byte(message))
+	}
+}
+
+// nextRequest waits for the next request
+func (f *fakeStreamServer) nextRequest(t *testing.T) wsRequest {
+	t.Helper()
+	select {
+	case request := <-f.requests:
+		return request
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for a request")
+		return wsRequest{}
+	}
+}
+
+func TestWSClient_Sharding(t *testing.T) {
+	server := newFakeStreamServer(t)
+	klineCache := cache.NewKlineCache(500)
+	bus := eventbus.NewEventBus()
+	candles := bus.SubscribeCandleClose()
+	client := NewWSClient(server.wsURL(), nil, klineCache, bus, WSConfig{StreamsPerShard: 2, ConnectTimeout: 5 * time.Second})
+	defer client.Close()
+
+	if err := client.Connect([]string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}, []string{"1m"}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	// Three streams on two connections
+	var subscribed []string
+	for i := 0; i < 2; i++ {
+		request := server.nextRequest(t)
+		if request.Method != "SUBSCRIBE" || len(request.Params) > 2 {
+			t.Errorf("unexpected request %+v", request)
+		}
+		subscribed = append(subscribed, request.Params...)
+	}
+	sort.Strings(subscribed)
+	if strings.Join(subscribed, ",") != "btcusdt@kline_1m,ethusdt@kline_1m,solusdt@kline_1m" {
+		t.Errorf("unexpected subscriptions %v", subscribed)
+	}
+	stats := client.GetStats()
+	if !stats.IsConnected || stats.StreamCount != 3 || len(stats.Shards) != 2 || stats.Shards[0].Streams != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// Events are handled by the connection subscribed to their stream only
+	server.broadcast(`{"stream":"btcusdt@kline_1m","data":{"e":"kline","E":1700000060001,"s":"BTCUSDT","k":{"t":1700000000000,"T":1700000059999,"s":"BTCUSDT","i":"1m","f":1,"L":2,"o":"100","c":"101","h":"102","l":"99","v":"10","n":2,"x":true,"q":"1000","V":"6","Q":"600","B":"0"}}}`)
+	select {
+	case event := <-candles:
+		if event.Symbol != "BTCUSDT" || event.Kline.Close != 101 || event.Kline.BuyVolume != 6 {
+			t.Errorf("unexpected candle event %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for candle event")
+	}
+	if kline, err := klineCache.GetLatestKline("BTCUSDT", "1m"); err != nil || kline.Close != 101 {
+		t.Errorf("expected the kline in the cache, got %+v (%v)", kline, err)
+	}
+
+	// New streams fill the connection with room
+	if err := client.Subscribe([]string{"DOGEUSDT"}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if request := server.nextRequest(t); request.Method != "SUBSCRIBE" || len(request.Params) != 1 || request.Params[0] != "dogeusdt@kline_1m" {
+		t.Errorf("unexpected request %+v", request)
+	}
+	if stats := client.GetStats(); len(stats.Shards) != 2 || stats.Shards[1].Streams != 2 || stats.SymbolCount != 4 {
+		t.Errorf("expected DOGEUSDT on the second connection, got %+v", stats)
+	}
+
+	if err := client.Unsubscribe([]string{"ETHUSDT"}); err != nil {
+		t.Fatalf("Unsubscribe failed: %v", err)
+	}
+	if request := server.nextRequest(t); request.Method != "UNSUBSCRIBE" || request.Params[0] != "ethusdt@kline_1m" {
+		t.Errorf("unexpected request %+v", request)
+	}
+
+	// Connections left without streams are closed
+	if err := client.Unsubscribe([]string{"SOLUSDT", "DOGEUSDT"}); err != nil {
+		t.Fatalf("Unsubscribe failed: %v", err)
+	}
+	if stats := client.GetStats(); len(stats.Shards) != 1 || stats.StreamCount != 1 || stats.SymbolCount != 1 {
+		t.Errorf("expected one connection left, got %+v", stats)
+	}
+}
+
+func TestWSShard_HandleResponse(t *testing.T) {
+	client := NewWSClient("", nil, nil, nil, DefaultWSConfig())
+	client.mu.Lock()
+	shard := client.newShard()
+	client.mu.Unlock()
+
+	shard.handleMessage([]byte(`{"result":null,"id":1}`))
+	if stats := shard.stats(); stats.LastError != "" || stats.Messages != 0 {
+		t.Errorf("expected a successful response to be ignored, got %+v", stats)
+	}
+	shard.handleMessage([]byte(`{"error":{"code":2,"msg":"Invalid request"},"id":2}`))
+	if stats := shard.stats(); stats.LastError != "Invalid request" {
+		t.Errorf("expected the request error, got %+v", stats)
+	}
+}
+
+func TestWSClient_Backfill(t *testing.T) {
+	const base, step = int64(1699999980000), int64(60000)
+
+	// REST serves the first eight 1m candles, closing at 100 + i, once released
+	release := make(chan struct{})
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		if r.URL.Path != "/api/v3/klines" || r.URL.Query().Get("symbol") != "BTCUSDT" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		var rows []string
+		for i := int64(0); i < 8; i++ {
+			open := base + i*step
+			rows = append(rows, fmt.Sprintf(`[%d,"100","101","99","%d","10",%d,"1000",5,"6","600","0"]`, open, 100+i, open+step-1))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(rows, ","))
+	}))
+	defer rest.Close()
+
+	klineCache := cache.NewKlineCache(500)
+	klineCache.Set("BTCUSDT", "1m", []types.Kline{{OpenTime: base, Close: 100, CloseTime: base + step - 1}})
+	bus := eventbus.NewEventBus()
+	candles := bus.SubscribeCandleClose()
+	client := NewWSClient("", NewClient(rest.URL), klineCache, bus, DefaultWSConfig())
+	client.lastClosedCandles["BTCUSDT-1m"] = base + step - 1
+	now := base + 4*step + 1000
+	client.now = func() time.Time { return time.UnixMilli(now) }
+
+	closeEvent := func(i int64) *KlineEvent {
+		event := &KlineEvent{Symbol: "BTCUSDT"}
+		event.Kline.StartTime = base + i*step
+		event.Kline.CloseTime = base + (i+1)*step - 1
+		event.Kline.Interval = "1m"
+		event.Kline.Close = fmt.Sprint(100 + i)
+		event.Kline.IsClosed = true
+		return event
+	}
+	expect := func(closes []float64, backfill []bool) {
+		t.Helper()
+		for i, want := range closes {
+			select {
+			case event := <-candles:
+				if event.Kline.Close != want || event.Backfill != backfill[i] {
+					t.Errorf("event %d: expected close %v (backfill %v), got %v (backfill %v)", i, want, backfill[i], event.Kline.Close, event.Backfill)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("timeout waiting for candle %v", want)
+			}
+		}
+		select {
+		case event := <-candles:
+			t.Errorf("unexpected candle event %+v", event)
+		default:
+		}
+	}
+
+	// Candles 1 and 2 are missed: they are backfilled on the symbol's worker without
+	// holding up the read loop, and candles 3 and 4 wait for them
+	client.handleKlineEvent(context.Background(), closeEvent(3))
+	client.handleKlineEvent(context.Background(), closeEvent(4))
+	expect(nil, nil)
+	close(release)
+	expect([]float64{101, 102, 103, 104}, []bool{true, true, false, false})
+	klines, _ := klineCache.Get("BTCUSDT", "1m", 10)
+	if len(klines) != 5 || klines[1].Close != 101 || klines[4].Close != 104 {
+		t.Errorf("expected the cache in order, got %+v", klines)
+	}
+	if gaps := client.GetStats().Gaps; gaps.Detected != 1 || gaps.Missing != 2 || gaps.Backfilled != 2 || gaps.LastStream != "btcusdt@kline_1m" {
+		t.Errorf("unexpected gap stats %+v", gaps)
+	}
+
+	// Candles older than the latest are dropped
+	client.handleKlineEvent(context.Background(), closeEvent(2))
+	expect(nil, nil)
+
+	// After a reconnect every closed candle since the latest is backfilled, the open one excluded
+	now = base + 7*step + 1000
+	seq := client.sequence("BTCUSDT")
+	seq.mu.Lock()
+	fetched := client.fillGap(context.Background(), seq, "BTCUSDT", "1m", 0)
+	seq.mu.Unlock()
+	if !fetched {
+		t.Error("expected a backfill request")
+	}
+	expect([]float64{105, 106}, []bool{true, true})
+	if gaps := client.GetStats().Gaps; gaps.Detected != 2 || gaps.Missing != 4 || gaps.Backfilled != 4 || gaps.Failures != 0 {
+		t.Errorf("unexpected gap stats %+v", gaps)
+	}
+
+	// Without a gap nothing is requested
+	seq.mu.Lock()
+	fetched = client.fillGap(context.Background(), seq, "BTCUSDT", "1m", 0)
+	seq.mu.Unlock()
+	if fetched {
+		t.Error("expected no backfill request without a gap")
+	}
+}
@@ -0,0 +1,442 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vyx/go-screener/internal/scheduler"
+	"github.com/vyx/go-screener/pkg/indicators"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Alternative bars
+//
+// Heikin-Ashi, Renko and range bars are built from the closed klines of a source
+// interval and stored next to regular klines under their own interval name:
+//
+//	ha:5m            Heikin-Ashi candles of 5m klines
+//	renko:10         Renko bricks of 10 (price units) from 1m closes
+//	renko:atr14:15m  Renko bricks of ATR(14) from 15m closes
+//	range:atr14      Range bars spanning ATR(14) from 1m klines
+//
+// ATR sizes are taken from the source klines when the bars are first built and
+// then stay fixed, so built bars never repaint. Renko and range bars aren't time
+// based: a bar opens 1ms after the previous one closed and closes with the source
+// kline that completed it. Bars completed by the same kline close 1ms apart, ending
+// at its close time. A source kline's volume goes to the bar forming when it opens
+
+// Bar types
+const (
+	BarHeikinAshi = "ha"
+	BarRenko      = "renko"
+	BarRange      = "range"
+)
+
+// DefaultBarSource is the interval Renko and range bars are built from unless one is given
+const DefaultBarSource = "1m"
+
+// minBarSize is the smallest Renko or range size, as a fraction of price
+const minBarSize = 0.0001
+
+// BarSpec describes an alternative bar interval
+type BarSpec struct {
+	Type      string  // BarHeikinAshi, BarRenko or BarRange
+	Source    string  // Interval of the klines the bars are built from
+	Size      float64 // Fixed brick or range size, 0 when sized by ATR
+	ATRPeriod int     // ATR period sizing the bricks or range, 0 for a fixed size
+}
+
+// IsBarInterval reports whether an interval names alternative bars, e.g. ha:5m
+func IsBarInterval(interval string) bool {
+	switch strings.SplitN(interval, ":", 2)[0] {
+	case BarHeikinAshi, BarRenko, BarRange:
+		return strings.Contains(interval, ":")
+	}
+	return false
+}
+
+// ParseBarInterval parses an alternative bar interval such as ha:5m, renko:atr14
+// or range:25:15m
+func ParseBarInterval(interval string) (BarSpec, error) {
+	parts := strings.Split(interval, ":")
+	spec := BarSpec{Type: parts[0]}
+
+	switch {
+	case spec.Type == BarHeikinAshi && len(parts) == 2:
+		spec.Source = parts[1]
+	case (spec.Type == BarRenko || spec.Type == BarRange) && (len(parts) == 2 || len(parts) == 3):
+		spec.Source = DefaultBarSource
+		if len(parts) == 3 {
+			spec.Source = parts[2]
+		}
+		if err := spec.parseSize(parts[1]); err != nil {
+			return BarSpec{}, fmt.Errorf("invalid bar interval %s: %w", interval, err)
+		}
+	default:
+		return BarSpec{}, fmt.Errorf("invalid bar interval %s: expected ha:<interval>, renko:<size>[:<interval>] or range:<size>[:<interval>]", interval)
+	}
+
+	if _, err := scheduler.ParseInterval(spec.Source); err != nil {
+		return BarSpec{}, fmt.Errorf("invalid bar interval %s: %w", interval, err)
+	}
+	return spec, nil
+}
+
+// parseSize parses a fixed size or atrN
+func (s *BarSpec) parseSize(size string) error {
+	if period, ok := strings.CutPrefix(size, "atr"); ok {
+		n, err := strconv.Atoi(period)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid ATR period %q", period)
+		}
+		s.ATRPeriod = n
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(size, 64)
+	if err != nil || value <= 0 || math.IsInf(value, 0) {
+		return fmt.Errorf("size must be a positive number or atrN, got %q", size)
+	}
+	s.Size = value
+	return nil
+}
+
+// BuildBars builds the bars of an alternative bar interval from closed source klines
+func BuildBars(interval string, source []types.Kline) ([]types.Kline, error) {
+	spec, err := ParseBarInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	_, bars, err := replayBars(spec, source)
+	return bars, err
+}
+
+// maxBarSourceKlines is the number of source klines fetched to build Renko and range bars
+const maxBarSourceKlines = 1000
+
+// KlineFetcher fetches the latest klines of a Binance interval, e.g. binance.Client.GetKlines
+type KlineFetcher func(ctx context.Context, symbol, interval string, limit int) ([]types.Kline, error)
+
+// FetchKlines returns the latest limit klines of an interval from fetch
+// Binance only serves its own intervals, so bar intervals are built from fetched
+// closed source klines
+func FetchKlines(ctx context.Context, fetch KlineFetcher, symbol, interval string, limit int) ([]types.Kline, error) {
+	if !IsBarInterval(interval) {
+		return fetch(ctx, symbol, interval, limit)
+	}
+
+	spec, err := ParseBarInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	// Heikin-Ashi candles map one to one; bricks and range bars need what history there is
+	sourceLimit := maxBarSourceKlines
+	if spec.Type == BarHeikinAshi && limit+1 < sourceLimit {
+		sourceLimit = limit + 1
+	}
+	source, err := fetch(ctx, symbol, spec.Source, sourceLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// The last kline is still open
+	if n := len(source); n > 0 && source[n-1].CloseTime >= time.Now().UnixMilli() {
+		source = source[:n-1]
+	}
+
+	bars, err := BuildBars(interval, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) > limit {
+		bars = bars[len(bars)-limit:]
+	}
+	return bars, nil
+}
+
+// replayBars creates the builder of a bar spec and feeds it the source klines
+func replayBars(spec BarSpec, source []types.Kline) (barBuilder, []types.Kline, error) {
+	builder, err := newBarBuilder(spec, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bars []types.Kline
+	for _, k := range source {
+		bars = append(bars, builder.add(k)...)
+	}
+	return builder, bars, nil
+}
+
+// barBuilder turns closed source klines into bars, one kline at a time
+type barBuilder interface {
+	// add returns the bars the kline closed
+	add(k types.Kline) []types.Kline
+}
+
+// newBarBuilder creates the builder of a bar spec, sizing ATR bars from the source klines
+func newBarBuilder(spec BarSpec, source []types.Kline) (barBuilder, error) {
+	if spec.Type == BarHeikinAshi {
+		return &heikinAshiBuilder{}, nil
+	}
+	if len(source) == 0 {
+		return nil, fmt.Errorf("no klines to size bars from")
+	}
+
+	size := spec.Size
+	if spec.ATRPeriod > 0 {
+		atr := indicators.CalculateATR(source, spec.ATRPeriod)
+		if atr == nil || *atr <= 0 {
+			return nil, fmt.Errorf("insufficient data for ATR(%d) bar size: %d klines", spec.ATRPeriod, len(source))
+		}
+		size = *atr
+	}
+	// Tiny sizes would turn every kline into thousands of bars
+	if last := source[len(source)-1].Close; size < last*minBarSize {
+		return nil, fmt.Errorf("bar size %g is below %g%% of price %g", size, minBarSize*100, last)
+	}
+
+	if spec.Type == BarRenko {
+		return &renkoBuilder{size: size}, nil
+	}
+	return &rangeBuilder{size: size}, nil
+}
+
+// heikinAshiBuilder builds one Heikin-Ashi candle per kline
+type heikinAshiBuilder struct {
+	prev *types.Kline
+}
+
+func (b *heikinAshiBuilder) add(k types.Kline) []types.Kline {
+	ha := k
+	ha.Close = (k.Open + k.High + k.Low + k.Close) / 4
+	if b.prev == nil {
+		ha.Open = (k.Open + k.Close) / 2
+	} else {
+		ha.Open = (b.prev.Open + b.prev.Close) / 2
+	}
+	ha.High = math.Max(k.High, math.Max(ha.Open, ha.Close))
+	ha.Low = math.Min(k.Low, math.Min(ha.Open, ha.Close))
+
+	b.prev = &ha
+	return []types.Kline{ha}
+}
+
+// renkoBuilder builds bricks from kline closes: a brick in the trend's direction
+// once price moves one size past the last brick, a reversal once it moves two
+type renkoBuilder struct {
+	size        float64
+	started     bool
+	top, bottom float64     // Bounds of the last brick; the anchor price before the first
+	pending     types.Kline // Volume since the last brick
+	lastClose   int64       // Close time of the last brick
+}
+
+func (b *renkoBuilder) add(k types.Kline) []types.Kline {
+	if !b.started {
+		b.started = true
+		b.top, b.bottom = k.Close, k.Close
+		b.lastClose = k.OpenTime - 1
+	}
+	addVolume(&b.pending, k)
+
+	var bricks []types.Kline
+	for k.Close >= b.top+b.size {
+		bricks = append(bricks, brick(b.top, b.top+b.size))
+		b.bottom, b.top = b.top, b.top+b.size
+	}
+	for k.Close <= b.bottom-b.size {
+		bricks = append(bricks, brick(b.bottom, b.bottom-b.size))
+		b.top, b.bottom = b.bottom, b.bottom-b.size
+	}
+	if len(bricks) == 0 {
+		return nil
+	}
+
+	addVolume(&bricks[0], b.pending)
+	b.pending = types.Kline{}
+	b.lastClose = stampBars(bricks, b.lastClose, k.CloseTime)
+	return bricks
+}
+
+// brick returns a Renko brick without wicks
+func brick(open, close float64) types.Kline {
+	return types.Kline{
+		Open:  open,
+		Close: close,
+		High:  math.Max(open, close),
+		Low:   math.Min(open, close),
+	}
+}
+
+// rangeBuilder builds bars that close once their high-low range reaches size
+// Price is assumed to move open, low, high, close in up klines and open, high,
+// low, close in down klines
+type rangeBuilder struct {
+	size      float64
+	bar       *types.Kline // Bar being formed
+	lastClose int64        // Close time of the last bar
+}
+
+func (b *rangeBuilder) add(k types.Kline) []types.Kline {
+	if b.bar == nil {
+		b.bar = &types.Kline{Open: k.Open, High: k.Open, Low: k.Open, Close: k.Open}
+		b.lastClose = k.OpenTime - 1
+	}
+	addVolume(b.bar, k)
+
+	path := []float64{k.Open, k.High, k.Low, k.Close}
+	if k.Close >= k.Open {
+		path = []float64{k.Open, k.Low, k.High, k.Close}
+	}
+
+	var bars []types.Kline
+	for _, price := range path {
+		// A move of several sizes closes several bars
+		for {
+			bar, closed := b.move(price)
+			if !closed {
+				break
+			}
+			bars = append(bars, bar)
+		}
+	}
+	if len(bars) > 0 {
+		b.lastClose = stampBars(bars, b.lastClose, k.CloseTime)
+	}
+	return bars
+}
+
+// move extends the bar being formed to price, or closes it at one size from its
+// far end if price is beyond and opens the next bar there
+func (b *rangeBuilder) move(price float64) (types.Kline, bool) {
+	bar := b.bar
+	switch {
+	case price-bar.Low >= b.size:
+		bar.High = bar.Low + b.size
+		bar.Close = bar.High
+	case bar.High-price >= b.size:
+		bar.Low = bar.High - b.size
+		bar.Close = bar.Low
+	default:
+		bar.High = math.Max(bar.High, price)
+		bar.Low = math.Min(bar.Low, price)
+		bar.Close = price
+		return types.Kline{}, false
+	}
+	b.bar = &types.Kline{Open: bar.Close, High: bar.Close, Low: bar.Close, Close: bar.Close}
+	return *bar, true
+}
+
+// stampBars sets the times of bars closed by one kline: each opens 1ms after the
+// previous bar closed and they close 1ms apart, the last at closeTime
+// Returns the close time of the last bar
+func stampBars(bars []types.Kline, lastClose, closeTime int64) int64 {
+	for i := range bars {
+		bars[i].OpenTime = lastClose + 1
+		bars[i].CloseTime = closeTime - int64(len(bars)-1-i)
+		lastClose = bars[i].CloseTime
+	}
+	return lastClose
+}
+
+// addVolume adds the volume fields of k to dst
+func addVolume(dst *types.Kline, k types.Kline) {
+	dst.Volume += k.Volume
+	dst.BuyVolume += k.BuyVolume
+	dst.SellVolume += k.SellVolume
+	dst.VolumeDelta += k.VolumeDelta
+	dst.QuoteVolume += k.QuoteVolume
+	dst.Trades += k.Trades
+	dst.TakerBuyBaseAssetVolume += k.TakerBuyBaseAssetVolume
+	dst.TakerBuyQuoteAssetVolume += k.TakerBuyQuoteAssetVolume
+	dst.NumberOfTrades += k.NumberOfTrades
+}
+
+// barSeries is the builder of one bar interval for a symbol
+type barSeries struct {
+	spec     BarSpec
+	builder  barBuilder
+	lastOpen int64 // Open time of the last source kline added
+}
+
+// buildBars builds a bar interval for a symbol from its source klines and keeps
+// it up to date from then on. Callers hold c.mu for writing
+func (c *KlineCache) buildBars(symbol, interval string) error {
+	spec, err := ParseBarInterval(interval)
+	if err != nil {
+		return err
+	}
+
+	// The source may itself be resampled
+	if _, ok := c.data[symbol][spec.Source]; !ok && c.isDerived(spec.Source) {
+		c.deriveHistory(symbol, spec.Source)
+	}
+	source := c.closedKlines(c.data[symbol][spec.Source])
+	if len(source) == 0 {
+		return fmt.Errorf("no %s klines for %s to build %s from", spec.Source, symbol, interval)
+	}
+
+	builder, bars, err := replayBars(spec, source)
+	if err != nil {
+		return err
+	}
+	if len(bars) > c.maxLen {
+		bars = bars[len(bars)-c.maxLen:]
+	}
+
+	if c.bars[symbol] == nil {
+		c.bars[symbol] = make(map[string]*barSeries)
+	}
+	c.bars[symbol][interval] = &barSeries{spec: spec, builder: builder, lastOpen: source[len(source)-1].OpenTime}
+	c.data[symbol][interval] = bars
+	log.Printf("[KlineCache] Built %d %s bars for %s from %d %s klines", len(bars), interval, symbol, len(source), spec.Source)
+	return nil
+}
+
+// closedKlines drops a trailing kline that is still open
+func (c *KlineCache) closedKlines(klines []types.Kline) []types.Kline {
+	if n := len(klines); n > 0 && klines[n-1].CloseTime >= time.Now().UnixMilli() {
+		return klines[:n-1]
+	}
+	return klines
+}
+
+// addBars feeds a closed kline to the bars built from its interval
+// Returns the bars it closed. Callers hold c.mu for writing
+func (c *KlineCache) addBars(symbol, interval string, kline types.Kline) []derivedClose {
+	var closed []derivedClose
+	for name, series := range c.bars[symbol] {
+		if series.spec.Source != interval || kline.OpenTime <= series.lastOpen {
+			continue
+		}
+		series.lastOpen = kline.OpenTime
+
+		for _, bar := range series.builder.add(kline) {
+			klines := append(c.data[symbol][name], bar)
+			if len(klines) > c.maxLen {
+				klines = klines[1:]
+			}
+			c.data[symbol][name] = klines
+			closed = append(closed, derivedClose{symbol: symbol, interval: name, kline: bar})
+		}
+	}
+	return closed
+}
+
+// dropBars forgets the bars built from an interval of a symbol, so they are rebuilt
+// from its new klines. Callers hold c.mu for writing
+func (c *KlineCache) dropBars(symbol, interval string) {
+	for name, series := range c.bars[symbol] {
+		if series.spec.Source == interval {
+			delete(c.bars[symbol], name)
+			delete(c.data[symbol], name)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// priceKline returns the closed 1m kline n minutes after resampleStart with the given prices
+func priceKline(n int, open, high, low, close float64) types.Kline {
+	k := minuteKline(n)
+	k.Open, k.High, k.Low, k.Close = open, high, low, close
+	return k
+}
+
+func TestParseBarInterval(t *testing.T) {
+	tests := []struct {
+		interval string
+		want     BarSpec
+	}{
+		{"ha:5m", BarSpec{Type: BarHeikinAshi, Source: "5m"}},
+		{"renko:10", BarSpec{Type: BarRenko, Source: DefaultBarSource, Size: 10}},
+		{"renko:atr14:15m", BarSpec{Type: BarRenko, Source: "15m", ATRPeriod: 14}},
+		{"range:0.5:1h", BarSpec{Type: BarRange, Source: "1h", Size: 0.5}},
+	}
+	for _, tt := range tests {
+		got, err := ParseBarInterval(tt.interval)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.interval, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.interval, tt.want, got)
+		}
+		if !IsBarInterval(tt.interval) {
+			t.Errorf("%s: expected a bar interval", tt.interval)
+		}
+	}
+
+	for _, interval := range []string{"ha", "ha:7x", "renko:abc", "renko:-1", "renko:atr0", "range:10:5m:1", "kagi:5m"} {
+		if _, err := ParseBarInterval(interval); err == nil {
+			t.Errorf("%s: expected an error", interval)
+		}
+	}
+	for _, interval := range []string{"5m", "1h", "ha", "kagi:5m"} {
+		if IsBarInterval(interval) {
+			t.Errorf("%s: expected not a bar interval", interval)
+		}
+	}
+}
+
+func TestBuildBars_HeikinAshi(t *testing.T) {
+	source := []types.Kline{
+		priceKline(0, 100, 104, 98, 102),
+		priceKline(1, 102, 106, 101, 105),
+	}
+
+	bars, err := BuildBars("ha:1m", source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("expected 2 candles, got %d", len(bars))
+	}
+
+	// First: open (100+102)/2, close (100+104+98+102)/4
+	if bars[0].Open != 101 || bars[0].Close != 101 || bars[0].High != 104 || bars[0].Low != 98 {
+		t.Errorf("unexpected first candle %+v", bars[0])
+	}
+	// Second: open (101+101)/2, close (102+106+101+105)/4
+	if bars[1].Open != 101 || bars[1].Close != 103.5 || bars[1].High != 106 || bars[1].Low != 101 {
+		t.Errorf("unexpected second candle %+v", bars[1])
+	}
+	if bars[1].OpenTime != source[1].OpenTime || bars[1].Volume != source[1].Volume {
+		t.Error("expected Heikin-Ashi candles to keep the kline times and volume")
+	}
+}
+
+func TestBuildBars_Renko(t *testing.T) {
+	source := []types.Kline{
+		priceKline(0, 100, 100, 100, 100),     // Anchor
+		priceKline(1, 100, 102.5, 100, 102.5), // Two up bricks
+		priceKline(2, 102, 102, 101, 101.5),   // Not enough to reverse
+		priceKline(3, 101, 101, 99, 99.5),     // Reversal: one down brick from 101
+	}
+
+	bars, err := BuildBars("renko:1", source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := [][2]float64{{100, 101}, {101, 102}, {101, 100}}
+	if len(bars) != len(want) {
+		t.Fatalf("expected %d bricks, got %d: %+v", len(want), len(bars), bars)
+	}
+	for i, w := range want {
+		if bars[i].Open != w[0] || bars[i].Close != w[1] {
+			t.Errorf("brick %d: expected %v -> %v, got %v -> %v", i, w[0], w[1], bars[i].Open, bars[i].Close)
+		}
+	}
+
+	// Bricks of one kline close 1ms apart, ending at its close time
+	if bars[0].OpenTime != source[0].OpenTime || bars[0].CloseTime != source[1].CloseTime-1 {
+		t.Errorf("unexpected first brick times %d-%d", bars[0].OpenTime, bars[0].CloseTime)
+	}
+	if bars[1].OpenTime != bars[0].CloseTime+1 || bars[1].CloseTime != source[1].CloseTime {
+		t.Errorf("unexpected second brick times %d-%d", bars[1].OpenTime, bars[1].CloseTime)
+	}
+	if bars[2].CloseTime != source[3].CloseTime {
+		t.Errorf("expected third brick to close with the fourth kline")
+	}
+
+	// Volume since the last brick goes to the next one
+	if bars[0].Volume != 20 || bars[1].Volume != 0 || bars[2].Volume != 20 {
+		t.Errorf("unexpected brick volumes %v %v %v", bars[0].Volume, bars[1].Volume, bars[2].Volume)
+	}
+}
+
+func TestBuildBars_Range(t *testing.T) {
+	// Up kline: price moves 100, 99, 103, 102
+	source := []types.Kline{priceKline(0, 100, 103, 99, 102)}
+
+	bars, err := BuildBars("range:2", source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []types.Kline{
+		{Open: 100, High: 101, Low: 99, Close: 101},
+		{Open: 101, High: 103, Low: 101, Close: 103},
+	}
+	if len(bars) != len(want) {
+		t.Fatalf("expected %d bars, got %d: %+v", len(want), len(bars), bars)
+	}
+	for i, w := range want {
+		b := bars[i]
+		if b.Open != w.Open || b.High != w.High || b.Low != w.Low || b.Close != w.Close {
+			t.Errorf("bar %d: expected %+v, got O%v H%v L%v C%v", i, w, b.Open, b.High, b.Low, b.Close)
+		}
+		if b.High-b.Low != 2 {
+			t.Errorf("bar %d: expected a range of 2, got %v", i, b.High-b.Low)
+		}
+	}
+}
+
+func TestBuildBars_ATRSize(t *testing.T) {
+	source := make([]types.Kline, 20)
+	for i := range source {
+		source[i] = minuteKline(i) // True range 3
+	}
+
+	bars, err := BuildBars("renko:atr14", source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, b := range bars {
+		if math.Abs(math.Abs(b.Close-b.Open)-3) > 1e-9 {
+			t.Fatalf("expected ATR sized bricks of 3, got %+v", b)
+		}
+	}
+
+	if _, err := BuildBars("renko:atr14", source[:10]); err == nil || !strings.Contains(err.Error(), "insufficient data") {
+		t.Errorf("expected insufficient data error, got %v", err)
+	}
+	if _, err := BuildBars("range:0.0001", source); err == nil {
+		t.Error("expected an error for a size far below price")
+	}
+}
+
+func TestFetchKlines(t *testing.T) {
+	var requests []string
+	fetch := func(ctx context.Context, symbol, interval string, limit int) ([]types.Kline, error) {
+		requests = append(requests, fmt.Sprintf("%s:%d", interval, limit))
+		var klines []types.Kline
+		for i := 0; i < limit; i++ {
+			klines = append(klines, minuteKline(i))
+		}
+		return klines, nil
+	}
+
+	klines, err := FetchKlines(context.Background(), fetch, "BTCUSDT", "1m", 10)
+	if err != nil || len(klines) != 10 {
+		t.Fatalf("expected 10 klines passed through, got %d (%v)", len(klines), err)
+	}
+
+	bars, err := FetchKlines(context.Background(), fetch, "BTCUSDT", "ha:1m", 5)
+	if err != nil {
+		t.Fatalf("FetchKlines(ha:1m) failed: %v", err)
+	}
+	if len(bars) != 5 {
+		t.Errorf("expected 5 Heikin-Ashi candles, got %d", len(bars))
+	}
+	if len(requests) != 2 || requests[0] != "1m:10" || requests[1] != "1m:6" {
+		t.Errorf("expected 1m:10 then 1m:6 requests, got %v", requests)
+	}
+}
+
+func TestKlineCache_Bars(t *testing.T) {
+	cache, closed := newResamplingCache(t, 500)
+
+	history := make([]types.Kline, 10)
+	for i := range history {
+		history[i] = minuteKline(i)
+	}
+	cache.Set("BTCUSDT", "1m", history)
+
+	bars, err := cache.Get("BTCUSDT", "ha:1m", 100)
+	if err != nil {
+		t.Fatalf("Get(ha:1m) failed: %v", err)
+	}
+	if len(bars) != 10 {
+		t.Fatalf("expected 10 Heikin-Ashi candles, got %d", len(bars))
+	}
+
+	// New klines extend the bars and close them like candles
+	cache.Update("BTCUSDT", "1m", minuteKline(10))
+	bars, _ = cache.Get("BTCUSDT", "ha:1m", 100)
+	if len(bars) != 11 || bars[10].OpenTime != minuteKline(10).OpenTime {
+		t.Fatalf("expected the new kline's candle, got %d candles", len(bars))
+	}
+	if len(*closed) != 1 || (*closed)[0].interval != "ha:1m" {
+		t.Errorf("expected one ha:1m close, got %+v", *closed)
+	}
+
+	// Repeated klines are ignored
+	cache.Update("BTCUSDT", "1m", minuteKline(10))
+	if bars, _ = cache.Get("BTCUSDT", "ha:1m", 100); len(bars) != 11 {
+		t.Errorf("expected a repeated kline to be ignored, got %d candles", len(bars))
+	}
+
+	// Replacing the source rebuilds the bars
+	cache.Set("BTCUSDT", "1m", history[:5])
+	if bars, _ = cache.Get("BTCUSDT", "ha:1m", 100); len(bars) != 5 {
+		t.Errorf("expected bars rebuilt from the new klines, got %d", len(bars))
+	}
+
+	if _, err := cache.Get("ETHUSDT", "ha:1m", 100); err == nil {
+		t.Error("expected an error without source klines")
+	}
+}
+
+func TestKlineCache_BarsFromResampled(t *testing.T) {
+	cache, closed := newResamplingCache(t, 500, "5m")
+
+	for i := 0; i < 10; i++ {
+		cache.Update("BTCUSDT", "1m", minuteKline(i))
+	}
+	bars, err := cache.Get("BTCUSDT", "ha:5m", 100)
+	if err != nil {
+		t.Fatalf("Get(ha:5m) failed: %v", err)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("expected 2 Heikin-Ashi candles, got %d", len(bars))
+	}
+
+	*closed = nil
+	for i := 10; i < 15; i++ {
+		cache.Update("BTCUSDT", "1m", minuteKline(i))
+	}
+	if bars, _ = cache.Get("BTCUSDT", "ha:5m", 100); len(bars) != 3 {
+		t.Errorf("expected a third candle after the 5m close, got %d", len(bars))
+	}
+
+	var intervals []string
+	for _, c := range *closed {
+		intervals = append(intervals, c.interval)
+	}
+	if len(intervals) != 2 || intervals[0] != "5m" || intervals[1] != "ha:5m" {
+		t.Errorf("expected 5m then ha:5m closes, got %v", intervals)
+	}
+}
@@ -0,0 +1,278 @@
+package cache
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/vyx/go-screener/internal/scheduler"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Footprints
+//
+// With footprints enabled, aggregate trades are bucketed into footprint candles of
+// each configured interval: per price level, the volume takers sold into the bid
+// and bought from the ask. A level spans a number of the symbol's ticks or, when
+// that isn't configured, the 1-2-5 multiple of the tick nearest autoLevelBps of the
+// first trade's price. A symbol's level size is fixed once chosen so its candles
+// stay comparable
+
+// autoLevelBps is the level size picked from price, in basis points
+const autoLevelBps = 2
+
+// DefaultFootprintCandles is the number of footprint candles kept per symbol and interval
+const DefaultFootprintCandles = 100
+
+// dayMillis is the length of a day, which footprint intervals must divide
+const dayMillis = 24 * 60 * 60 * 1000
+
+// FootprintConfig configures footprint candles
+type FootprintConfig struct {
+	Intervals     []string // Candle intervals, up to 1d
+	TicksPerLevel int      // Ticks per price level, 0 to size levels from price
+	MaxCandles    int      // Candles kept per symbol and interval
+}
+
+// footprints holds footprint candles and level sizes
+type footprints struct {
+	config  FootprintConfig
+	steps   map[string]int64                        // interval -> length in milliseconds
+	ticks   map[string]float64                      // symbol -> tick size
+	levels  map[string]float64                      // symbol -> level size
+	candles map[string]map[string][]types.Footprint // [symbol][interval]
+}
+
+// EnableFootprints builds footprint candles of the configured intervals from trades
+func (c *KlineCache) EnableFootprints(config FootprintConfig) error {
+	if config.MaxCandles <= 0 {
+		config.MaxCandles = DefaultFootprintCandles
+	}
+
+	steps := make(map[string]int64, len(config.Intervals))
+	for _, interval := range config.Intervals {
+		step, err := scheduler.ParseInterval(interval)
+		if err != nil {
+			return fmt.Errorf("invalid footprint interval %s: %w", interval, err)
+		}
+		if dayMillis%step.Milliseconds() != 0 {
+			return fmt.Errorf("invalid footprint interval %s: must divide a day", interval)
+		}
+		steps[interval] = step.Milliseconds()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.footprints = &footprints{
+		config:  config,
+		steps:   steps,
+		ticks:   make(map[string]float64),
+		levels:  make(map[string]float64),
+		candles: make(map[string]map[string][]types.Footprint),
+	}
+	log.Printf("[KlineCache] Building %v footprints", config.Intervals)
+	return nil
+}
+
+// SetTickSize sets a symbol's tick size, used to size its footprint levels
+func (c *KlineCache) SetTickSize(symbol string, tick float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.footprints != nil && tick > 0 {
+		c.footprints.ticks[symbol] = tick
+	}
+}
+
+// AddTrade adds a trade to the symbol's footprint candles
+// Trades older than the latest candle of an interval are ignored
+func (c *KlineCache) AddTrade(symbol string, trade types.AggTrade) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := c.footprints
+	if f == nil || trade.Price <= 0 {
+		return
+	}
+	size := f.levelSize(symbol, trade.Price)
+
+	if f.candles[symbol] == nil {
+		f.candles[symbol] = make(map[string][]types.Footprint)
+	}
+	for interval, step := range f.steps {
+		openTime := trade.Time - trade.Time%step
+		candles := f.candles[symbol][interval]
+
+		n := len(candles)
+		if n > 0 && candles[n-1].OpenTime > openTime {
+			continue
+		}
+		if n == 0 || candles[n-1].OpenTime < openTime {
+			candles = append(candles, types.Footprint{
+				OpenTime:  openTime,
+				CloseTime: openTime + step - 1,
+				LevelSize: size,
+			})
+			if len(candles) > f.config.MaxCandles {
+				candles = candles[len(candles)-f.config.MaxCandles:]
+			}
+			n = len(candles)
+		}
+
+		addFootprintTrade(&candles[n-1], trade)
+		f.candles[symbol][interval] = candles
+	}
+}
+
+// GetFootprints retrieves the latest N footprint candles for a symbol/interval pair
+// limit is clamped to between 1 and the configured MaxCandles
+func (c *KlineCache) GetFootprints(symbol, interval string, limit int) ([]types.Footprint, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.footprints == nil {
+		return nil, fmt.Errorf("footprints are not enabled")
+	}
+	if _, ok := c.footprints.steps[interval]; !ok {
+		return nil, fmt.Errorf("no %s footprints, built intervals are %v", interval, c.footprints.config.Intervals)
+	}
+	candles, ok := c.footprints.candles[symbol][interval]
+	if !ok {
+		return nil, fmt.Errorf("no footprints for %s", symbol)
+	}
+
+	limit = max(1, min(limit, c.footprints.config.MaxCandles))
+	if len(candles) > limit {
+		candles = candles[len(candles)-limit:]
+	}
+	result := make([]types.Footprint, len(candles))
+	copy(result, candles)
+
+	// The latest candle keeps changing
+	if n := len(result); n > 0 {
+		result[n-1].Levels = append([]types.FootprintLevel(nil), result[n-1].Levels...)
+	}
+	return result, nil
+}
+
+// levelSize returns the symbol's footprint level size, choosing it on first use
+func (f *footprints) levelSize(symbol string, price float64) float64 {
+	if size, ok := f.levels[symbol]; ok {
+		return size
+	}
+
+	target := price * autoLevelBps / 10000
+	tick := f.ticks[symbol]
+	var size float64
+	switch {
+	case tick > 0 && f.config.TicksPerLevel > 0:
+		size = tick * float64(f.config.TicksPerLevel)
+	case tick > 0:
+		size = tick * math.Round(niceNumber(math.Max(target/tick, 1)))
+	default:
+		size = niceNumber(target)
+	}
+
+	size = roundPrice(size)
+	f.levels[symbol] = size
+	log.Printf("[KlineCache] %s footprint levels span %v", symbol, size)
+	return size
+}
+
+// addFootprintTrade adds a trade to its level of a footprint candle
+func addFootprintTrade(fp *types.Footprint, trade types.AggTrade) {
+	level := footprintLevel(fp, levelPrice(trade.Price, fp.LevelSize))
+	if trade.IsBuyerMaker {
+		level.BidVolume += trade.Quantity
+		fp.BidVolume += trade.Quantity
+	} else {
+		level.AskVolume += trade.Quantity
+		fp.AskVolume += trade.Quantity
+	}
+	level.Trades++
+	fp.Trades++
+	fp.Delta = fp.AskVolume - fp.BidVolume
+
+	if poc := findLevel(fp.Levels, fp.POC); poc < 0 || levelVolume(*level) > levelVolume(fp.Levels[poc]) {
+		fp.POC = level.Price
+	}
+}
+
+// footprintLevel returns the level at a price, inserting it if missing
+func footprintLevel(fp *types.Footprint, price float64) *types.FootprintLevel {
+	i := sort.Search(len(fp.Levels), func(i int) bool { return fp.Levels[i].Price >= price })
+	if i == len(fp.Levels) || fp.Levels[i].Price != price {
+		fp.Levels = append(fp.Levels, types.FootprintLevel{})
+		copy(fp.Levels[i+1:], fp.Levels[i:])
+		fp.Levels[i] = types.FootprintLevel{Price: price}
+	}
+	return &fp.Levels[i]
+}
+
+// findLevel returns the index of the level at a price, -1 if there is none
+func findLevel(levels []types.FootprintLevel, price float64) int {
+	i := sort.Search(len(levels), func(i int) bool { return levels[i].Price >= price })
+	if i < len(levels) && levels[i].Price == price {
+		return i
+	}
+	return -1
+}
+
+func levelVolume(level types.FootprintLevel) float64 {
+	return level.BidVolume + level.AskVolume
+}
+
+// RegroupFootprints merges every n levels of footprint candles into one
+func RegroupFootprints(candles []types.Footprint, n int) []types.Footprint {
+	if n <= 1 {
+		return candles
+	}
+
+	result := make([]types.Footprint, len(candles))
+	for i, fp := range candles {
+		grouped := fp
+		grouped.LevelSize = roundPrice(fp.LevelSize * float64(n))
+		grouped.Levels = nil
+		grouped.POC = 0
+		for _, level := range fp.Levels {
+			merged := footprintLevel(&grouped, levelPrice(level.Price, grouped.LevelSize))
+			merged.BidVolume += level.BidVolume
+			merged.AskVolume += level.AskVolume
+			merged.Trades += level.Trades
+		}
+		for j, level := range grouped.Levels {
+			if j == 0 || levelVolume(level) > levelVolume(grouped.Levels[findLevel(grouped.Levels, grouped.POC)]) {
+				grouped.POC = level.Price
+			}
+		}
+		result[i] = grouped
+	}
+	return result
+}
+
+// levelPrice returns the lowest price of the level a price falls in
+func levelPrice(price, size float64) float64 {
+	return roundPrice(math.Floor(price/size+1e-9) * size)
+}
+
+// roundPrice drops floating point noise from a computed price
+func roundPrice(price float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(price, 'g', 12, 64), 64)
+	return rounded
+}
+
+// niceNumber rounds a positive number to the nearest 1, 2 or 5 times a power of ten
+func niceNumber(x float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(x)))
+	switch frac := x / exp; {
+	case frac < 1.5:
+		return exp
+	case frac < 3.5:
+		return 2 * exp
+	case frac < 7.5:
+		return 5 * exp
+	default:
+		return 10 * exp
+	}
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// newFootprintCache returns a cache building 1m and 5m footprints with levels of
+// ticksPerLevel ticks of 0.1 for BTCUSDT
+func newFootprintCache(t *testing.T, ticksPerLevel int) *KlineCache {
+	t.Helper()
+	cache := NewKlineCache(500)
+	config := FootprintConfig{Intervals: []string{"1m", "5m"}, TicksPerLevel: ticksPerLevel, MaxCandles: 3}
+	if err := cache.EnableFootprints(config); err != nil {
+		t.Fatalf("EnableFootprints failed: %v", err)
+	}
+	cache.SetTickSize("BTCUSDT", 0.1)
+	return cache
+}
+
+// trade returns a trade minutes and seconds after resampleStart
+func trade(minute, second int, price, quantity float64, buyerMaker bool) types.AggTrade {
+	return types.AggTrade{
+		Price:        price,
+		Quantity:     quantity,
+		Time:         resampleStart + int64(minute)*60000 + int64(second)*1000,
+		IsBuyerMaker: buyerMaker,
+	}
+}
+
+func TestKlineCache_Footprints(t *testing.T) {
+	cache := newFootprintCache(t, 5) // Levels of 0.5
+
+	cache.AddTrade("BTCUSDT", trade(0, 1, 100.2, 2, false))
+	cache.AddTrade("BTCUSDT", trade(0, 2, 100.4, 1, true))
+	cache.AddTrade("BTCUSDT", trade(0, 3, 100.6, 4, true))
+	cache.AddTrade("BTCUSDT", trade(0, 4, 99.9, 1, false))
+	cache.AddTrade("BTCUSDT", trade(1, 0, 100.0, 3, false))
+
+	candles, err := cache.GetFootprints("BTCUSDT", "1m", 10)
+	if err != nil {
+		t.Fatalf("GetFootprints failed: %v", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("expected 2 candles, got %d", len(candles))
+	}
+
+	fp := candles[0]
+	if fp.OpenTime != resampleStart || fp.CloseTime != resampleStart+59999 || fp.LevelSize != 0.5 {
+		t.Errorf("unexpected candle %d-%d with levels of %v", fp.OpenTime, fp.CloseTime, fp.LevelSize)
+	}
+	want := []types.FootprintLevel{
+		{Price: 99.5, AskVolume: 1, Trades: 1},
+		{Price: 100, BidVolume: 1, AskVolume: 2, Trades: 2},
+		{Price: 100.5, BidVolume: 4, Trades: 1},
+	}
+	if len(fp.Levels) != len(want) {
+		t.Fatalf("expected %d levels, got %+v", len(want), fp.Levels)
+	}
+	for i, w := range want {
+		if fp.Levels[i] != w {
+			t.Errorf("level %d: expected %+v, got %+v", i, w, fp.Levels[i])
+		}
+	}
+	if fp.AskVolume != 3 || fp.BidVolume != 5 || fp.Delta != -2 || fp.Trades != 4 || fp.POC != 100.5 {
+		t.Errorf("unexpected totals %+v", fp)
+	}
+
+	// The 5m candle holds every trade
+	five, _ := cache.GetFootprints("BTCUSDT", "5m", 10)
+	if len(five) != 1 || five[0].Trades != 5 || five[0].POC != 100 {
+		t.Errorf("expected one 5m candle of 5 trades with POC 100, got %+v", five)
+	}
+
+	// Late trades are ignored and old candles are trimmed
+	cache.AddTrade("BTCUSDT", trade(0, 5, 100, 1, false))
+	for minute := 2; minute < 5; minute++ {
+		cache.AddTrade("BTCUSDT", trade(minute, 0, 100, 1, false))
+	}
+	candles, _ = cache.GetFootprints("BTCUSDT", "1m", 10)
+	if len(candles) != 3 || candles[0].OpenTime != resampleStart+2*60000 {
+		t.Errorf("expected the last 3 candles, got %d", len(candles))
+	}
+	if candles, _ = cache.GetFootprints("BTCUSDT", "1m", 1); len(candles) != 1 || candles[0].OpenTime != resampleStart+4*60000 {
+		t.Errorf("expected the latest candle, got %+v", candles)
+	}
+	if candles, _ = cache.GetFootprints("BTCUSDT", "1m", -1); len(candles) != 1 {
+		t.Errorf("expected a negative limit to return the latest candle, got %d", len(candles))
+	}
+}
+
+func TestKlineCache_FootprintErrors(t *testing.T) {
+	if _, err := NewKlineCache(500).GetFootprints("BTCUSDT", "1m", 10); err == nil {
+		t.Error("expected an error with footprints disabled")
+	}
+	if err := NewKlineCache(500).EnableFootprints(FootprintConfig{Intervals: []string{"1w"}}); err == nil {
+		t.Error("expected an error for a weekly interval")
+	}
+
+	cache := newFootprintCache(t, 5)
+	if _, err := cache.GetFootprints("BTCUSDT", "15m", 10); err == nil || !strings.Contains(err.Error(), "no 15m footprints") {
+		t.Errorf("expected an error for an interval not built, got %v", err)
+	}
+	if _, err := cache.GetFootprints("ETHUSDT", "1m", 10); err == nil {
+		t.Error("expected an error for a symbol without trades")
+	}
+}
+
+func TestKlineCache_FootprintLevelSize(t *testing.T) {
+	cache := newFootprintCache(t, 0)
+	cache.SetTickSize("ETHUSDT", 0.01)
+
+	// 2bps of 60000 is 12, the nearest 1-2-5 multiple of the tick is 100 ticks
+	cache.AddTrade("BTCUSDT", trade(0, 0, 60000, 1, false))
+	// 2bps of 3000 is 0.6, 50 ticks
+	cache.AddTrade("ETHUSDT", trade(0, 0, 3000, 1, false))
+	// Without a tick size, 2bps of 0.25 rounds to 0.00005
+	cache.AddTrade("DOGEUSDT", trade(0, 0, 0.25, 1, false))
+
+	for symbol, want := range map[string]float64{"BTCUSDT": 10, "ETHUSDT": 0.5, "DOGEUSDT": 0.00005} {
+		candles, err := cache.GetFootprints(symbol, "1m", 1)
+		if err != nil {
+			t.Fatalf("%s: GetFootprints failed: %v", symbol, err)
+		}
+		if candles[0].LevelSize != want {
+			t.Errorf("%s: expected levels of %v, got %v", symbol, want, candles[0].LevelSize)
+		}
+	}
+}
+
+func TestRegroupFootprints(t *testing.T) {
+	cache := newFootprintCache(t, 1) // Levels of 0.1
+	for i, price := range []float64{100.0, 100.1, 100.2, 100.3, 100.3} {
+		cache.AddTrade("BTCUSDT", trade(0, i, price, 1, i%2 == 0))
+	}
+	candles, _ := cache.GetFootprints("BTCUSDT", "1m", 1)
+
+	grouped := RegroupFootprints(candles, 2)
+	fp := grouped[0]
+	if fp.LevelSize != 0.2 || len(fp.Levels) != 2 {
+		t.Fatalf("expected 2 levels of 0.2, got %+v", fp)
+	}
+	if fp.Levels[0] != (types.FootprintLevel{Price: 100, BidVolume: 1, AskVolume: 1, Trades: 2}) {
+		t.Errorf("unexpected first level %+v", fp.Levels[0])
+	}
+	if fp.Levels[1] != (types.FootprintLevel{Price: 100.2, BidVolume: 2, AskVolume: 1, Trades: 3}) {
+		t.Errorf("unexpected second level %+v", fp.Levels[1])
+	}
+	if fp.POC != 100.2 || fp.Trades != 5 || fp.Delta != candles[0].Delta {
+		t.Errorf("unexpected totals %+v", fp)
+	}
+	if len(candles[0].Levels) != 4 {
+		t.Error("expected the original candles to be left alone")
+	}
+}
@@ -16,6 +16,19 @@ type KlineCache struct {
 	maxLen int                                  // max klines to keep per symbol/interval
 	hits   int64                                // cache hit counter
 	misses int64                                // cache miss counter
+
+	// Resampling (see resample.go)
+	base     string           // interval higher timeframes are built from, "" if disabled
+	baseStep int64            // base interval length in milliseconds
+	derived  map[string]int64 // derived interval -> length in milliseconds
+	streamed map[string]bool  // intervals with their own streams, never derived
+	onClose  CloseHandler     // receives candles closed by resampling
+
+	// Alternative bars (see bars.go)
+	bars map[string]map[string]*barSeries // [symbol][bar interval]
+
+	// Footprint candles built from trades (see footprint.go), nil if disabled
+	footprints *footprints
 }
 
 // NewKlineCache creates a new kline cache with specified max length per symbol/interval
@@ -23,6 +36,7 @@ func NewKlineCache(maxLen int) *KlineCache {
 	return &KlineCache{
 		data:   make(map[string]map[string][]types.Kline),
 		maxLen: maxLen,
+		bars:   make(map[string]map[string]*barSeries),
 	}
 }
 
@@ -35,18 +49,46 @@ func (c *KlineCache) Set(symbol, interval string, klines []types.Kline) {
 		c.data[symbol] = make(map[string][]types.Kline)
 	}
 
-	// Keep only the most recent maxLen klines
-	if len(klines) > c.maxLen {
-		klines = klines[len(klines)-c.maxLen:]
+	// Keep only the most recent klines
+	if limit := c.limit(interval); len(klines) > limit {
+		klines = klines[len(klines)-limit:]
 	}
 
 	c.data[symbol][interval] = klines
+	c.dropBars(symbol, interval)
 	log.Printf("[KlineCache] Set %d klines for %s@%s", len(klines), symbol, interval)
 }
 
 // Get retrieves the latest N klines for a symbol/interval pair
-// Returns empty slice if not found
+// Returns empty slice if not found. A derived interval missing for the symbol is
+// built from its base klines. Alternative bar intervals such as ha:5m are built
+// from their source klines on first use
 func (c *KlineCache) Get(symbol, interval string, limit int) ([]types.Kline, error) {
+	c.mu.RLock()
+	_, cached := c.data[symbol][interval]
+	derive := !cached && c.isDerived(interval)
+	c.mu.RUnlock()
+
+	if derive {
+		c.mu.Lock()
+		if _, ok := c.data[symbol][interval]; !ok {
+			c.deriveHistory(symbol, interval)
+		}
+		c.mu.Unlock()
+	}
+
+	if !cached && IsBarInterval(interval) {
+		c.mu.Lock()
+		if _, ok := c.data[symbol][interval]; !ok {
+			if err := c.buildBars(symbol, interval); err != nil {
+				c.misses++
+				c.mu.Unlock()
+				return nil, err
+			}
+		}
+		c.mu.Unlock()
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -79,10 +121,37 @@ func (c *KlineCache) Get(symbol, interval string, limit int) ([]types.Kline, err
 }
 
 // Update appends a new kline to the cache for a symbol/interval pair
-// This is called when receiving WebSocket updates
+// This is called when receiving WebSocket updates. Closed base klines also update
+// the derived intervals and closed klines the bars built from them; the candles
+// and bars that closes go to the close handler
 func (c *KlineCache) Update(symbol, interval string, kline types.Kline) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.update(symbol, interval, kline)
+
+	var resampled []derivedClose
+	if interval == c.base {
+		resampled = c.resample(symbol, kline)
+	}
+
+	// Bars are built from streamed and resampled klines alike
+	closed := c.addBars(symbol, interval, kline)
+	for _, candle := range resampled {
+		closed = append(closed, candle)
+		closed = append(closed, c.addBars(symbol, candle.interval, candle.kline)...)
+	}
+	handler := c.onClose
+	c.mu.Unlock()
+
+	if handler == nil {
+		return
+	}
+	for _, candle := range closed {
+		handler(candle.symbol, candle.interval, candle.kline)
+	}
+}
+
+// update stores a kline. Callers hold c.mu for writing
+func (c *KlineCache) update(symbol, interval string, kline types.Kline) {
 
 	if c.data[symbol] == nil {
 		c.data[symbol] = make(map[string][]types.Kline)
@@ -101,7 +170,7 @@ func (c *KlineCache) Update(symbol, interval string, kline types.Kline) {
 		log.Printf("[KlineCache] Appended new kline for %s@%s at %d", symbol, interval, kline.OpenTime)
 
 		// Trim if exceeds max length
-		if len(klines) > c.maxLen {
+		if len(klines) > c.limit(interval) {
 			klines = klines[1:] // Remove oldest
 		}
 	}
@@ -203,6 +272,10 @@ func (c *KlineCache) Clear() {
 	defer c.mu.Unlock()
 
 	c.data = make(map[string]map[string][]types.Kline)
+	c.bars = make(map[string]map[string]*barSeries)
+	if c.footprints != nil {
+		c.footprints.candles = make(map[string]map[string][]types.Footprint)
+	}
 	c.hits = 0
 	c.misses = 0
 	log.Println("[KlineCache] Cleared all cache data")
@@ -0,0 +1,277 @@
+package cache
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vyx/go-screener/internal/scheduler"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Resampling
+//
+// With a base interval set, higher timeframes are built from its closed klines
+// instead of needing their own WebSocket stream. When a base kline closes the last
+// slot of a derived candle (e.g. the 1m kline at 10:04 for the 10:00 5m candle),
+// the derived candle is aggregated, stored and handed to the close handler.
+// Only intervals configured through Derive are resampled, such as the timeframes of
+// loaded traders; Get builds a symbol's history of a derived interval from its base
+// klines on first use
+
+// CloseHandler receives candles closed by resampling
+type CloseHandler func(symbol, interval string, kline types.Kline)
+
+// derivedClose is a resampled candle waiting to be handed to the close handler
+type derivedClose struct {
+	symbol   string
+	interval string
+	kline    types.Kline
+}
+
+// SetBaseInterval enables resampling of higher timeframes from an interval
+func (c *KlineCache) SetBaseInterval(interval string) error {
+	step, err := scheduler.ParseInterval(interval)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.base = interval
+	c.baseStep = step.Milliseconds()
+	c.derived = make(map[string]int64)
+	log.Printf("[KlineCache] Resampling from %s klines", interval)
+	return nil
+}
+
+// Derive keeps an interval up to date from the base interval
+// The base interval keeps enough klines to build one candle of every derived interval.
+// The base and streamed intervals are already up to date and left alone
+func (c *KlineCache) Derive(interval string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.derive(interval)
+}
+
+// SetStreamed records the intervals streamed on their own, which Derive leaves alone
+func (c *KlineCache) SetStreamed(intervals []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.streamed = make(map[string]bool, len(intervals))
+	for _, interval := range intervals {
+		c.streamed[interval] = true
+	}
+}
+
+// OnClose sets the handler for candles closed by resampling
+func (c *KlineCache) OnClose(handler CloseHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onClose = handler
+}
+
+// IsDerived reports whether an interval is resampled from the base interval
+func (c *KlineCache) IsDerived(interval string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.isDerived(interval)
+}
+
+// derive registers a derived interval. Callers hold c.mu for writing
+func (c *KlineCache) derive(interval string) error {
+	if c.base == "" {
+		return fmt.Errorf("no base interval to resample from")
+	}
+	if _, ok := c.derived[interval]; ok || interval == c.base || c.streamed[interval] {
+		return nil
+	}
+
+	step, err := c.derivedStep(interval)
+	if err != nil {
+		return err
+	}
+	c.derived[interval] = step
+	log.Printf("[KlineCache] Deriving %s klines from %s", interval, c.base)
+	return nil
+}
+
+// derivedStep returns the length of an interval that can be resampled from the base interval
+func (c *KlineCache) derivedStep(interval string) (int64, error) {
+	duration, err := scheduler.ParseInterval(interval)
+	if err != nil {
+		return 0, err
+	}
+	// Weekly and monthly candles don't open on epoch boundaries
+	if duration > 24*time.Hour {
+		return 0, fmt.Errorf("cannot resample %s: only intervals up to 1d are supported", interval)
+	}
+
+	step := duration.Milliseconds()
+	if step <= c.baseStep || step%c.baseStep != 0 {
+		return 0, fmt.Errorf("cannot resample %s: not a multiple of %s", interval, c.base)
+	}
+	return step, nil
+}
+
+// isDerived reports whether an interval was configured through Derive. Callers hold c.mu
+func (c *KlineCache) isDerived(interval string) bool {
+	_, ok := c.derived[interval]
+	return ok
+}
+
+// limit returns the number of klines kept for an interval
+// The base interval keeps at least one full candle of the largest derived interval
+func (c *KlineCache) limit(interval string) int {
+	limit := c.maxLen
+	if interval != c.base {
+		return limit
+	}
+	for _, step := range c.derived {
+		if n := int(step / c.baseStep); n > limit {
+			limit = n
+		}
+	}
+	return limit
+}
+
+// resample updates the derived intervals of a symbol after a base kline closed
+// Returns the derived candles it closed. Callers hold c.mu for writing
+func (c *KlineCache) resample(symbol string, kline types.Kline) []derivedClose {
+	base := c.data[symbol][c.base]
+	end := kline.OpenTime + c.baseStep
+
+	var closed []derivedClose
+	for interval, step := range c.derived {
+		if end%step != 0 {
+			continue // Not the last base kline of the candle
+		}
+
+		klines := c.data[symbol][interval]
+		if len(klines) == 0 {
+			// First candle for this symbol; build what history the base interval has
+			c.deriveHistory(symbol, interval)
+			klines = c.data[symbol][interval]
+			if len(klines) > 0 && klines[len(klines)-1].OpenTime == end-step {
+				closed = append(closed, derivedClose{symbol: symbol, interval: interval, kline: klines[len(klines)-1]})
+			}
+			continue
+		}
+
+		candle, ok := aggregate(base, end-step, step, c.baseStep)
+		if !ok {
+			log.Printf("[KlineCache] Missing %s klines for %s@%s at %d, not closing candle", c.base, symbol, interval, end-step)
+			continue
+		}
+
+		c.data[symbol][interval] = c.store(klines, candle)
+		closed = append(closed, derivedClose{symbol: symbol, interval: interval, kline: candle})
+	}
+	return closed
+}
+
+// store puts a resampled candle into a derived interval's klines
+// A bootstrapped candle with the same open time (fetched while still open) is replaced
+func (c *KlineCache) store(klines []types.Kline, candle types.Kline) []types.Kline {
+	i := len(klines)
+	for i > 0 && klines[i-1].OpenTime >= candle.OpenTime {
+		i--
+	}
+	if i < len(klines) && klines[i].OpenTime == candle.OpenTime {
+		klines[i] = candle
+		return klines
+	}
+
+	klines = append(klines[:i], append([]types.Kline{candle}, klines[i:]...)...)
+	if len(klines) > c.maxLen {
+		klines = klines[1:]
+	}
+	return klines
+}
+
+// deriveHistory builds a derived interval for a symbol from its base klines
+// Callers hold c.mu for writing
+func (c *KlineCache) deriveHistory(symbol, interval string) {
+	base, ok := c.data[symbol][c.base]
+	if !ok {
+		return
+	}
+	klines := aggregateAll(base, c.baseStep, c.derived[interval])
+	if len(klines) > c.maxLen {
+		klines = klines[len(klines)-c.maxLen:]
+	}
+	c.data[symbol][interval] = klines
+	log.Printf("[KlineCache] Derived %d klines for %s@%s from %s", len(klines), symbol, interval, c.base)
+}
+
+// aggregateAll resamples base klines into every complete, closed candle of step milliseconds
+func aggregateAll(base []types.Kline, baseStep, step int64) []types.Kline {
+	now := time.Now().UnixMilli()
+
+	var klines []types.Kline
+	for i := 0; i < len(base); {
+		start := base[i].OpenTime - base[i].OpenTime%step
+
+		j := i
+		for j < len(base) && base[j].OpenTime < start+step {
+			j++
+		}
+		bucket := base[i:j]
+		i = j
+
+		if bucket[len(bucket)-1].CloseTime >= now {
+			break // The candle is still open
+		}
+		if candle, ok := aggregate(bucket, start, step, baseStep); ok {
+			klines = append(klines, candle)
+		}
+	}
+	return klines
+}
+
+// aggregate combines the base klines of the candle opening at start into one kline
+// Returns false unless every base kline of the candle is present
+func aggregate(base []types.Kline, start, step, baseStep int64) (types.Kline, bool) {
+	end := start + step
+
+	// Base klines are ordered; find the ones inside the candle from the end
+	last := len(base)
+	for last > 0 && base[last-1].OpenTime >= end {
+		last--
+	}
+	first := last
+	for first > 0 && base[first-1].OpenTime >= start {
+		first--
+	}
+	bucket := base[first:last]
+	if int64(len(bucket)) != step/baseStep {
+		return types.Kline{}, false
+	}
+
+	candle := types.Kline{
+		OpenTime:  start,
+		Open:      bucket[0].Open,
+		High:      bucket[0].High,
+		Low:       bucket[0].Low,
+		Close:     bucket[len(bucket)-1].Close,
+		CloseTime: end - 1,
+	}
+	for _, k := range bucket {
+		if k.High > candle.High {
+			candle.High = k.High
+		}
+		if k.Low < candle.Low {
+			candle.Low = k.Low
+		}
+		candle.Volume += k.Volume
+		candle.BuyVolume += k.BuyVolume
+		candle.SellVolume += k.SellVolume
+		candle.VolumeDelta += k.VolumeDelta
+		candle.QuoteVolume += k.QuoteVolume
+		candle.Trades += k.Trades
+		candle.TakerBuyBaseAssetVolume += k.TakerBuyBaseAssetVolume
+		candle.TakerBuyQuoteAssetVolume += k.TakerBuyQuoteAssetVolume
+		candle.NumberOfTrades += k.NumberOfTrades
+	}
+	return candle, true
+}
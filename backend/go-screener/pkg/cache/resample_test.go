@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// resampleStart is a past timestamp on a 1h boundary
+const resampleStart int64 = 1_700_000_000_000 - 1_700_000_000_000%3_600_000
+
+// minuteKline returns the closed 1m kline n minutes after resampleStart
+func minuteKline(n int) types.Kline {
+	open := resampleStart + int64(n)*60_000
+	price := 100 + float64(n)
+	return types.Kline{
+		OpenTime:    open,
+		Open:        price,
+		High:        price + 2,
+		Low:         price - 1,
+		Close:       price + 1,
+		Volume:      10,
+		BuyVolume:   6,
+		SellVolume:  4,
+		VolumeDelta: 2,
+		QuoteVolume: 1000,
+		Trades:      5,
+		CloseTime:   open + 59_999,
+	}
+}
+
+func newResamplingCache(t *testing.T, maxLen int, derived ...string) (*KlineCache, *[]derivedClose) {
+	t.Helper()
+
+	cache := NewKlineCache(maxLen)
+	if err := cache.SetBaseInterval("1m"); err != nil {
+		t.Fatalf("SetBaseInterval failed: %v", err)
+	}
+	for _, interval := range derived {
+		if err := cache.Derive(interval); err != nil {
+			t.Fatalf("Derive(%s) failed: %v", interval, err)
+		}
+	}
+
+	var closed []derivedClose
+	cache.OnClose(func(symbol, interval string, kline types.Kline) {
+		closed = append(closed, derivedClose{symbol: symbol, interval: interval, kline: kline})
+	})
+	return cache, &closed
+}
+
+func TestKlineCache_Resample(t *testing.T) {
+	cache, closed := newResamplingCache(t, 500, "5m")
+
+	for i := 0; i < 10; i++ {
+		cache.Update("BTCUSDT", "1m", minuteKline(i))
+	}
+
+	if len(*closed) != 2 {
+		t.Fatalf("Expected 2 closed 5m candles, got %d", len(*closed))
+	}
+
+	klines, err := cache.Get("BTCUSDT", "5m", 10)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(klines) != 2 {
+		t.Fatalf("Expected 2 klines, got %d", len(klines))
+	}
+
+	want := types.Kline{
+		OpenTime:    resampleStart + 300_000,
+		Open:        105,
+		High:        111,
+		Low:         104,
+		Close:       110,
+		Volume:      50,
+		BuyVolume:   30,
+		SellVolume:  20,
+		VolumeDelta: 10,
+		QuoteVolume: 5000,
+		Trades:      25,
+		CloseTime:   resampleStart + 600_000 - 1,
+	}
+	if klines[1] != want {
+		t.Errorf("Expected %+v, got %+v", want, klines[1])
+	}
+
+	last := (*closed)[1]
+	if last.symbol != "BTCUSDT" || last.interval != "5m" || last.kline != want {
+		t.Errorf("Unexpected close event %+v", last)
+	}
+}
+
+func TestKlineCache_ResampleReplacesBootstrappedCandle(t *testing.T) {
+	cache, closed := newResamplingCache(t, 500, "5m")
+
+	// Bootstrapped while the second candle was still open
+	cache.Set("BTCUSDT", "5m", []types.Kline{
+		{OpenTime: resampleStart, Close: 1},
+		{OpenTime: resampleStart + 300_000, Close: 2},
+	})
+	for i := 0; i < 10; i++ {
+		cache.Update("BTCUSDT", "1m", minuteKline(i))
+	}
+
+	klines, _ := cache.Get("BTCUSDT", "5m", 10)
+	if len(klines) != 2 {
+		t.Fatalf("Expected 2 klines, got %d", len(klines))
+	}
+	if klines[1].Close != 110 {
+		t.Errorf("Expected bootstrapped candle to be replaced, got close=%f", klines[1].Close)
+	}
+	if len(*closed) != 2 {
+		t.Errorf("Expected 2 close events, got %d", len(*closed))
+	}
+}
+
+func TestKlineCache_ResampleMissingBaseKline(t *testing.T) {
+	cache, closed := newResamplingCache(t, 500, "5m")
+
+	for i := 0; i < 10; i++ {
+		if i == 7 {
+			continue
+		}
+		cache.Update("BTCUSDT", "1m", minuteKline(i))
+	}
+
+	// The second candle can't be built without 1m kline 7
+	if len(*closed) != 1 {
+		t.Fatalf("Expected 1 closed candle, got %d", len(*closed))
+	}
+	klines, _ := cache.Get("BTCUSDT", "5m", 10)
+	if len(klines) != 1 {
+		t.Errorf("Expected 1 kline, got %d", len(klines))
+	}
+}
+
+func TestKlineCache_GetDerivesHistory(t *testing.T) {
+	cache, closed := newResamplingCache(t, 500, "3m")
+
+	// Starts mid-candle; the incomplete first 3m candle is dropped
+	klines := make([]types.Kline, 0, 10)
+	for i := 1; i <= 10; i++ {
+		klines = append(klines, minuteKline(i))
+	}
+	cache.Set("BTCUSDT", "1m", klines)
+
+	derived, err := cache.Get("BTCUSDT", "3m", 10)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(derived) != 2 {
+		t.Fatalf("Expected 2 klines, got %d", len(derived))
+	}
+	if derived[0].OpenTime != resampleStart+180_000 || derived[0].Open != 103 || derived[1].Close != 109 {
+		t.Errorf("Unexpected klines %+v", derived)
+	}
+	// Kept up to date from then on
+	cache.Update("BTCUSDT", "1m", minuteKline(11))
+	if len(*closed) != 1 || (*closed)[0].interval != "3m" {
+		t.Fatalf("Expected a 3m close event, got %+v", *closed)
+	}
+
+	// Any multiple of the base interval is served once a trader's timeframes derive it
+	if err := cache.Derive("2m"); err != nil {
+		t.Fatalf("Derive(2m) failed: %v", err)
+	}
+	if derived, err := cache.Get("BTCUSDT", "2m", 10); err != nil || len(derived) != 5 {
+		t.Errorf("Expected 5 2m klines, got %d (%v)", len(derived), err)
+	}
+	if err := cache.Derive("7s"); err == nil {
+		t.Error("Expected error for an interval that isn't a multiple of 1m")
+	}
+
+	// Streamed intervals keep their streams
+	cache.SetStreamed([]string{"1m", "1d"})
+	if err := cache.Derive("1d"); err != nil || cache.IsDerived("1d") {
+		t.Errorf("Expected 1d to be left alone, got derived=%v (%v)", cache.IsDerived("1d"), err)
+	}
+}
+
+func TestKlineCache_BaseRetention(t *testing.T) {
+	cache, _ := newResamplingCache(t, 10, "15m")
+
+	for i := 0; i < 30; i++ {
+		cache.Update("BTCUSDT", "1m", minuteKline(i))
+	}
+
+	base, _ := cache.Get("BTCUSDT", "1m", 100)
+	if len(base) != 15 {
+		t.Errorf("Expected base interval to keep 15 klines, got %d", len(base))
+	}
+	derived, _ := cache.Get("BTCUSDT", "15m", 100)
+	if len(derived) != 2 {
+		t.Errorf("Expected 2 15m klines, got %d", len(derived))
+	}
+}
+
+func TestKlineCache_DeriveRequiresBase(t *testing.T) {
+	cache := NewKlineCache(500)
+	if err := cache.Derive("5m"); err == nil {
+		t.Error("Expected error without a base interval")
+	}
+
+	if err := cache.SetBaseInterval("5m"); err != nil {
+		t.Fatalf("SetBaseInterval failed: %v", err)
+	}
+	if err := cache.Derive("1m"); err == nil {
+		t.Error("Expected error for an interval shorter than the base")
+	}
+}
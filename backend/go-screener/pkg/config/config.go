@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/vyx/go-screener/pkg/yaegi"
 )
 
 // Config holds all application configuration
@@ -21,6 +24,20 @@ type Config struct {
 	MinVolume        float64
 	KlineInterval    string
 	ScreeningInterval time.Duration
+	DepthSymbolCount int // Top symbols whose order books are tracked, 0 disables depth
+	WSStreamsPerShard int // Kline streams per WebSocket connection
+
+	// Trade settings (aggregate trades feed footprint candles and large trade detection)
+	TradeSymbolCount      int      // Top symbols whose trades are ingested, 0 disables trades
+	FootprintIntervals    []string // Footprint candle intervals
+	FootprintTicks        int      // Ticks per price level, 0 to size levels from price
+	LargeTradeMinNotional float64  // Trades of at least this notional are large, 0 disables
+	LargeTradePercentile  float64  // Percentile of a symbol's recent trade sizes that is large, 0 disables
+
+	// Futures settings (funding, open interest, long/short ratios and liquidations of perpetuals)
+	BinanceFuturesAPIURL string
+	BinanceFuturesWSURL  string
+	FuturesSymbolCount   int // Top symbols whose perpetuals are tracked, 0 disables futures
 
 	// Supabase settings
 	SupabaseURL        string
@@ -38,6 +55,14 @@ type Config struct {
 	MachineCPUs   int
 	MachineMemory int
 
+	// Sandbox settings (standard library packages user code may import)
+	SandboxAllowedImports []string
+
+	// Filter state settings (per-trader, per-symbol key/value state)
+	FilterStateMaxKeys  int
+	FilterStateMaxBytes int
+	FilterStatePersist  bool
+
 	// Application settings
 	Environment string
 	Version     string
@@ -83,6 +108,18 @@ func Load() (*Config, error) {
 		MinVolume:         getEnvAsFloat("MIN_VOLUME", 100000),
 		KlineInterval:     getEnv("KLINE_INTERVAL", "5m"),
 		ScreeningInterval: getEnvAsDuration("SCREENING_INTERVAL_MS", 60000) * time.Millisecond,
+		DepthSymbolCount:  getEnvAsInt("DEPTH_SYMBOL_COUNT", 20),
+		WSStreamsPerShard: getEnvAsInt("WS_STREAMS_PER_SHARD", 200),
+
+		TradeSymbolCount:      getEnvAsInt("TRADE_SYMBOL_COUNT", 20),
+		FootprintIntervals:    getEnvAsList("FOOTPRINT_INTERVALS", []string{"1m", "5m", "15m"}),
+		FootprintTicks:        getEnvAsInt("FOOTPRINT_TICKS", 0),
+		LargeTradeMinNotional: getEnvAsFloat("LARGE_TRADE_MIN_NOTIONAL", 250000),
+		LargeTradePercentile:  getEnvAsFloat("LARGE_TRADE_PERCENTILE", 99.9),
+
+		BinanceFuturesAPIURL: getEnv("BINANCE_FUTURES_API_URL", "https://fapi.binance.com"),
+		BinanceFuturesWSURL:  getEnv("BINANCE_FUTURES_WS_URL", "wss://fstream.binance.com"),
+		FuturesSymbolCount:   getEnvAsInt("FUTURES_SYMBOL_COUNT", 50),
 
 		SupabaseURL:        getEnv("SUPABASE_URL", ""),
 		SupabaseServiceKey: supabaseServiceKey, // Use decoded value
@@ -97,6 +134,12 @@ func Load() (*Config, error) {
 		MachineCPUs:   getEnvAsInt("MACHINE_CPUS", 1),
 		MachineMemory: getEnvAsInt("MACHINE_MEMORY", 256),
 
+		SandboxAllowedImports: getEnvAsList("SANDBOX_ALLOWED_IMPORTS", append([]string(nil), yaegi.DefaultAllowedImports...)),
+
+		FilterStateMaxKeys:  getEnvAsInt("FILTER_STATE_MAX_KEYS", 64),
+		FilterStateMaxBytes: getEnvAsInt("FILTER_STATE_MAX_BYTES", 16384),
+		FilterStatePersist:  getEnvAsBool("FILTER_STATE_PERSIST", false),
+
 		Environment: getEnv("ENVIRONMENT", "development"),
 		Version:     getEnv("VERSION", "1.0.0"),
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
@@ -145,6 +188,18 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
 	return value
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func getEnvAsDuration(key string, defaultValue int) time.Duration {
 	valueStr := os.Getenv(key)
 	if valueStr == "" {
@@ -157,6 +212,20 @@ func getEnvAsDuration(key string, defaultValue int) time.Duration {
 	return time.Duration(value)
 }
 
+func getEnvAsList(key string, defaultValue []string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // IsDevelopment returns true if running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
@@ -0,0 +1,231 @@
+package indicators
+
+import (
+	"math"
+	"sort"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Divergences
+//
+// A divergence compares two consecutive price swing points with the oscillator
+// values on the same candles. Regular divergences warn of a reversal, hidden
+// divergences of a trend continuation:
+//
+//	regular bullish: price lower low,   oscillator higher low
+//	hidden bullish:  price higher low,  oscillator lower low
+//	regular bearish: price higher high, oscillator lower high
+//	hidden bearish:  price lower high,  oscillator higher high
+
+// Divergence types
+const (
+	DivergenceRegularBullish = "regular_bullish"
+	DivergenceHiddenBullish  = "hidden_bullish"
+	DivergenceRegularBearish = "regular_bearish"
+	DivergenceHiddenBearish  = "hidden_bearish"
+)
+
+// DivergenceOptions configure how swing points are found and paired
+// Zero fields use the defaults of DefaultDivergenceOptions
+type DivergenceOptions struct {
+	PivotLeft  int // Bars before a swing point that must be above a low / below a high (3)
+	PivotRight int // Bars after a swing point that confirm it (3)
+	Lookback   int // Only swing points within the last Lookback bars are paired (100)
+	MinGap     int // Min bars between the two swing points (5)
+	MaxGap     int // Max bars between the two swing points (60)
+}
+
+// DefaultDivergenceOptions returns the default divergence settings
+func DefaultDivergenceOptions() DivergenceOptions {
+	return DivergenceOptions{
+		PivotLeft:  3,
+		PivotRight: 3,
+		Lookback:   100,
+		MinGap:     5,
+		MaxGap:     60,
+	}
+}
+
+// withDefaults fills zero fields with the default settings
+func (o DivergenceOptions) withDefaults() DivergenceOptions {
+	d := DefaultDivergenceOptions()
+	if o.PivotLeft == 0 {
+		o.PivotLeft = d.PivotLeft
+	}
+	if o.PivotRight == 0 {
+		o.PivotRight = d.PivotRight
+	}
+	if o.Lookback == 0 {
+		o.Lookback = d.Lookback
+	}
+	if o.MinGap == 0 {
+		o.MinGap = d.MinGap
+	}
+	if o.MaxGap == 0 {
+		o.MaxGap = d.MaxGap
+	}
+	return o
+}
+
+// Divergence is a divergence between two price swing points and an oscillator
+type Divergence struct {
+	Type        string  `json:"type"`
+	Bullish     bool    `json:"bullish"`
+	StartIndex  int     `json:"startIndex"` // Index of the first swing point
+	EndIndex    int     `json:"endIndex"`   // Index of the second, more recent swing point
+	StartPrice  float64 `json:"startPrice"`
+	EndPrice    float64 `json:"endPrice"`
+	StartValue  float64 `json:"startValue"`  // Oscillator at the first swing point
+	EndValue    float64 `json:"endValue"`    // Oscillator at the second swing point
+	PriceChange float64 `json:"priceChange"` // Percent change in price between the swing points
+	Strength    float64 `json:"strength"`    // Oscillator change relative to the larger value (0 to 1)
+	StartTime   int64   `json:"startTime"`   // Close time of the first swing point
+	EndTime     int64   `json:"endTime"`     // Close time of the second swing point
+}
+
+// DetectDivergences finds divergences between price and an oscillator series with
+// one value per kline, ordered by the index of the second swing point
+func DetectDivergences(klines []types.Kline, oscillator []float64, opts DivergenceOptions) []Divergence {
+	return detectDivergences(klines, oscillator, 0, opts)
+}
+
+// detectDivergences ignores swing points before index from, where the oscillator is warming up
+func detectDivergences(klines []types.Kline, oscillator []float64, from int, opts DivergenceOptions) []Divergence {
+	if len(oscillator) != len(klines) || len(klines) == 0 {
+		return nil
+	}
+	o := opts.withDefaults()
+	if start := len(klines) - o.Lookback; start > from {
+		from = start
+	}
+
+	var divergences []Divergence
+	pair := func(points []SwingPoint, bullish bool) {
+		var prev *SwingPoint
+		for i := range points {
+			point := &points[i]
+			if point.Index < from {
+				continue
+			}
+			if prev != nil {
+				gap := point.Index - prev.Index
+				if gap >= o.MinGap && gap <= o.MaxGap {
+					if d, ok := divergenceBetween(klines, oscillator, *prev, *point, bullish); ok {
+						divergences = append(divergences, d)
+					}
+				}
+			}
+			prev = point
+		}
+	}
+	pair(FindSwingLows(klines, o.PivotLeft, o.PivotRight), true)
+	pair(FindSwingHighs(klines, o.PivotLeft, o.PivotRight), false)
+
+	sort.SliceStable(divergences, func(i, j int) bool { return divergences[i].EndIndex < divergences[j].EndIndex })
+	return divergences
+}
+
+// divergenceBetween classifies the divergence between two swing lows (bullish) or highs
+func divergenceBetween(klines []types.Kline, oscillator []float64, a, b SwingPoint, bullish bool) (Divergence, bool) {
+	va, vb := oscillator[a.Index], oscillator[b.Index]
+	priceUp, valueUp := b.Price > a.Price, vb > va
+	if b.Price == a.Price || va == vb || priceUp == valueUp {
+		return Divergence{}, false
+	}
+
+	var kind string
+	switch {
+	case bullish && !priceUp:
+		kind = DivergenceRegularBullish
+	case bullish:
+		kind = DivergenceHiddenBullish
+	case priceUp:
+		kind = DivergenceRegularBearish
+	default:
+		kind = DivergenceHiddenBearish
+	}
+
+	strength := math.Abs(vb-va) / math.Max(math.Abs(va), math.Abs(vb))
+	if strength > 1 {
+		strength = 1
+	}
+
+	return Divergence{
+		Type:        kind,
+		Bullish:     bullish,
+		StartIndex:  a.Index,
+		EndIndex:    b.Index,
+		StartPrice:  a.Price,
+		EndPrice:    b.Price,
+		StartValue:  va,
+		EndValue:    vb,
+		PriceChange: (b.Price - a.Price) / a.Price * 100,
+		Strength:    strength,
+		StartTime:   klines[a.Index].CloseTime,
+		EndTime:     klines[b.Index].CloseTime,
+	}, true
+}
+
+// DetectRSIDivergences finds divergences between price and RSI
+func DetectRSIDivergences(klines []types.Kline, period int, opts DivergenceOptions) []Divergence {
+	rsi := CalculateRSI(klines, period)
+	if rsi == nil {
+		return nil
+	}
+	return detectDivergences(klines, rsi.Values, period, opts)
+}
+
+// DetectMACDDivergences finds divergences between price and the MACD histogram
+func DetectMACDDivergences(klines []types.Kline, shortPeriod, longPeriod, signalPeriod int, opts DivergenceOptions) []Divergence {
+	macd := CalculateMACD(klines, shortPeriod, longPeriod, signalPeriod)
+	if macd == nil {
+		return nil
+	}
+	return detectDivergences(klines, macd.Histogram, longPeriod+signalPeriod-1, opts)
+}
+
+// DetectOBVDivergences finds divergences between price and On-Balance Volume
+func DetectOBVDivergences(klines []types.Kline, opts DivergenceOptions) []Divergence {
+	return detectDivergences(klines, CalculateOBVSeries(klines), 1, opts)
+}
+
+// DetectVolumeDeltaDivergences finds divergences between price and the taker volume
+// delta of the swing candles, e.g. a lower low made with less net selling
+func DetectVolumeDeltaDivergences(klines []types.Kline, opts DivergenceOptions) []Divergence {
+	delta := make([]float64, len(klines))
+	for i, k := range klines {
+		delta[i] = k.VolumeDelta
+	}
+	return detectDivergences(klines, delta, 0, opts)
+}
+
+// LatestDivergence returns the most recent divergence whose second swing point is
+// within the last maxAge bars, or nil if there is none
+func LatestDivergence(klines []types.Kline, divergences []Divergence, maxAge int) *Divergence {
+	if len(divergences) == 0 {
+		return nil
+	}
+	latest := divergences[len(divergences)-1]
+	if len(klines)-1-latest.EndIndex > maxAge {
+		return nil
+	}
+	return &latest
+}
+
+// DivergenceSeries converts divergences to chart data points for series code
+// Each point is the second swing point (x, y) with the first one in x0, y0
+func DivergenceSeries(divergences []Divergence) []map[string]interface{} {
+	points := make([]map[string]interface{}, 0, len(divergences))
+	for _, d := range divergences {
+		points = append(points, map[string]interface{}{
+			"x":        d.EndTime,
+			"y":        d.EndPrice,
+			"x0":       d.StartTime,
+			"y0":       d.StartPrice,
+			"type":     d.Type,
+			"strength": d.Strength,
+		})
+	}
+	return points
+}
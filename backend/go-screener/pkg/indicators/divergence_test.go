@@ -0,0 +1,118 @@
+package indicators
+
+import (
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Two swing lows at 3 and 9, two swing highs at 6 and 12
+var divergenceCloses = []float64{100, 98, 96, 94, 97, 99, 101, 98, 95, 93, 96, 99, 103, 100, 98, 97}
+
+func TestDetectDivergences(t *testing.T) {
+	opts := DivergenceOptions{PivotLeft: 2, PivotRight: 2, MinGap: 3}
+
+	tests := []struct {
+		name   string
+		closes []float64
+		values map[int]float64 // Oscillator at the swing points, 50 elsewhere
+		want   []string
+	}{
+		{
+			name:   "regular bullish and bearish",
+			closes: divergenceCloses,
+			values: map[int]float64{3: 20, 9: 30, 6: 70, 12: 60},
+			want:   []string{DivergenceRegularBullish, DivergenceRegularBearish},
+		},
+		{
+			name:   "hidden bullish and bearish",
+			closes: []float64{100, 98, 96, 94, 97, 99, 101, 98, 97, 95, 96, 98, 99, 97, 96, 95},
+			values: map[int]float64{3: 30, 9: 20, 6: 60, 12: 70},
+			want:   []string{DivergenceHiddenBullish, DivergenceHiddenBearish},
+		},
+		{
+			name:   "no divergence",
+			closes: divergenceCloses,
+			values: map[int]float64{3: 30, 9: 20, 6: 60, 12: 65},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			klines := zigzag(tt.closes...)
+			oscillator := make([]float64, len(klines))
+			for i := range oscillator {
+				oscillator[i] = 50
+			}
+			for i, v := range tt.values {
+				oscillator[i] = v
+			}
+
+			got := DetectDivergences(klines, oscillator, opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %+v", tt.want, got)
+			}
+			for i, d := range got {
+				if d.Type != tt.want[i] {
+					t.Errorf("divergence %d: expected %s, got %s", i, tt.want[i], d.Type)
+				}
+			}
+		})
+	}
+
+	klines := zigzag(divergenceCloses...)
+	for i := range klines {
+		klines[i].CloseTime = int64(i)*60000 + 59999
+	}
+	oscillator := make([]float64, len(klines))
+	oscillator[3], oscillator[9] = 20, 30
+	got := DetectDivergences(klines, oscillator, opts)
+	if len(got) != 1 {
+		t.Fatalf("expected one divergence, got %+v", got)
+	}
+	d := got[0]
+	if d.StartIndex != 3 || d.EndIndex != 9 || d.StartPrice != 93 || d.EndPrice != 92 || !d.Bullish {
+		t.Errorf("unexpected swing points %+v", d)
+	}
+	if d.Strength != float64(10)/30 || d.EndTime != 9*60000+59999 {
+		t.Errorf("unexpected strength or time %+v", d)
+	}
+
+	// Pairs closer than MinGap don't count
+	if got := DetectDivergences(klines, oscillator, DivergenceOptions{PivotLeft: 2, PivotRight: 2, MinGap: 10}); len(got) != 0 {
+		t.Errorf("expected no divergence below MinGap, got %+v", got)
+	}
+	if DetectDivergences(klines, oscillator[1:], opts) != nil {
+		t.Error("expected nil for a misaligned oscillator")
+	}
+
+	if latest := LatestDivergence(klines, got, 6); latest == nil || latest.EndIndex != 9 {
+		t.Errorf("expected latest divergence at 9, got %+v", latest)
+	}
+	if LatestDivergence(klines, got, 5) != nil {
+		t.Error("expected no divergence within 5 bars")
+	}
+
+	points := DivergenceSeries(got)
+	if len(points) != 1 || points[0]["x"] != d.EndTime || points[0]["y0"] != d.StartPrice || points[0]["type"] != DivergenceRegularBullish {
+		t.Errorf("unexpected series points %+v", points)
+	}
+}
+
+func TestDetectVolumeDeltaDivergences(t *testing.T) {
+	klines := zigzag(divergenceCloses...)
+	klines[3].VolumeDelta = -500 // Heavy selling into the first low
+	klines[9].VolumeDelta = -100 // Lighter selling into the lower low
+
+	got := DetectVolumeDeltaDivergences(klines, DivergenceOptions{PivotLeft: 2, PivotRight: 2, MinGap: 3})
+	if len(got) != 1 || got[0].Type != DivergenceRegularBullish {
+		t.Errorf("expected a regular bullish delta divergence, got %+v", got)
+	}
+}
+
+func TestDetectRSIDivergences_Short(t *testing.T) {
+	if DetectRSIDivergences([]types.Kline{ohlc(1, 2, 0.5, 1.5)}, 14, DivergenceOptions{}) != nil {
+		t.Error("expected nil without enough klines for RSI")
+	}
+}
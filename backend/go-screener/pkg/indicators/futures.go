@@ -0,0 +1,82 @@
+package indicators
+
+import (
+	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Futures positioning
+//
+// For symbols with a tracked perpetual, data.Futures holds its funding, open
+// interest and top trader long/short history, and recent liquidations. These
+// helpers return nothing for other symbols
+
+// OpenInterestChange calculates the percent change of open interest over the last periods
+// of its history (5 minutes each by default)
+func OpenInterestChange(data *types.MarketData, periods int) *float64 {
+	if data == nil || data.Futures == nil || periods <= 0 {
+		return nil
+	}
+	history := data.Futures.OpenInterestHist
+	if len(history) <= periods {
+		return nil
+	}
+
+	before := history[len(history)-1-periods].Value
+	if before == 0 {
+		return nil
+	}
+	change := (history[len(history)-1].Value - before) / before * 100
+	return &change
+}
+
+// Liquidations returns the liquidations of the last minutes before data.Timestamp, oldest first
+func Liquidations(data *types.MarketData, minutes int) []types.Liquidation {
+	if data == nil || data.Futures == nil {
+		return nil
+	}
+
+	now := data.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+	since := now.Add(-time.Duration(minutes) * time.Minute).UnixMilli()
+
+	var result []types.Liquidation
+	for _, liquidation := range data.Futures.Liquidations {
+		if liquidation.Time >= since {
+			result = append(result, liquidation)
+		}
+	}
+	return result
+}
+
+// LongLiquidations returns the liquidated longs of the last minutes, oldest first
+func LongLiquidations(data *types.MarketData, minutes int) []types.Liquidation {
+	return liquidationsBySide(Liquidations(data, minutes), "long")
+}
+
+// ShortLiquidations returns the liquidated shorts of the last minutes, oldest first
+func ShortLiquidations(data *types.MarketData, minutes int) []types.Liquidation {
+	return liquidationsBySide(Liquidations(data, minutes), "short")
+}
+
+func liquidationsBySide(liquidations []types.Liquidation, side string) []types.Liquidation {
+	var result []types.Liquidation
+	for _, liquidation := range liquidations {
+		if liquidation.Side == side {
+			result = append(result, liquidation)
+		}
+	}
+	return result
+}
+
+// LiquidationNotional sums the notional of liquidations
+func LiquidationNotional(liquidations []types.Liquidation) float64 {
+	var sum float64
+	for _, liquidation := range liquidations {
+		sum += liquidation.Notional
+	}
+	return sum
+}
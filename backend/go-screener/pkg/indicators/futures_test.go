@@ -0,0 +1,54 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+func TestOpenInterestChange(t *testing.T) {
+	data := &types.MarketData{Futures: &types.FuturesContext{
+		OpenInterestHist: []types.OpenInterestPoint{{Value: 100}, {Value: 120}, {Value: 90}},
+	}}
+
+	if change := OpenInterestChange(data, 1); change == nil || math.Abs(*change+25) > 1e-9 {
+		t.Errorf("expected -25%% over 1 period, got %v", change)
+	}
+	if change := OpenInterestChange(data, 2); change == nil || math.Abs(*change+10) > 1e-9 {
+		t.Errorf("expected -10%% over 2 periods, got %v", change)
+	}
+	if change := OpenInterestChange(data, 3); change != nil {
+		t.Errorf("expected nil without enough history, got %v", *change)
+	}
+	if change := OpenInterestChange(&types.MarketData{}, 1); change != nil {
+		t.Errorf("expected nil without futures, got %v", *change)
+	}
+}
+
+func TestLiquidations(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	minutesAgo := func(m int) int64 { return now.Add(-time.Duration(m) * time.Minute).UnixMilli() }
+	data := &types.MarketData{
+		Timestamp: now,
+		Futures: &types.FuturesContext{Liquidations: []types.Liquidation{
+			{Side: "long", Notional: 100000, Time: minutesAgo(30)},
+			{Side: "short", Notional: 50000, Time: minutesAgo(10)},
+			{Side: "long", Notional: 200000, Time: minutesAgo(1)},
+		}},
+	}
+
+	if longs := LongLiquidations(data, 15); len(longs) != 1 || longs[0].Notional != 200000 {
+		t.Errorf("expected one long liquidation in 15 minutes, got %+v", longs)
+	}
+	if shorts := ShortLiquidations(data, 15); len(shorts) != 1 || shorts[0].Notional != 50000 {
+		t.Errorf("expected one short liquidation in 15 minutes, got %+v", shorts)
+	}
+	if notional := LiquidationNotional(LongLiquidations(data, 60)); notional != 300000 {
+		t.Errorf("expected 300000 of longs liquidated in an hour, got %v", notional)
+	}
+	if liquidations := Liquidations(&types.MarketData{}, 15); liquidations != nil {
+		t.Errorf("expected no liquidations without futures, got %+v", liquidations)
+	}
+}
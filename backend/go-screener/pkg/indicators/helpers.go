@@ -453,3 +453,470 @@ func DetectEngulfingPattern(klines []types.Kline) string {
 
 	return ""
 }
+
+// latestValue returns the last value of a series, or nil if the series is empty
+func latestValue(series []float64) *float64 {
+	if len(series) == 0 {
+		return nil
+	}
+	val := series[len(series)-1]
+	return &val
+}
+
+// CalculateWMA calculates the Weighted Moving Average (latest bar weighs the most)
+func CalculateWMA(klines []types.Kline, period int) *float64 {
+	if len(klines) < period || period <= 0 {
+		return nil
+	}
+	return latestValue(CalculateWMASeries(klines, period))
+}
+
+// CalculateWMASeries calculates Weighted Moving Average series
+func CalculateWMASeries(klines []types.Kline, period int) []float64 {
+	results := make([]float64, len(klines))
+	if len(klines) < period || period <= 0 {
+		return results
+	}
+
+	weights := float64(period*(period+1)) / 2
+	for i := period - 1; i < len(klines); i++ {
+		sum := 0.0
+		for j := 0; j < period; j++ {
+			sum += klines[i-j].Close * float64(period-j)
+		}
+		results[i] = sum / weights
+	}
+
+	return results
+}
+
+// trueRange returns the true range of the kline at index i
+func trueRange(klines []types.Kline, i int) float64 {
+	high := klines[i].High
+	low := klines[i].Low
+	if i == 0 {
+		return high - low
+	}
+	prevClose := klines[i-1].Close
+	return math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+}
+
+// CalculateATR calculates the Average True Range with Wilder's smoothing
+func CalculateATR(klines []types.Kline, period int) *float64 {
+	if len(klines) < period+1 || period <= 0 {
+		return nil
+	}
+	return latestValue(CalculateATRSeries(klines, period))
+}
+
+// CalculateATRSeries calculates Average True Range series
+// The first value is at index period, the average of the first period true ranges
+func CalculateATRSeries(klines []types.Kline, period int) []float64 {
+	results := make([]float64, len(klines))
+	if len(klines) < period+1 || period <= 0 {
+		return results
+	}
+
+	sum := 0.0
+	for i := 1; i <= period; i++ {
+		sum += trueRange(klines, i)
+	}
+	results[period] = sum / float64(period)
+
+	for i := period + 1; i < len(klines); i++ {
+		results[i] = (results[i-1]*float64(period-1) + trueRange(klines, i)) / float64(period)
+	}
+
+	return results
+}
+
+// ADXResult contains Average Directional Index results
+type ADXResult struct {
+	ADX     []float64
+	PlusDI  []float64
+	MinusDI []float64
+}
+
+// CalculateADX calculates ADX with the +DI and -DI lines using Wilder's smoothing
+// The DI lines start at index period and ADX at index 2*period-1
+func CalculateADX(klines []types.Kline, period int) *ADXResult {
+	if len(klines) < 2*period || period <= 0 {
+		return nil
+	}
+
+	adx := make([]float64, len(klines))
+	plusDI := make([]float64, len(klines))
+	minusDI := make([]float64, len(klines))
+	dx := make([]float64, len(klines))
+
+	var smoothedTR, smoothedPlusDM, smoothedMinusDM float64
+	for i := 1; i < len(klines); i++ {
+		upMove := klines[i].High - klines[i-1].High
+		downMove := klines[i-1].Low - klines[i].Low
+
+		plusDM, minusDM := 0.0, 0.0
+		if upMove > downMove && upMove > 0 {
+			plusDM = upMove
+		}
+		if downMove > upMove && downMove > 0 {
+			minusDM = downMove
+		}
+		tr := trueRange(klines, i)
+
+		if i <= period {
+			// Sum the first period values
+			smoothedTR += tr
+			smoothedPlusDM += plusDM
+			smoothedMinusDM += minusDM
+			if i < period {
+				continue
+			}
+		} else {
+			smoothedTR = smoothedTR - smoothedTR/float64(period) + tr
+			smoothedPlusDM = smoothedPlusDM - smoothedPlusDM/float64(period) + plusDM
+			smoothedMinusDM = smoothedMinusDM - smoothedMinusDM/float64(period) + minusDM
+		}
+
+		if smoothedTR > 0 {
+			plusDI[i] = smoothedPlusDM / smoothedTR * 100
+			minusDI[i] = smoothedMinusDM / smoothedTR * 100
+		}
+		if sum := plusDI[i] + minusDI[i]; sum > 0 {
+			dx[i] = math.Abs(plusDI[i]-minusDI[i]) / sum * 100
+		}
+	}
+
+	// ADX is the Wilder average of DX
+	first := 2*period - 1
+	sum := 0.0
+	for i := period; i <= first; i++ {
+		sum += dx[i]
+	}
+	adx[first] = sum / float64(period)
+	for i := first + 1; i < len(klines); i++ {
+		adx[i] = (adx[i-1]*float64(period-1) + dx[i]) / float64(period)
+	}
+
+	return &ADXResult{
+		ADX:     adx,
+		PlusDI:  plusDI,
+		MinusDI: minusDI,
+	}
+}
+
+// GetLatestADX returns the most recent ADX, +DI and -DI values
+func GetLatestADX(klines []types.Kline, period int) *struct {
+	ADX     float64
+	PlusDI  float64
+	MinusDI float64
+} {
+	result := CalculateADX(klines, period)
+	if result == nil || len(result.ADX) == 0 {
+		return nil
+	}
+
+	idx := len(result.ADX) - 1
+	return &struct {
+		ADX     float64
+		PlusDI  float64
+		MinusDI float64
+	}{
+		ADX:     result.ADX[idx],
+		PlusDI:  result.PlusDI[idx],
+		MinusDI: result.MinusDI[idx],
+	}
+}
+
+// CalculateCCI calculates the Commodity Channel Index
+func CalculateCCI(klines []types.Kline, period int) *float64 {
+	if len(klines) < period || period <= 0 {
+		return nil
+	}
+	return latestValue(CalculateCCISeries(klines, period))
+}
+
+// CalculateCCISeries calculates Commodity Channel Index series
+func CalculateCCISeries(klines []types.Kline, period int) []float64 {
+	results := make([]float64, len(klines))
+	if len(klines) < period || period <= 0 {
+		return results
+	}
+
+	typicalPrices := make([]float64, len(klines))
+	for i, kline := range klines {
+		typicalPrices[i] = (kline.High + kline.Low + kline.Close) / 3
+	}
+
+	for i := period - 1; i < len(klines); i++ {
+		window := typicalPrices[i-period+1 : i+1]
+
+		sma := 0.0
+		for _, tp := range window {
+			sma += tp
+		}
+		sma /= float64(period)
+
+		meanDeviation := 0.0
+		for _, tp := range window {
+			meanDeviation += math.Abs(tp - sma)
+		}
+		meanDeviation /= float64(period)
+
+		if meanDeviation > 0 {
+			results[i] = (typicalPrices[i] - sma) / (0.015 * meanDeviation)
+		}
+	}
+
+	return results
+}
+
+// CalculateWilliamsR calculates Williams %R (-100 to 0)
+func CalculateWilliamsR(klines []types.Kline, period int) *float64 {
+	if len(klines) < period || period <= 0 {
+		return nil
+	}
+	return latestValue(CalculateWilliamsRSeries(klines, period))
+}
+
+// CalculateWilliamsRSeries calculates Williams %R series
+// Flat windows, where the highest high equals the lowest low, read -50
+func CalculateWilliamsRSeries(klines []types.Kline, period int) []float64 {
+	results := make([]float64, len(klines))
+	if len(klines) < period || period <= 0 {
+		return results
+	}
+
+	for i := period - 1; i < len(klines); i++ {
+		highestHigh, lowestLow := windowRange(klines, i, period)
+		if highestHigh == lowestLow {
+			results[i] = -50
+			continue
+		}
+		results[i] = (highestHigh - klines[i].Close) / (highestHigh - lowestLow) * -100
+	}
+
+	return results
+}
+
+// CalculateROC calculates the Rate of Change in percent over period bars
+func CalculateROC(klines []types.Kline, period int) *float64 {
+	if len(klines) < period+1 || period <= 0 {
+		return nil
+	}
+	return latestValue(CalculateROCSeries(klines, period))
+}
+
+// CalculateROCSeries calculates Rate of Change series
+func CalculateROCSeries(klines []types.Kline, period int) []float64 {
+	results := make([]float64, len(klines))
+	if len(klines) < period+1 || period <= 0 {
+		return results
+	}
+
+	for i := period; i < len(klines); i++ {
+		if past := klines[i-period].Close; past != 0 {
+			results[i] = (klines[i].Close - past) / past * 100
+		}
+	}
+
+	return results
+}
+
+// ChannelResult contains the bands of a price channel
+type ChannelResult struct {
+	Upper  []float64
+	Middle []float64
+	Lower  []float64
+}
+
+// CalculateKeltnerChannels calculates Keltner Channels: an EMA middle line with
+// bands multiplier ATRs away
+func CalculateKeltnerChannels(klines []types.Kline, period, atrPeriod int, multiplier float64) *ChannelResult {
+	if len(klines) < period || len(klines) < atrPeriod+1 || period <= 0 || atrPeriod <= 0 {
+		return nil
+	}
+
+	middle := CalculateEMASeries(klines, period)
+	atr := CalculateATRSeries(klines, atrPeriod)
+	upper := make([]float64, len(klines))
+	lower := make([]float64, len(klines))
+
+	start := period - 1
+	if atrPeriod > start {
+		start = atrPeriod
+	}
+	for i := start; i < len(klines); i++ {
+		upper[i] = middle[i] + multiplier*atr[i]
+		lower[i] = middle[i] - multiplier*atr[i]
+	}
+
+	return &ChannelResult{
+		Upper:  upper,
+		Middle: middle,
+		Lower:  lower,
+	}
+}
+
+// GetLatestKeltnerChannels returns the most recent Keltner Channels values
+func GetLatestKeltnerChannels(klines []types.Kline, period, atrPeriod int, multiplier float64) *struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+} {
+	return latestChannel(CalculateKeltnerChannels(klines, period, atrPeriod, multiplier))
+}
+
+// CalculateDonchianChannels calculates Donchian Channels: the highest high and
+// lowest low of the last period bars and their midpoint
+func CalculateDonchianChannels(klines []types.Kline, period int) *ChannelResult {
+	if len(klines) < period || period <= 0 {
+		return nil
+	}
+
+	upper := make([]float64, len(klines))
+	middle := make([]float64, len(klines))
+	lower := make([]float64, len(klines))
+
+	for i := period - 1; i < len(klines); i++ {
+		upper[i], lower[i] = windowRange(klines, i, period)
+		middle[i] = (upper[i] + lower[i]) / 2
+	}
+
+	return &ChannelResult{
+		Upper:  upper,
+		Middle: middle,
+		Lower:  lower,
+	}
+}
+
+// GetLatestDonchianChannels returns the most recent Donchian Channels values
+func GetLatestDonchianChannels(klines []types.Kline, period int) *struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+} {
+	return latestChannel(CalculateDonchianChannels(klines, period))
+}
+
+// latestChannel returns the last values of a channel
+func latestChannel(result *ChannelResult) *struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+} {
+	if result == nil || len(result.Middle) == 0 {
+		return nil
+	}
+
+	idx := len(result.Middle) - 1
+	return &struct {
+		Upper  float64
+		Middle float64
+		Lower  float64
+	}{
+		Upper:  result.Upper[idx],
+		Middle: result.Middle[idx],
+		Lower:  result.Lower[idx],
+	}
+}
+
+// windowRange returns the highest high and lowest low of the period bars ending at index i
+func windowRange(klines []types.Kline, i, period int) (float64, float64) {
+	highestHigh := klines[i-period+1].High
+	lowestLow := klines[i-period+1].Low
+	for j := i - period + 2; j <= i; j++ {
+		if klines[j].High > highestHigh {
+			highestHigh = klines[j].High
+		}
+		if klines[j].Low < lowestLow {
+			lowestLow = klines[j].Low
+		}
+	}
+	return highestHigh, lowestLow
+}
+
+// CalculateOBV calculates On-Balance Volume
+func CalculateOBV(klines []types.Kline) *float64 {
+	if len(klines) == 0 {
+		return nil
+	}
+	return latestValue(CalculateOBVSeries(klines))
+}
+
+// CalculateOBVSeries calculates On-Balance Volume series, starting at 0
+func CalculateOBVSeries(klines []types.Kline) []float64 {
+	results := make([]float64, len(klines))
+	for i := 1; i < len(klines); i++ {
+		results[i] = results[i-1]
+		if klines[i].Close > klines[i-1].Close {
+			results[i] += klines[i].Volume
+		} else if klines[i].Close < klines[i-1].Close {
+			results[i] -= klines[i].Volume
+		}
+	}
+	return results
+}
+
+// AroonResult contains Aroon indicator results (0 to 100, oscillator -100 to 100)
+type AroonResult struct {
+	Up         []float64
+	Down       []float64
+	Oscillator []float64
+}
+
+// CalculateAroon calculates Aroon Up and Down: how recently within the last period
+// bars the highest high and lowest low occurred
+func CalculateAroon(klines []types.Kline, period int) *AroonResult {
+	if len(klines) < period+1 || period <= 0 {
+		return nil
+	}
+
+	up := make([]float64, len(klines))
+	down := make([]float64, len(klines))
+	oscillator := make([]float64, len(klines))
+
+	for i := period; i < len(klines); i++ {
+		highIdx, lowIdx := i-period, i-period
+		for j := i - period + 1; j <= i; j++ {
+			// Ties go to the most recent bar
+			if klines[j].High >= klines[highIdx].High {
+				highIdx = j
+			}
+			if klines[j].Low <= klines[lowIdx].Low {
+				lowIdx = j
+			}
+		}
+		up[i] = float64(period-(i-highIdx)) / float64(period) * 100
+		down[i] = float64(period-(i-lowIdx)) / float64(period) * 100
+		oscillator[i] = up[i] - down[i]
+	}
+
+	return &AroonResult{
+		Up:         up,
+		Down:       down,
+		Oscillator: oscillator,
+	}
+}
+
+// GetLatestAroon returns the most recent Aroon values
+func GetLatestAroon(klines []types.Kline, period int) *struct {
+	Up         float64
+	Down       float64
+	Oscillator float64
+} {
+	result := CalculateAroon(klines, period)
+	if result == nil || len(result.Up) == 0 {
+		return nil
+	}
+
+	idx := len(result.Up) - 1
+	return &struct {
+		Up         float64
+		Down       float64
+		Oscillator float64
+	}{
+		Up:         result.Up[idx],
+		Down:       result.Down[idx],
+		Oscillator: result.Oscillator[idx],
+	}
+}
@@ -259,6 +259,106 @@ func TestDetectEngulfingPattern(t *testing.T) {
 	}
 }
 
+func TestLatestIndicators(t *testing.T) {
+	// Closes rise 0.5 per bar from 100.5 to 125 with a constant true range of 2
+	klines := createTestKlines(50, 100.0)
+
+	tests := []struct {
+		name string
+		got  *float64
+		want float64
+	}{
+		{"WMA", CalculateWMA(klines, 3), 125 - 1.0/3},
+		{"ATR", CalculateATR(klines, 14), 2},
+		{"ROC", CalculateROC(klines, 10), (125.0 - 120.0) / 120.0 * 100},
+		{"WilliamsR", CalculateWilliamsR(klines, 14), (125.5 - 125.0) / (125.5 - 117.0) * -100},
+		{"OBV", CalculateOBV(klines), 49 * 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got == nil {
+				t.Fatalf("%s = nil, want %v", tt.name, tt.want)
+			}
+			if math.Abs(*tt.got-tt.want) > 0.001 {
+				t.Errorf("%s = %v, want %v", tt.name, *tt.got, tt.want)
+			}
+		})
+	}
+
+	// Latest values match the series
+	if cci := CalculateCCI(klines, 20); cci == nil || *cci <= 0 {
+		t.Errorf("CCI in an uptrend = %v, want > 0", cci)
+	} else if series := CalculateCCISeries(klines, 20); series[len(series)-1] != *cci {
+		t.Errorf("CCI series ends at %v, latest is %v", series[len(series)-1], *cci)
+	}
+
+	// Not enough data
+	short := createTestKlines(10, 100.0)
+	if CalculateATR(short, 14) != nil || CalculateROC(short, 10) != nil || CalculateWMA(short, 20) != nil {
+		t.Error("indicators with insufficient data should return nil")
+	}
+	if series := CalculateATRSeries(short, 14); len(series) != len(short) {
+		t.Errorf("ATR series length = %d, want %d", len(series), len(short))
+	}
+}
+
+func TestCalculateADX(t *testing.T) {
+	klines := createTestKlines(50, 100.0)
+
+	result := GetLatestADX(klines, 14)
+	if result == nil {
+		t.Fatal("GetLatestADX() returned nil")
+	}
+
+	// A steady uptrend only has upward directional movement
+	if math.Abs(result.PlusDI-25) > 0.001 || result.MinusDI != 0 {
+		t.Errorf("+DI = %v, -DI = %v, want 25 and 0", result.PlusDI, result.MinusDI)
+	}
+	if math.Abs(result.ADX-100) > 0.001 {
+		t.Errorf("ADX = %v, want 100", result.ADX)
+	}
+
+	if CalculateADX(klines[:20], 14) != nil {
+		t.Error("CalculateADX() needs 2*period klines")
+	}
+}
+
+func TestCalculateChannels(t *testing.T) {
+	klines := createTestKlines(50, 100.0)
+
+	donchian := GetLatestDonchianChannels(klines, 20)
+	if donchian == nil {
+		t.Fatal("GetLatestDonchianChannels() returned nil")
+	}
+	if donchian.Upper != 125.5 || donchian.Lower != 114 || donchian.Middle != 119.75 {
+		t.Errorf("Donchian = %+v, want 125.5/119.75/114", *donchian)
+	}
+
+	keltner := GetLatestKeltnerChannels(klines, 20, 10, 2)
+	if keltner == nil {
+		t.Fatal("GetLatestKeltnerChannels() returned nil")
+	}
+	// Bands are multiplier ATRs around the EMA
+	if math.Abs(keltner.Upper-keltner.Middle-4) > 0.001 || math.Abs(keltner.Middle-keltner.Lower-4) > 0.001 {
+		t.Errorf("Keltner = %+v, want bands 4 away from the middle", *keltner)
+	}
+}
+
+func TestCalculateAroon(t *testing.T) {
+	klines := createTestKlines(50, 100.0)
+
+	result := GetLatestAroon(klines, 25)
+	if result == nil {
+		t.Fatal("GetLatestAroon() returned nil")
+	}
+
+	// New highs on every bar, the lowest low is period bars ago
+	if result.Up != 100 || result.Down != 0 || result.Oscillator != 100 {
+		t.Errorf("Aroon = %+v, want up 100, down 0, oscillator 100", *result)
+	}
+}
+
 func BenchmarkCalculateMA(b *testing.B) {
 	klines := createTestKlines(250, 100.0)
 	b.ResetTimer()
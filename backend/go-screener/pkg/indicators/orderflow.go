@@ -0,0 +1,270 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Order flow
+//
+// Binance klines split volume into taker buys and taker sells: BuyVolume is volume
+// from market buys lifting the ask, SellVolume from market sells hitting the bid,
+// and VolumeDelta is their difference. The helpers below read those fields
+
+// CalculateCVD calculates the cumulative volume delta over all klines
+func CalculateCVD(klines []types.Kline) *float64 {
+	return latestValue(CalculateCVDSeries(klines))
+}
+
+// CalculateCVDSeries calculates cumulative volume delta series anchored at the first kline
+func CalculateCVDSeries(klines []types.Kline) []float64 {
+	cvd := make([]float64, len(klines))
+	var sum float64
+	for i, k := range klines {
+		sum += k.VolumeDelta
+		cvd[i] = sum
+	}
+	return cvd
+}
+
+// CalculateSessionCVDSeries calculates cumulative volume delta series that restarts at
+// every session boundary. sessionMs is the session length in milliseconds, e.g.
+// 86400000 for UTC days
+func CalculateSessionCVDSeries(klines []types.Kline, sessionMs int64) []float64 {
+	if sessionMs <= 0 {
+		return CalculateCVDSeries(klines)
+	}
+
+	cvd := make([]float64, len(klines))
+	var sum float64
+	for i, k := range klines {
+		if i > 0 && k.OpenTime/sessionMs != klines[i-1].OpenTime/sessionMs {
+			sum = 0
+		}
+		sum += k.VolumeDelta
+		cvd[i] = sum
+	}
+	return cvd
+}
+
+// CalculateRollingCVDSeries calculates the volume delta summed over the last period bars
+func CalculateRollingCVDSeries(klines []types.Kline, period int) []float64 {
+	if len(klines) < period || period <= 0 {
+		return []float64{}
+	}
+
+	cvd := make([]float64, len(klines))
+	var sum float64
+	for i, k := range klines {
+		sum += k.VolumeDelta
+		if i >= period {
+			sum -= klines[i-period].VolumeDelta
+		}
+		if i >= period-1 {
+			cvd[i] = sum
+		}
+	}
+	return cvd
+}
+
+// CalculateRollingCVD calculates the volume delta summed over the last period bars
+func CalculateRollingCVD(klines []types.Kline, period int) *float64 {
+	return latestValue(CalculateRollingCVDSeries(klines, period))
+}
+
+// DetectCVDDivergences finds divergences between price and cumulative volume delta,
+// e.g. a higher high made while CVD makes a lower high
+func DetectCVDDivergences(klines []types.Kline, opts DivergenceOptions) []Divergence {
+	return detectDivergences(klines, CalculateCVDSeries(klines), 0, opts)
+}
+
+// CalculateBuySellRatio calculates taker buy volume over taker sell volume for the
+// last period bars. Above 1 buyers dominate; nil when there was no selling
+func CalculateBuySellRatio(klines []types.Kline, period int) *float64 {
+	if len(klines) < period || period <= 0 {
+		return nil
+	}
+
+	var buy, sell float64
+	for _, k := range klines[len(klines)-period:] {
+		buy += k.BuyVolume
+		sell += k.SellVolume
+	}
+	if sell == 0 {
+		return nil
+	}
+	ratio := buy / sell
+	return &ratio
+}
+
+// CalculateImbalance calculates the taker imbalance of the last period bars: volume
+// delta over volume, from -1 (all sells) to 1 (all buys)
+func CalculateImbalance(klines []types.Kline, period int) *float64 {
+	return latestValue(CalculateImbalanceSeries(klines, period))
+}
+
+// CalculateImbalanceSeries calculates taker imbalance series over period bars
+func CalculateImbalanceSeries(klines []types.Kline, period int) []float64 {
+	if len(klines) < period || period <= 0 {
+		return []float64{}
+	}
+
+	imbalance := make([]float64, len(klines))
+	var delta, volume float64
+	for i, k := range klines {
+		delta += k.VolumeDelta
+		volume += k.Volume
+		if i >= period {
+			delta -= klines[i-period].VolumeDelta
+			volume -= klines[i-period].Volume
+		}
+		if i >= period-1 && volume > 0 {
+			imbalance[i] = delta / volume
+		}
+	}
+	return imbalance
+}
+
+// AbsorptionOptions are the thresholds used to detect absorption
+// Zero fields use the defaults of DefaultAbsorptionOptions
+type AbsorptionOptions struct {
+	Lookback       int     // Bars the average volume and range are taken over (20)
+	VolumeMultiple float64 // Min volume, as a multiple of the average volume (2)
+	RangeMultiple  float64 // Max range, as a multiple of the average range (0.7)
+	MinImbalance   float64 // Min |delta| / volume of the candle (0.2)
+}
+
+// DefaultAbsorptionOptions returns the default absorption thresholds
+func DefaultAbsorptionOptions() AbsorptionOptions {
+	return AbsorptionOptions{
+		Lookback:       20,
+		VolumeMultiple: 2,
+		RangeMultiple:  0.7,
+		MinImbalance:   0.2,
+	}
+}
+
+// withDefaults fills zero fields with the default thresholds
+func (o AbsorptionOptions) withDefaults() AbsorptionOptions {
+	d := DefaultAbsorptionOptions()
+	if o.Lookback == 0 {
+		o.Lookback = d.Lookback
+	}
+	if o.VolumeMultiple == 0 {
+		o.VolumeMultiple = d.VolumeMultiple
+	}
+	if o.RangeMultiple == 0 {
+		o.RangeMultiple = d.RangeMultiple
+	}
+	if o.MinImbalance == 0 {
+		o.MinImbalance = d.MinImbalance
+	}
+	return o
+}
+
+// Absorption is a high volume, small range candle whose delta opposes its close:
+// aggressive sellers absorbed by passive buyers (bullish) or the reverse (bearish)
+type Absorption struct {
+	Index       int     `json:"index"`
+	Bullish     bool    `json:"bullish"`
+	VolumeRatio float64 `json:"volumeRatio"` // Volume over the average volume
+	RangeRatio  float64 `json:"rangeRatio"`  // Range over the average range
+	Imbalance   float64 `json:"imbalance"`   // Delta over volume, -1 to 1
+	OpenTime    int64   `json:"openTime"`
+}
+
+// DetectAbsorption returns the absorption candles among the klines
+func DetectAbsorption(klines []types.Kline, opts AbsorptionOptions) []Absorption {
+	var found []Absorption
+	for i := range klines {
+		if a := DetectAbsorptionAt(klines, i, opts); a != nil {
+			found = append(found, *a)
+		}
+	}
+	return found
+}
+
+// DetectAbsorptionAt returns the absorption on the kline at index i, or nil if there is none
+func DetectAbsorptionAt(klines []types.Kline, i int, opts AbsorptionOptions) *Absorption {
+	o := opts.withDefaults()
+	if i < o.Lookback || i >= len(klines) {
+		return nil
+	}
+
+	var avgVolume, avgRange float64
+	for _, k := range klines[i-o.Lookback : i] {
+		avgVolume += k.Volume
+		avgRange += k.High - k.Low
+	}
+	avgVolume /= float64(o.Lookback)
+	avgRange /= float64(o.Lookback)
+
+	k := klines[i]
+	if avgVolume <= 0 || avgRange <= 0 || k.Volume <= 0 {
+		return nil
+	}
+
+	a := &Absorption{
+		Index:       i,
+		VolumeRatio: k.Volume / avgVolume,
+		RangeRatio:  (k.High - k.Low) / avgRange,
+		Imbalance:   k.VolumeDelta / k.Volume,
+		OpenTime:    k.OpenTime,
+	}
+	if a.VolumeRatio < o.VolumeMultiple || a.RangeRatio > o.RangeMultiple || math.Abs(a.Imbalance) < o.MinImbalance {
+		return nil
+	}
+
+	switch {
+	case a.Imbalance < 0 && k.Close >= k.Open:
+		a.Bullish = true // Selling didn't move price down
+	case a.Imbalance > 0 && k.Close <= k.Open:
+		a.Bullish = false // Buying didn't move price up
+	default:
+		return nil
+	}
+	return a
+}
+
+// Large trades
+//
+// For symbols whose trades are tracked, data.Tape keeps the trades flagged as
+// unusually large for the symbol. These helpers return nothing for other symbols
+
+// LargeTrades returns the large trades of the last minutes, oldest first
+func LargeTrades(data *types.MarketData, minutes int) []types.LargeTrade {
+	if data == nil || data.Tape == nil {
+		return nil
+	}
+	return data.Tape.LargeTrades(minutes)
+}
+
+// LargeBuys returns the large taker buys of the last minutes, oldest first
+func LargeBuys(data *types.MarketData, minutes int) []types.LargeTrade {
+	return largeTradesBySide(LargeTrades(data, minutes), types.TakerBuy)
+}
+
+// LargeSells returns the large taker sells of the last minutes, oldest first
+func LargeSells(data *types.MarketData, minutes int) []types.LargeTrade {
+	return largeTradesBySide(LargeTrades(data, minutes), types.TakerSell)
+}
+
+func largeTradesBySide(trades []types.LargeTrade, side string) []types.LargeTrade {
+	var result []types.LargeTrade
+	for _, trade := range trades {
+		if trade.Side == side {
+			result = append(result, trade)
+		}
+	}
+	return result
+}
+
+// LargeTradeNotional sums the notional of trades
+func LargeTradeNotional(trades []types.LargeTrade) float64 {
+	var sum float64
+	for _, trade := range trades {
+		sum += trade.Notional
+	}
+	return sum
+}
@@ -0,0 +1,154 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// flow returns a kline with the given taker buy and sell volume
+func flow(openTime int64, buy, sell float64) types.Kline {
+	return types.Kline{
+		OpenTime:    openTime,
+		Open:        100,
+		High:        101,
+		Low:         99,
+		Close:       100,
+		Volume:      buy + sell,
+		BuyVolume:   buy,
+		SellVolume:  sell,
+		VolumeDelta: buy - sell,
+	}
+}
+
+func TestCalculateCVDSeries(t *testing.T) {
+	const day = int64(86400000)
+	klines := []types.Kline{
+		flow(day-120000, 10, 5), // +5
+		flow(day-60000, 2, 8),   // -6
+		flow(day, 7, 3),         // +4, new session
+		flow(day+60000, 4, 1),   // +3
+	}
+
+	assertSeries(t, "CVD", CalculateCVDSeries(klines), []float64{5, -1, 3, 6})
+	assertSeries(t, "session CVD", CalculateSessionCVDSeries(klines, day), []float64{5, -1, 4, 7})
+	assertSeries(t, "rolling CVD", CalculateRollingCVDSeries(klines, 2), []float64{0, -1, -2, 7})
+
+	if got := CalculateCVD(klines); got == nil || *got != 6 {
+		t.Errorf("expected CVD 6, got %v", got)
+	}
+	if got := CalculateRollingCVD(klines, 5); got != nil {
+		t.Errorf("expected nil rolling CVD with too few klines, got %v", *got)
+	}
+}
+
+func TestCalculateImbalance(t *testing.T) {
+	klines := []types.Kline{flow(0, 10, 10), flow(1, 30, 10), flow(2, 5, 15)}
+
+	assertSeries(t, "imbalance", CalculateImbalanceSeries(klines, 2), []float64{0, 20.0 / 60, 10.0 / 60})
+
+	if got := CalculateImbalance(klines, 3); got == nil || math.Abs(*got-10.0/80) > 1e-9 {
+		t.Errorf("expected imbalance 0.125, got %v", got)
+	}
+	if got := CalculateBuySellRatio(klines, 2); got == nil || *got != 35.0/25 {
+		t.Errorf("expected buy/sell ratio 1.4, got %v", got)
+	}
+	if CalculateBuySellRatio([]types.Kline{flow(0, 10, 0)}, 1) != nil {
+		t.Error("expected nil buy/sell ratio without selling")
+	}
+}
+
+func TestDetectAbsorption(t *testing.T) {
+	klines := make([]types.Kline, 0, 22)
+	for i := 0; i < 20; i++ {
+		klines = append(klines, flow(int64(i), 50, 50)) // Volume 100, range 2
+	}
+
+	// Heavy selling, tiny range, green close: sellers absorbed
+	bullish := flow(20, 100, 300)
+	bullish.High, bullish.Low, bullish.Close = 100.6, 99.8, 100.4
+	klines = append(klines, bullish)
+
+	// Heavy volume that moved price: not absorption
+	breakout := flow(21, 100, 300)
+	breakout.Low, breakout.Close = 95, 95.5
+	klines = append(klines, breakout)
+
+	got := DetectAbsorption(klines, AbsorptionOptions{})
+	if len(got) != 1 {
+		t.Fatalf("expected one absorption, got %+v", got)
+	}
+	a := got[0]
+	if a.Index != 20 || !a.Bullish || a.VolumeRatio != 4 || a.Imbalance != -0.5 {
+		t.Errorf("unexpected absorption %+v", a)
+	}
+
+	// Same candle but buyers dominating a red close: bearish
+	bearish := flow(20, 300, 100)
+	bearish.High, bearish.Low, bearish.Close = 100.2, 99.4, 99.6
+	klines[20] = bearish
+	if a := DetectAbsorptionAt(klines, 20, AbsorptionOptions{}); a == nil || a.Bullish {
+		t.Errorf("expected bearish absorption, got %+v", a)
+	}
+
+	if DetectAbsorptionAt(klines, 5, AbsorptionOptions{}) != nil {
+		t.Error("expected nil before the lookback is filled")
+	}
+}
+
+func assertSeries(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: expected %v, got %v", name, want, got)
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("%s[%d]: expected %.4f, got %.4f", name, i, want[i], got[i])
+		}
+	}
+}
+
+// fakeTape serves fixed large trades, each minutesAgo before now
+type fakeTape struct {
+	trades     []types.LargeTrade
+	minutesAgo []int
+}
+
+func (f *fakeTape) LargeTrades(minutes int) []types.LargeTrade {
+	var result []types.LargeTrade
+	for i, trade := range f.trades {
+		if f.minutesAgo[i] <= minutes {
+			result = append(result, trade)
+		}
+	}
+	return result
+}
+
+func TestLargeTrades(t *testing.T) {
+	data := &types.MarketData{Tape: &fakeTape{
+		trades: []types.LargeTrade{
+			{ID: 1, Side: types.TakerBuy, Notional: 300000},
+			{ID: 2, Side: types.TakerSell, Notional: 500000},
+			{ID: 3, Side: types.TakerBuy, Notional: 400000},
+		},
+		minutesAgo: []int{20, 10, 2},
+	}}
+
+	if buys := LargeBuys(data, 15); len(buys) != 1 || buys[0].ID != 3 {
+		t.Errorf("expected trade 3 as the only buy of the last 15 minutes, got %+v", buys)
+	}
+	if sells := LargeSells(data, 15); len(sells) != 1 || sells[0].ID != 2 {
+		t.Errorf("expected trade 2 as the only sell of the last 15 minutes, got %+v", sells)
+	}
+	if notional := LargeTradeNotional(LargeBuys(data, 30)); notional != 700000 {
+		t.Errorf("expected 700000 bought in 30 minutes, got %v", notional)
+	}
+
+	if trades := LargeTrades(&types.MarketData{}, 15); trades != nil {
+		t.Errorf("expected no trades without a tape, got %+v", trades)
+	}
+	if trades := LargeBuys(nil, 15); trades != nil {
+		t.Errorf("expected no trades without data, got %+v", trades)
+	}
+}
@@ -0,0 +1,323 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Candlestick patterns
+//
+// Patterns are detected on the candle at a given index, using the candles before it
+// for multi-candle formations and trend context. Pass closed klines: a pattern on
+// a candle that is still open can disappear before it closes
+
+// Pattern names
+const (
+	PatternDoji               = "doji"
+	PatternDragonflyDoji      = "dragonfly_doji"
+	PatternGravestoneDoji     = "gravestone_doji"
+	PatternLongLeggedDoji     = "long_legged_doji"
+	PatternHammer             = "hammer"
+	PatternShootingStar       = "shooting_star"
+	PatternBullishEngulfing   = "bullish_engulfing"
+	PatternBearishEngulfing   = "bearish_engulfing"
+	PatternBullishHarami      = "bullish_harami"
+	PatternBearishHarami      = "bearish_harami"
+	PatternMorningStar        = "morning_star"
+	PatternEveningStar        = "evening_star"
+	PatternThreeWhiteSoldiers = "three_white_soldiers"
+	PatternThreeBlackCrows    = "three_black_crows"
+	PatternInsideBar          = "inside_bar"
+	PatternOutsideBar         = "outside_bar"
+	PatternBullishPinBar      = "bullish_pin_bar"
+	PatternBearishPinBar      = "bearish_pin_bar"
+)
+
+// PatternNames lists every pattern DetectPatterns recognizes
+var PatternNames = []string{
+	PatternDoji, PatternDragonflyDoji, PatternGravestoneDoji, PatternLongLeggedDoji,
+	PatternHammer, PatternShootingStar,
+	PatternBullishEngulfing, PatternBearishEngulfing,
+	PatternBullishHarami, PatternBearishHarami,
+	PatternMorningStar, PatternEveningStar,
+	PatternThreeWhiteSoldiers, PatternThreeBlackCrows,
+	PatternInsideBar, PatternOutsideBar,
+	PatternBullishPinBar, PatternBearishPinBar,
+}
+
+// IsPattern reports whether a pattern name is recognized
+func IsPattern(name string) bool {
+	for _, pattern := range PatternNames {
+		if pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Pattern directions
+const (
+	PatternBullish = "bullish"
+	PatternBearish = "bearish"
+	PatternNeutral = "neutral"
+)
+
+// PatternOptions are the tolerances used to recognize patterns
+// Zero fields use the defaults of DefaultPatternOptions
+type PatternOptions struct {
+	DojiBody      float64 // Max body of a doji, as a fraction of the candle range (0.1)
+	SmallShadow   float64 // Max shadow that counts as none, as a fraction of the range (0.1)
+	ShadowToBody  float64 // Min shadow to body ratio of hammers and shooting stars (2)
+	LongBody      float64 // Min body of a long candle, as a fraction of the range (0.6)
+	StarBody      float64 // Max body of a star, as a fraction of the first candle's body (0.3)
+	PinBarNose    float64 // Min nose (long shadow) of a pin bar, as a fraction of the range (0.66)
+	TrendLookback int     // Candles that set the trend before hammers and stars, at least 2; negative ignores trend (5)
+}
+
+// DefaultPatternOptions returns the default pattern tolerances
+func DefaultPatternOptions() PatternOptions {
+	return PatternOptions{
+		DojiBody:      0.1,
+		SmallShadow:   0.1,
+		ShadowToBody:  2,
+		LongBody:      0.6,
+		StarBody:      0.3,
+		PinBarNose:    0.66,
+		TrendLookback: 5,
+	}
+}
+
+// withDefaults fills zero fields with the default tolerances
+func (o PatternOptions) withDefaults() PatternOptions {
+	d := DefaultPatternOptions()
+	if o.DojiBody == 0 {
+		o.DojiBody = d.DojiBody
+	}
+	if o.SmallShadow == 0 {
+		o.SmallShadow = d.SmallShadow
+	}
+	if o.ShadowToBody == 0 {
+		o.ShadowToBody = d.ShadowToBody
+	}
+	if o.LongBody == 0 {
+		o.LongBody = d.LongBody
+	}
+	if o.StarBody == 0 {
+		o.StarBody = d.StarBody
+	}
+	if o.PinBarNose == 0 {
+		o.PinBarNose = d.PinBarNose
+	}
+	if o.TrendLookback == 0 {
+		o.TrendLookback = d.TrendLookback
+	}
+	if o.TrendLookback == 1 {
+		// A single candle has no trend to compare
+		o.TrendLookback = 2
+	}
+	return o
+}
+
+// PatternMatch is a pattern found on a candle
+type PatternMatch struct {
+	Name      string `json:"name"`
+	Direction string `json:"direction"` // bullish, bearish or neutral
+	Index     int    `json:"index"`     // Index of the candle the pattern completes on
+	Candles   int    `json:"candles"`   // Number of candles in the pattern
+	OpenTime  int64  `json:"openTime"`  // Open time of the candle the pattern completes on
+}
+
+// candle holds the measurements patterns are defined on
+type candle struct {
+	types.Kline
+	body, upper, lower, rng float64
+}
+
+func measure(k types.Kline) candle {
+	return candle{
+		Kline: k,
+		body:  math.Abs(k.Close - k.Open),
+		upper: k.High - math.Max(k.Open, k.Close),
+		lower: math.Min(k.Open, k.Close) - k.Low,
+		rng:   k.High - k.Low,
+	}
+}
+
+func (c candle) bullish() bool { return c.Close > c.Open }
+func (c candle) bearish() bool { return c.Close < c.Open }
+
+func (c candle) bodyTop() float64    { return math.Max(c.Open, c.Close) }
+func (c candle) bodyBottom() float64 { return math.Min(c.Open, c.Close) }
+
+// long reports whether the body covers most of the candle
+func (c candle) long(o PatternOptions) bool {
+	return c.rng > 0 && c.body >= o.LongBody*c.rng
+}
+
+// DetectPatterns returns the patterns completed by the last kline
+func DetectPatterns(klines []types.Kline, opts PatternOptions) []PatternMatch {
+	return DetectPatternsAt(klines, len(klines)-1, opts)
+}
+
+// HasPattern reports whether the last kline completes the named pattern
+func HasPattern(klines []types.Kline, name string, opts PatternOptions) bool {
+	for _, match := range DetectPatterns(klines, opts) {
+		if match.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectPatternsAt returns the patterns completed by the kline at index i
+func DetectPatternsAt(klines []types.Kline, i int, opts PatternOptions) []PatternMatch {
+	if i < 0 || i >= len(klines) {
+		return nil
+	}
+	o := opts.withDefaults()
+
+	var matches []PatternMatch
+	add := func(name, direction string, candles int) {
+		matches = append(matches, PatternMatch{
+			Name:      name,
+			Direction: direction,
+			Index:     i,
+			Candles:   candles,
+			OpenTime:  klines[i].OpenTime,
+		})
+	}
+
+	c := measure(klines[i])
+	if c.rng <= 0 {
+		return nil // Flat candle, nothing to recognize
+	}
+
+	// Single candle
+	if c.body <= o.DojiBody*c.rng {
+		add(PatternDoji, PatternNeutral, 1)
+		switch {
+		case c.upper <= o.SmallShadow*c.rng:
+			add(PatternDragonflyDoji, PatternBullish, 1)
+		case c.lower <= o.SmallShadow*c.rng:
+			add(PatternGravestoneDoji, PatternBearish, 1)
+		case c.upper >= 0.3*c.rng && c.lower >= 0.3*c.rng:
+			add(PatternLongLeggedDoji, PatternNeutral, 1)
+		}
+	} else {
+		if c.lower >= o.ShadowToBody*c.body && c.upper <= o.SmallShadow*c.rng && inTrend(klines, i, -1, o) {
+			add(PatternHammer, PatternBullish, 1)
+		}
+		if c.upper >= o.ShadowToBody*c.body && c.lower <= o.SmallShadow*c.rng && inTrend(klines, i, 1, o) {
+			add(PatternShootingStar, PatternBearish, 1)
+		}
+	}
+	if c.lower >= o.PinBarNose*c.rng {
+		add(PatternBullishPinBar, PatternBullish, 1)
+	}
+	if c.upper >= o.PinBarNose*c.rng {
+		add(PatternBearishPinBar, PatternBearish, 1)
+	}
+
+	if i < 1 {
+		return matches
+	}
+
+	// Two candles
+	p := measure(klines[i-1])
+	switch {
+	case p.bearish() && c.bullish() && c.Open <= p.Close && c.Close >= p.Open && c.body > p.body:
+		add(PatternBullishEngulfing, PatternBullish, 2)
+	case p.bullish() && c.bearish() && c.Open >= p.Close && c.Close <= p.Open && c.body > p.body:
+		add(PatternBearishEngulfing, PatternBearish, 2)
+	}
+	if p.long(o) && c.body < p.body && c.bodyTop() <= p.bodyTop() && c.bodyBottom() >= p.bodyBottom() {
+		switch {
+		case p.bearish() && c.bullish():
+			add(PatternBullishHarami, PatternBullish, 2)
+		case p.bullish() && c.bearish():
+			add(PatternBearishHarami, PatternBearish, 2)
+		}
+	}
+	switch {
+	case c.High < p.High && c.Low > p.Low:
+		add(PatternInsideBar, PatternNeutral, 2)
+	case c.High > p.High && c.Low < p.Low:
+		direction := PatternNeutral
+		if c.bullish() {
+			direction = PatternBullish
+		} else if c.bearish() {
+			direction = PatternBearish
+		}
+		add(PatternOutsideBar, direction, 2)
+	}
+
+	if i < 2 {
+		return matches
+	}
+
+	// Three candles
+	f := measure(klines[i-2])
+	star := p.body <= o.StarBody*f.body
+	midpoint := (f.Open + f.Close) / 2
+	switch {
+	case f.bearish() && f.long(o) && star && p.bodyTop() <= f.Close && c.bullish() && c.Close >= midpoint:
+		add(PatternMorningStar, PatternBullish, 3)
+	case f.bullish() && f.long(o) && star && p.bodyBottom() >= f.Close && c.bearish() && c.Close <= midpoint:
+		add(PatternEveningStar, PatternBearish, 3)
+	}
+
+	soldiers := []candle{f, p, c}
+	if advancing(soldiers, o) {
+		add(PatternThreeWhiteSoldiers, PatternBullish, 3)
+	}
+	if declining(soldiers, o) {
+		add(PatternThreeBlackCrows, PatternBearish, 3)
+	}
+
+	return matches
+}
+
+// advancing reports whether long bullish candles each open inside the previous body and close higher
+func advancing(candles []candle, o PatternOptions) bool {
+	for j, c := range candles {
+		if !c.bullish() || !c.long(o) {
+			return false
+		}
+		if j > 0 {
+			prev := candles[j-1]
+			if c.Open < prev.Open || c.Open > prev.Close || c.Close <= prev.Close {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// declining reports whether long bearish candles each open inside the previous body and close lower
+func declining(candles []candle, o PatternOptions) bool {
+	for j, c := range candles {
+		if !c.bearish() || !c.long(o) {
+			return false
+		}
+		if j > 0 {
+			prev := candles[j-1]
+			if c.Open > prev.Open || c.Open < prev.Close || c.Close >= prev.Close {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// inTrend reports whether the closes leading into candle i move in a direction (1 up, -1 down)
+func inTrend(klines []types.Kline, i, direction int, o PatternOptions) bool {
+	if o.TrendLookback < 0 {
+		return true
+	}
+	if i < o.TrendLookback {
+		return false
+	}
+	change := klines[i-1].Close - klines[i-o.TrendLookback].Close
+	return change*float64(direction) > 0
+}
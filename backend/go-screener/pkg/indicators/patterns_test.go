@@ -0,0 +1,204 @@
+package indicators
+
+import (
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+func ohlc(open, high, low, close float64) types.Kline {
+	return types.Kline{Open: open, High: high, Low: low, Close: close}
+}
+
+// falling returns n bearish candles stepping down from price
+func falling(price float64, n int) []types.Kline {
+	klines := make([]types.Kline, n)
+	for i := range klines {
+		p := price - float64(i)*2
+		klines[i] = ohlc(p, p+0.5, p-2.5, p-2)
+	}
+	return klines
+}
+
+// rising returns n bullish candles stepping up from price
+func rising(price float64, n int) []types.Kline {
+	klines := make([]types.Kline, n)
+	for i := range klines {
+		p := price + float64(i)*2
+		klines[i] = ohlc(p, p+2.5, p-0.5, p+2)
+	}
+	return klines
+}
+
+func TestDetectPatterns(t *testing.T) {
+	tests := []struct {
+		name   string
+		klines []types.Kline
+		want   string
+		not    string
+	}{
+		{
+			name:   "doji",
+			klines: []types.Kline{ohlc(100, 102, 98, 100.1)},
+			want:   PatternDoji,
+		},
+		{
+			name:   "long-legged doji",
+			klines: []types.Kline{ohlc(100, 103, 97, 100)},
+			want:   PatternLongLeggedDoji,
+		},
+		{
+			name:   "dragonfly doji",
+			klines: []types.Kline{ohlc(100, 100.1, 95, 100)},
+			want:   PatternDragonflyDoji,
+		},
+		{
+			name:   "gravestone doji",
+			klines: []types.Kline{ohlc(100, 105, 99.9, 100)},
+			want:   PatternGravestoneDoji,
+		},
+		{
+			name:   "hammer after a decline",
+			klines: append(falling(120, 5), ohlc(109, 110.2, 104, 110)),
+			want:   PatternHammer,
+		},
+		{
+			name:   "no hammer after a rally",
+			klines: append(rising(100, 5), ohlc(109, 110.2, 104, 110)),
+			not:    PatternHammer,
+		},
+		{
+			name:   "shooting star after a rally",
+			klines: append(rising(100, 5), ohlc(111, 116, 109.9, 110)),
+			want:   PatternShootingStar,
+		},
+		{
+			name:   "bullish engulfing",
+			klines: []types.Kline{ohlc(100, 100.5, 97.5, 98), ohlc(97, 102.5, 96.5, 102)},
+			want:   PatternBullishEngulfing,
+		},
+		{
+			name:   "bearish engulfing",
+			klines: []types.Kline{ohlc(100, 102.5, 99.5, 102), ohlc(103, 103.5, 97.5, 98)},
+			want:   PatternBearishEngulfing,
+		},
+		{
+			name:   "bullish harami",
+			klines: []types.Kline{ohlc(110, 110.5, 99.5, 100), ohlc(102, 105, 101, 104)},
+			want:   PatternBullishHarami,
+		},
+		{
+			name:   "bearish harami",
+			klines: []types.Kline{ohlc(100, 110.5, 99.5, 110), ohlc(108, 109, 105, 106)},
+			want:   PatternBearishHarami,
+		},
+		{
+			name:   "morning star",
+			klines: []types.Kline{ohlc(110, 110.5, 99.5, 100), ohlc(99, 100, 97, 98.5), ohlc(99, 107, 98.5, 106.5)},
+			want:   PatternMorningStar,
+		},
+		{
+			name:   "evening star",
+			klines: []types.Kline{ohlc(100, 110.5, 99.5, 110), ohlc(111, 113, 110, 111.5), ohlc(111, 111.5, 103, 103.5)},
+			want:   PatternEveningStar,
+		},
+		{
+			name:   "three white soldiers",
+			klines: []types.Kline{ohlc(100, 105.5, 99.5, 105), ohlc(103, 109.5, 102.5, 109), ohlc(107, 113.5, 106.5, 113)},
+			want:   PatternThreeWhiteSoldiers,
+		},
+		{
+			name:   "three black crows",
+			klines: []types.Kline{ohlc(113, 113.5, 106.5, 107), ohlc(109, 109.5, 102.5, 103), ohlc(105, 105.5, 98.5, 99)},
+			want:   PatternThreeBlackCrows,
+		},
+		{
+			name:   "inside bar",
+			klines: []types.Kline{ohlc(100, 110, 90, 105), ohlc(102, 108, 95, 104)},
+			want:   PatternInsideBar,
+		},
+		{
+			name:   "outside bar",
+			klines: []types.Kline{ohlc(100, 105, 98, 103), ohlc(102, 108, 95, 107)},
+			want:   PatternOutsideBar,
+		},
+		{
+			name:   "bullish pin bar",
+			klines: []types.Kline{ohlc(100, 101, 90, 100.5)},
+			want:   PatternBullishPinBar,
+		},
+		{
+			name:   "bearish pin bar",
+			klines: []types.Kline{ohlc(100, 110, 99, 99.5)},
+			want:   PatternBearishPinBar,
+		},
+		{
+			name:   "plain candle",
+			klines: []types.Kline{ohlc(100, 106, 99, 105)},
+			not:    PatternDoji,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.want != "" && !HasPattern(tt.klines, tt.want, PatternOptions{}) {
+				t.Errorf("expected %s in %+v", tt.want, DetectPatterns(tt.klines, PatternOptions{}))
+			}
+			if tt.not != "" && HasPattern(tt.klines, tt.not, PatternOptions{}) {
+				t.Errorf("did not expect %s in %+v", tt.not, DetectPatterns(tt.klines, PatternOptions{}))
+			}
+		})
+	}
+}
+
+func TestDetectPatterns_Options(t *testing.T) {
+	// Body is 20% of the range: a doji only with a looser tolerance
+	klines := []types.Kline{ohlc(100, 104, 99, 101)}
+	if HasPattern(klines, PatternDoji, PatternOptions{}) {
+		t.Error("expected no doji with default tolerance")
+	}
+	if !HasPattern(klines, PatternDoji, PatternOptions{DojiBody: 0.25}) {
+		t.Error("expected doji with DojiBody 0.25")
+	}
+
+	// A hammer without enough history for the trend matches only when trend is ignored
+	hammer := []types.Kline{ohlc(109, 110.2, 104, 110)}
+	if HasPattern(hammer, PatternHammer, PatternOptions{}) {
+		t.Error("expected no hammer without trend history")
+	}
+	if !HasPattern(hammer, PatternHammer, PatternOptions{TrendLookback: -1}) {
+		t.Error("expected hammer with trend ignored")
+	}
+
+	// A one-candle lookback still compares two closes
+	declined := append(falling(120, 2), hammer...)
+	if !HasPattern(declined, PatternHammer, PatternOptions{TrendLookback: 1}) {
+		t.Error("expected hammer after a two-candle decline with TrendLookback 1")
+	}
+}
+
+func TestDetectPatternsAt(t *testing.T) {
+	klines := []types.Kline{ohlc(100, 100.5, 97.5, 98), ohlc(97, 102.5, 96.5, 102), ohlc(102, 106, 101, 105)}
+	klines[1].OpenTime = 60000
+
+	matches := DetectPatternsAt(klines, 1, PatternOptions{})
+	found := false
+	for _, m := range matches {
+		if m.Name == PatternBullishEngulfing {
+			found = true
+			if m.Index != 1 || m.Candles != 2 || m.OpenTime != 60000 || m.Direction != PatternBullish {
+				t.Errorf("unexpected match %+v", m)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected bullish engulfing at index 1, got %+v", matches)
+	}
+
+	if DetectPatternsAt(klines, 3, PatternOptions{}) != nil {
+		t.Error("expected nil for an index out of range")
+	}
+	if !IsPattern(PatternMorningStar) || IsPattern("cup_and_handle") {
+		t.Error("IsPattern mismatch")
+	}
+}
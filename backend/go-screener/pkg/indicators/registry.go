@@ -0,0 +1,587 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Indicator registry
+//
+// Every indicator a trader can configure by name is declared here once: its
+// parameters with defaults and bounds, how many klines it needs before the first
+// value, the lines it outputs and how to calculate them. Trader loading validates
+// configs against it, the analysis calculator and chart series read it, and the
+// catalog is published to the frontend
+
+// Parameter types
+const (
+	ParamInt   = "int"
+	ParamFloat = "float"
+)
+
+// ParamSpec declares a parameter of an indicator
+type ParamSpec struct {
+	Name        string  `json:"name"`
+	Type        string  `json:"type"` // int or float
+	Default     float64 `json:"default"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+	Description string  `json:"description"`
+}
+
+// IndicatorParams are the resolved parameters of an indicator, by name
+type IndicatorParams map[string]float64
+
+// Int returns an integer parameter
+func (p IndicatorParams) Int(name string) int {
+	return int(p[name])
+}
+
+// IndicatorOutput holds one series per output line, with one value per kline
+type IndicatorOutput map[string][]float64
+
+// IndicatorSpec declares an indicator of the registry
+type IndicatorSpec struct {
+	Name        string      `json:"name"`
+	Aliases     []string    `json:"aliases,omitempty"`
+	Description string      `json:"description"`
+	Params      []ParamSpec `json:"params"`
+	Outputs     []string    `json:"outputs"`          // Output lines; single-line indicators output "value"
+	Overlay     bool        `json:"overlay"`          // Drawn over price rather than in its own panel
+	Stream      string      `json:"stream,omitempty"` // Streaming engine name, if streamed
+
+	// Warmup returns the number of klines needed for the first value
+	Warmup func(p IndicatorParams) int `json:"-"`
+	// Calculate returns the output series, or nil if the klines are too few
+	Calculate func(klines []types.Kline, p IndicatorParams) IndicatorOutput `json:"-"`
+}
+
+// IndicatorInfo is the published description of a registry indicator
+type IndicatorInfo struct {
+	*IndicatorSpec
+	Warmup int `json:"warmup"` // Klines needed with the default parameters
+}
+
+// registry holds the indicators by lower-case name and alias
+var registry = map[string]*IndicatorSpec{}
+
+// registryOrder keeps the catalog in declaration order
+var registryOrder []*IndicatorSpec
+
+// RegisterIndicator adds an indicator to the registry
+// It panics on a duplicate name, as registration happens at init
+func RegisterIndicator(spec *IndicatorSpec) {
+	for _, name := range append([]string{spec.Name}, spec.Aliases...) {
+		key := strings.ToLower(name)
+		if _, exists := registry[key]; exists {
+			panic(fmt.Sprintf("indicator %s registered twice", name))
+		}
+		registry[key] = spec
+	}
+	registryOrder = append(registryOrder, spec)
+}
+
+// LookupIndicator returns the registry indicator of a name or alias, case-insensitively
+func LookupIndicator(name string) (*IndicatorSpec, bool) {
+	spec, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	return spec, ok
+}
+
+// IndicatorCatalog describes every registry indicator, in declaration order
+func IndicatorCatalog() []IndicatorInfo {
+	catalog := make([]IndicatorInfo, len(registryOrder))
+	for i, spec := range registryOrder {
+		catalog[i] = IndicatorInfo{IndicatorSpec: spec, Warmup: spec.Warmup(spec.Defaults())}
+	}
+	return catalog
+}
+
+// Defaults returns the default parameters of the indicator
+func (s *IndicatorSpec) Defaults() IndicatorParams {
+	params := make(IndicatorParams, len(s.Params))
+	for _, p := range s.Params {
+		params[p.Name] = p.Default
+	}
+	return params
+}
+
+// ResolveParams checks raw config parameters against the schema and fills in defaults
+// Numbers may be JSON numbers or numeric strings; unknown names are an error
+func (s *IndicatorSpec) ResolveParams(raw map[string]interface{}) (IndicatorParams, error) {
+	if unknown := s.UnknownParams(raw); len(unknown) > 0 {
+		return nil, fmt.Errorf("%s: unknown parameter(s) %s", s.Name, strings.Join(unknown, ", "))
+	}
+	return s.ResolveKnownParams(raw)
+}
+
+// UnknownParams returns the sorted names in raw that aren't parameters of the indicator
+func (s *IndicatorSpec) UnknownParams(raw map[string]interface{}) []string {
+	var unknown []string
+	for name := range raw {
+		if !s.hasParam(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func (s *IndicatorSpec) hasParam(name string) bool {
+	for _, p := range s.Params {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveKnownParams resolves parameters like ResolveParams but ignores unknown names
+// Stored configs use it, so a parameter dropped from the schema doesn't break them
+func (s *IndicatorSpec) ResolveKnownParams(raw map[string]interface{}) (IndicatorParams, error) {
+	params := s.Defaults()
+	for _, p := range s.Params {
+		value, ok := raw[p.Name]
+		if !ok {
+			continue
+		}
+		v, err := paramNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: parameter %s: %w", s.Name, p.Name, err)
+		}
+		if p.Type == ParamInt && v != math.Trunc(v) {
+			return nil, fmt.Errorf("%s: parameter %s must be an integer, got %v", s.Name, p.Name, v)
+		}
+		if v < p.Min || v > p.Max {
+			return nil, fmt.Errorf("%s: parameter %s must be between %v and %v, got %v", s.Name, p.Name, p.Min, p.Max, v)
+		}
+		params[p.Name] = v
+	}
+	return params, nil
+}
+
+// paramNumber converts a decoded JSON parameter to a number
+func paramNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("not a number: %q", v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// ParamValues returns the parameters as config values: ints for integer parameters
+func (s *IndicatorSpec) ParamValues(p IndicatorParams) map[string]interface{} {
+	values := make(map[string]interface{}, len(s.Params))
+	for _, param := range s.Params {
+		if param.Type == ParamInt {
+			values[param.Name] = p.Int(param.Name)
+		} else {
+			values[param.Name] = p[param.Name]
+		}
+	}
+	return values
+}
+
+// StreamParams returns the parameters in declaration order, as the stream of the indicator takes them
+func (s *IndicatorSpec) StreamParams(p IndicatorParams) []float64 {
+	values := make([]float64, len(s.Params))
+	for i, param := range s.Params {
+		values[i] = p[param.Name]
+	}
+	return values
+}
+
+// ValidateIndicatorConfig checks a chart indicator config against the registry
+// Names that aren't in the registry are custom indicators drawn by series code and pass
+func ValidateIndicatorConfig(config types.IndicatorConfig) error {
+	spec, ok := LookupIndicator(config.Name)
+	if !ok {
+		return nil
+	}
+	_, err := spec.ResolveParams(config.Params)
+	return err
+}
+
+// ValidateIndicatorConfigs checks every config and reports the first invalid one
+func ValidateIndicatorConfigs(configs []types.IndicatorConfig) error {
+	for _, config := range configs {
+		if err := ValidateIndicatorConfig(config); err != nil {
+			return fmt.Errorf("indicator %q: %w", config.ID, err)
+		}
+	}
+	return nil
+}
+
+// ValidateStoredIndicatorConfigs checks configs of a stored trader
+// Unknown parameters are returned as "<id>.<param>" instead of failing, since they
+// may have been valid when the trader was saved; other invalid values still fail
+func ValidateStoredIndicatorConfigs(configs []types.IndicatorConfig) ([]string, error) {
+	var unknown []string
+	for _, config := range configs {
+		spec, ok := LookupIndicator(config.Name)
+		if !ok {
+			continue
+		}
+		for _, name := range spec.UnknownParams(config.Params) {
+			unknown = append(unknown, config.ID+"."+name)
+		}
+		if _, err := spec.ResolveKnownParams(config.Params); err != nil {
+			return unknown, fmt.Errorf("indicator %q: %w", config.ID, err)
+		}
+	}
+	return unknown, nil
+}
+
+// CalculateIndicator calculates a registry indicator by name with raw config parameters
+func CalculateIndicator(name string, klines []types.Kline, params map[string]interface{}) (IndicatorOutput, error) {
+	spec, ok := LookupIndicator(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown indicator: %s", name)
+	}
+	p, err := spec.ResolveParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return spec.Compute(klines, p)
+}
+
+// Compute runs the indicator with resolved parameters, failing when the klines
+// don't cover the warmup
+func (s *IndicatorSpec) Compute(klines []types.Kline, p IndicatorParams) (IndicatorOutput, error) {
+	if need := s.Warmup(p); len(klines) < need {
+		return nil, fmt.Errorf("insufficient data for %s: need %d klines, got %d", s.Name, need, len(klines))
+	}
+	output := s.Calculate(klines, p)
+	if output == nil {
+		return nil, fmt.Errorf("insufficient data for %s", s.Name)
+	}
+	return output, nil
+}
+
+// IndicatorChartSeries calculates a chart indicator config as series code data points:
+// {"x": close time, "y": first output, "y2": second, ...} for the last limit klines
+// Points before the indicator warms up are left out
+func IndicatorChartSeries(config types.IndicatorConfig, klines []types.Kline, limit int) ([]map[string]interface{}, error) {
+	spec, ok := LookupIndicator(config.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown indicator: %s", config.Name)
+	}
+	p, err := spec.ResolveParams(config.Params)
+	if err != nil {
+		return nil, err
+	}
+	output, err := spec.Compute(klines, p)
+	if err != nil {
+		return nil, err
+	}
+
+	start := spec.Warmup(p) - 1
+	if len(klines)-limit > start {
+		start = len(klines) - limit
+	}
+
+	points := make([]map[string]interface{}, 0, len(klines)-start)
+	for i := start; i < len(klines); i++ {
+		point := map[string]interface{}{"x": klines[i].CloseTime}
+		for j, name := range spec.Outputs {
+			key := "y"
+			if j > 0 {
+				key = fmt.Sprintf("y%d", j+1)
+			}
+			point[key] = output[name][i]
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// periodParam declares the common "period" parameter
+func periodParam(def float64) ParamSpec {
+	return ParamSpec{Name: "period", Type: ParamInt, Default: def, Min: 1, Max: 500, Description: "Lookback in bars"}
+}
+
+// single wraps a single-line series as an output
+func single(series []float64) IndicatorOutput {
+	if len(series) == 0 {
+		return nil
+	}
+	return IndicatorOutput{"value": series}
+}
+
+// periodPlus returns a warmup of period plus extra bars
+func periodPlus(extra int) func(p IndicatorParams) int {
+	return func(p IndicatorParams) int { return p.Int("period") + extra }
+}
+
+func init() {
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "SMA",
+		Aliases:     []string{"MA"},
+		Description: "Simple Moving Average of the close",
+		Params:      []ParamSpec{periodParam(20)},
+		Outputs:     []string{"value"},
+		Overlay:     true,
+		Stream:      StreamSMA,
+		Warmup:      periodPlus(0),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return single(CalculateMASeries(k, p.Int("period")))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "EMA",
+		Description: "Exponential Moving Average of the close",
+		Params:      []ParamSpec{periodParam(20)},
+		Outputs:     []string{"value"},
+		Overlay:     true,
+		Stream:      StreamEMA,
+		Warmup:      periodPlus(0),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return single(CalculateEMASeries(k, p.Int("period")))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "WMA",
+		Description: "Weighted Moving Average of the close, latest bar weighing the most",
+		Params:      []ParamSpec{periodParam(20)},
+		Outputs:     []string{"value"},
+		Overlay:     true,
+		Warmup:      periodPlus(0),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return single(CalculateWMASeries(k, p.Int("period")))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "RSI",
+		Description: "Relative Strength Index (0 to 100)",
+		Params:      []ParamSpec{periodParam(14)},
+		Outputs:     []string{"value"},
+		Stream:      StreamRSI,
+		Warmup:      periodPlus(1),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			result := CalculateRSI(k, p.Int("period"))
+			if result == nil {
+				return nil
+			}
+			return single(result.Values)
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "MACD",
+		Description: "Moving Average Convergence Divergence with signal line and histogram",
+		Params: []ParamSpec{
+			{Name: "shortPeriod", Type: ParamInt, Default: 12, Min: 1, Max: 500, Description: "Fast EMA period"},
+			{Name: "longPeriod", Type: ParamInt, Default: 26, Min: 1, Max: 500, Description: "Slow EMA period"},
+			{Name: "signalPeriod", Type: ParamInt, Default: 9, Min: 1, Max: 500, Description: "Signal line EMA period"},
+		},
+		Outputs: []string{"macd", "signal", "histogram"},
+		Stream:  StreamMACD,
+		Warmup: func(p IndicatorParams) int {
+			return p.Int("longPeriod") + p.Int("signalPeriod") - 1
+		},
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			result := CalculateMACD(k, p.Int("shortPeriod"), p.Int("longPeriod"), p.Int("signalPeriod"))
+			if result == nil {
+				return nil
+			}
+			return IndicatorOutput{"macd": result.MACD, "signal": result.Signal, "histogram": result.Histogram}
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "BollingerBands",
+		Aliases:     []string{"BB"},
+		Description: "SMA middle band with bands stdDev standard deviations away",
+		Params: []ParamSpec{
+			periodParam(20),
+			{Name: "stdDev", Type: ParamFloat, Default: 2, Min: 0.1, Max: 10, Description: "Band width in standard deviations"},
+		},
+		Outputs: []string{"upper", "middle", "lower"},
+		Overlay: true,
+		Warmup:  periodPlus(0),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			result := CalculateBollingerBands(k, p.Int("period"), p["stdDev"])
+			if result == nil {
+				return nil
+			}
+			return IndicatorOutput{"upper": result.Upper, "middle": result.Middle, "lower": result.Lower}
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "VWAP",
+		Description: "Volume Weighted Average Price, anchored at the first kline",
+		Params:      []ParamSpec{},
+		Outputs:     []string{"value"},
+		Overlay:     true,
+		Warmup:      func(IndicatorParams) int { return 1 },
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			series := make([]float64, len(k))
+			var tpv, volume float64
+			for i, kline := range k {
+				tpv += (kline.High + kline.Low + kline.Close) / 3 * kline.Volume
+				volume += kline.Volume
+				if volume > 0 {
+					series[i] = tpv / volume
+				}
+			}
+			return single(series)
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "Stochastic",
+		Description: "Stochastic Oscillator %K and %D (0 to 100)",
+		Params: []ParamSpec{
+			{Name: "kPeriod", Type: ParamInt, Default: 14, Min: 1, Max: 500, Description: "%K lookback in bars"},
+			{Name: "dPeriod", Type: ParamInt, Default: 3, Min: 1, Max: 500, Description: "%D smoothing in bars"},
+		},
+		Outputs: []string{"k", "d"},
+		Warmup:  func(p IndicatorParams) int { return p.Int("kPeriod") },
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			kPeriod, dPeriod := p.Int("kPeriod"), p.Int("dPeriod")
+			kSeries := make([]float64, len(k))
+			dSeries := make([]float64, len(k))
+			for i := kPeriod - 1; i < len(k); i++ {
+				if result := CalculateStochastic(k[:i+1], kPeriod, dPeriod); result != nil {
+					kSeries[i], dSeries[i] = result.K, result.D
+				}
+			}
+			return IndicatorOutput{"k": kSeries, "d": dSeries}
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "ATR",
+		Description: "Average True Range with Wilder's smoothing",
+		Params:      []ParamSpec{periodParam(14)},
+		Outputs:     []string{"value"},
+		Stream:      StreamATR,
+		Warmup:      periodPlus(1),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return single(CalculateATRSeries(k, p.Int("period")))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "CCI",
+		Description: "Commodity Channel Index",
+		Params:      []ParamSpec{periodParam(20)},
+		Outputs:     []string{"value"},
+		Warmup:      periodPlus(0),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return single(CalculateCCISeries(k, p.Int("period")))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "WilliamsR",
+		Aliases:     []string{"Williams%R", "WILLR"},
+		Description: "Williams %R (-100 to 0)",
+		Params:      []ParamSpec{periodParam(14)},
+		Outputs:     []string{"value"},
+		Warmup:      periodPlus(0),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return single(CalculateWilliamsRSeries(k, p.Int("period")))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "ROC",
+		Description: "Rate of Change in percent over period bars",
+		Params:      []ParamSpec{periodParam(12)},
+		Outputs:     []string{"value"},
+		Warmup:      periodPlus(1),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return single(CalculateROCSeries(k, p.Int("period")))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "ADX",
+		Description: "Average Directional Index with the +DI and -DI lines",
+		Params:      []ParamSpec{periodParam(14)},
+		Outputs:     []string{"adx", "plusDI", "minusDI"},
+		Warmup:      func(p IndicatorParams) int { return 2 * p.Int("period") },
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			result := CalculateADX(k, p.Int("period"))
+			if result == nil {
+				return nil
+			}
+			return IndicatorOutput{"adx": result.ADX, "plusDI": result.PlusDI, "minusDI": result.MinusDI}
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "KeltnerChannels",
+		Aliases:     []string{"KC"},
+		Description: "EMA middle line with bands multiplier ATRs away",
+		Params: []ParamSpec{
+			periodParam(20),
+			{Name: "atrPeriod", Type: ParamInt, Default: 10, Min: 1, Max: 500, Description: "ATR lookback in bars"},
+			{Name: "multiplier", Type: ParamFloat, Default: 2, Min: 0.1, Max: 10, Description: "Band width in ATRs"},
+		},
+		Outputs: []string{"upper", "middle", "lower"},
+		Overlay: true,
+		Warmup: func(p IndicatorParams) int {
+			if atr := p.Int("atrPeriod") + 1; atr > p.Int("period") {
+				return atr
+			}
+			return p.Int("period")
+		},
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return channelOutput(CalculateKeltnerChannels(k, p.Int("period"), p.Int("atrPeriod"), p["multiplier"]))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "DonchianChannels",
+		Aliases:     []string{"DC"},
+		Description: "Highest high and lowest low of the last period bars and their midpoint",
+		Params:      []ParamSpec{periodParam(20)},
+		Outputs:     []string{"upper", "middle", "lower"},
+		Overlay:     true,
+		Warmup:      periodPlus(0),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return channelOutput(CalculateDonchianChannels(k, p.Int("period")))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "OBV",
+		Description: "On-Balance Volume",
+		Params:      []ParamSpec{},
+		Outputs:     []string{"value"},
+		Warmup:      func(IndicatorParams) int { return 1 },
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			return single(CalculateOBVSeries(k))
+		},
+	})
+	RegisterIndicator(&IndicatorSpec{
+		Name:        "Aroon",
+		Description: "Aroon Up, Down (0 to 100) and Oscillator (-100 to 100)",
+		Params:      []ParamSpec{periodParam(25)},
+		Outputs:     []string{"up", "down", "oscillator"},
+		Warmup:      periodPlus(1),
+		Calculate: func(k []types.Kline, p IndicatorParams) IndicatorOutput {
+			result := CalculateAroon(k, p.Int("period"))
+			if result == nil {
+				return nil
+			}
+			return IndicatorOutput{"up": result.Up, "down": result.Down, "oscillator": result.Oscillator}
+		},
+	})
+}
+
+// channelOutput wraps a channel as an output
+func channelOutput(result *ChannelResult) IndicatorOutput {
+	if result == nil {
+		return nil
+	}
+	return IndicatorOutput{"upper": result.Upper, "middle": result.Middle, "lower": result.Lower}
+}
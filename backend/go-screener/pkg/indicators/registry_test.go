@@ -0,0 +1,183 @@
+package indicators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// trend returns n klines closing one higher each bar
+func trend(n int) []types.Kline {
+	klines := make([]types.Kline, n)
+	for i := range klines {
+		price := 100 + float64(i)
+		klines[i] = types.Kline{
+			OpenTime:    int64(i) * 60000,
+			CloseTime:   int64(i+1)*60000 - 1,
+			Open:        price - 0.5,
+			High:        price + 1,
+			Low:         price - 1,
+			Close:       price,
+			Volume:      10,
+			BuyVolume:   6,
+			SellVolume:  4,
+			VolumeDelta: 2,
+		}
+	}
+	return klines
+}
+
+func TestLookupIndicator(t *testing.T) {
+	for _, name := range []string{"RSI", "rsi", " BB ", "BollingerBands", "Williams%R", "MA"} {
+		if _, ok := LookupIndicator(name); !ok {
+			t.Errorf("expected %q in the registry", name)
+		}
+	}
+	if _, ok := LookupIndicator("RSI (14)"); ok {
+		t.Error("expected display names not to resolve")
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	spec, _ := LookupIndicator("BollingerBands")
+
+	params, err := spec.ResolveParams(map[string]interface{}{"period": "10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params["period"] != 10 || params["stdDev"] != 2 {
+		t.Errorf("expected period 10 and default stdDev 2, got %v", params)
+	}
+
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		want   string
+	}{
+		{"unknown", map[string]interface{}{"length": 20.0}, "unknown parameter(s) length"},
+		{"not an integer", map[string]interface{}{"period": 10.5}, "must be an integer"},
+		{"below min", map[string]interface{}{"period": 0.0}, "must be between"},
+		{"above max", map[string]interface{}{"stdDev": 50.0}, "must be between"},
+		{"not a number", map[string]interface{}{"period": "ten"}, "not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := spec.ResolveParams(tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateIndicatorConfigs(t *testing.T) {
+	configs := []types.IndicatorConfig{
+		{ID: "rsi", Name: "RSI", Params: map[string]interface{}{"period": 14.0}},
+		{ID: "volume", Name: "Volume"}, // Custom, drawn by series code
+	}
+	if err := ValidateIndicatorConfigs(configs); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	configs = append(configs, types.IndicatorConfig{ID: "macd", Name: "MACD", Params: map[string]interface{}{"fast": 12.0}})
+	err := ValidateIndicatorConfigs(configs)
+	if err == nil || !strings.Contains(err.Error(), `indicator "macd"`) {
+		t.Errorf("expected error for the macd config, got %v", err)
+	}
+}
+
+func TestValidateStoredIndicatorConfigs(t *testing.T) {
+	// Stored traders keep loading with parameters the registry doesn't know
+	configs := []types.IndicatorConfig{
+		{ID: "rsi", Name: "RSI", Params: map[string]interface{}{"period": 14.0, "smoothing": 3.0}},
+		{ID: "macd", Name: "MACD", Params: map[string]interface{}{"fast": 12.0}},
+	}
+	unknown, err := ValidateStoredIndicatorConfigs(configs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(unknown, ",") != "rsi.smoothing,macd.fast" {
+		t.Errorf("unknown = %v, want rsi.smoothing and macd.fast", unknown)
+	}
+	if err := ValidateIndicatorConfigs(configs); err == nil {
+		t.Error("expected strict validation to reject unknown parameters")
+	}
+
+	// Known parameters are still checked
+	configs = append(configs, types.IndicatorConfig{ID: "rsi2", Name: "RSI", Params: map[string]interface{}{"period": -1.0}})
+	if _, err := ValidateStoredIndicatorConfigs(configs); err == nil || !strings.Contains(err.Error(), `indicator "rsi2"`) {
+		t.Errorf("expected error for the rsi2 config, got %v", err)
+	}
+}
+
+func TestIndicatorCatalogWarmup(t *testing.T) {
+	catalog := IndicatorCatalog()
+	if len(catalog) == 0 {
+		t.Fatal("expected a non-empty catalog")
+	}
+
+	for _, info := range catalog {
+		klines := trend(info.Warmup)
+		output, err := info.Compute(klines, info.Defaults())
+		if err != nil {
+			t.Errorf("%s: expected a result with %d klines, got %v", info.Name, info.Warmup, err)
+			continue
+		}
+		for _, name := range info.Outputs {
+			if len(output[name]) != len(klines) {
+				t.Errorf("%s: expected %d values for %s, got %d", info.Name, len(klines), name, len(output[name]))
+			}
+		}
+
+		if info.Warmup > 1 {
+			if _, err := info.Compute(trend(info.Warmup-1), info.Defaults()); err == nil {
+				t.Errorf("%s: expected an error below the warmup", info.Name)
+			}
+		}
+	}
+}
+
+func TestIndicatorChartSeries(t *testing.T) {
+	klines := trend(40)
+	config := types.IndicatorConfig{ID: "bb", Name: "BB", Params: map[string]interface{}{"period": 20.0}}
+
+	points, err := IndicatorChartSeries(config, klines, 150)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 21 {
+		t.Fatalf("expected points from the warmup on (21), got %d", len(points))
+	}
+	first := points[0]
+	if first["x"] != klines[19].CloseTime {
+		t.Errorf("expected first point at the 20th kline, got %v", first["x"])
+	}
+	for _, key := range []string{"y", "y2", "y3"} {
+		if _, ok := first[key]; !ok {
+			t.Errorf("expected %s in the point", key)
+		}
+	}
+
+	points, _ = IndicatorChartSeries(config, klines, 5)
+	if len(points) != 5 || points[4]["x"] != klines[39].CloseTime {
+		t.Errorf("expected the last 5 points, got %d", len(points))
+	}
+}
+
+func TestStreamConfig(t *testing.T) {
+	name, params, ok := StreamConfig(types.IndicatorConfig{Name: "macd", Params: map[string]interface{}{"signalPeriod": "5"}})
+	if !ok || name != StreamMACD {
+		t.Fatalf("expected the MACD stream, got %q %v", name, ok)
+	}
+	if len(params) != 3 || params[0] != 12 || params[1] != 26 || params[2] != 5 {
+		t.Errorf("expected params [12 26 5], got %v", params)
+	}
+
+	if _, _, ok := StreamConfig(types.IndicatorConfig{Name: "WMA"}); ok {
+		t.Error("expected no stream for WMA")
+	}
+	if _, _, ok := StreamConfig(types.IndicatorConfig{Name: "RSI", Params: map[string]interface{}{"period": 0.0}}); ok {
+		t.Error("expected no stream for invalid parameters")
+	}
+}
@@ -0,0 +1,358 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Streaming indicators
+//
+// A Stream keeps the rolling state of one indicator and updates it in O(1) per
+// closed kline, so a new candle doesn't mean recomputing hundreds of klines.
+// Fed the same klines, a stream produces the same values as the batch function
+
+// Indicators available as streams
+const (
+	StreamSMA  = "SMA"  // params: period
+	StreamEMA  = "EMA"  // params: period
+	StreamRSI  = "RSI"  // params: period
+	StreamMACD = "MACD" // params: short, long, signal periods; values: macd, signal, histogram
+	StreamATR  = "ATR"  // params: period
+)
+
+// Stream is an indicator updated one closed kline at a time
+type Stream interface {
+	// Update adds the next closed kline
+	Update(kline types.Kline)
+	// Values returns the latest values, false until enough klines were seen
+	Values() ([]float64, bool)
+}
+
+// NewStream creates the stream of a named indicator
+func NewStream(name string, params []float64) (Stream, error) {
+	periods := make([]int, len(params))
+	for i, p := range params {
+		periods[i] = int(p)
+		if periods[i] <= 0 || float64(periods[i]) != p {
+			return nil, fmt.Errorf("%s: period must be a positive integer, got %v", name, p)
+		}
+	}
+
+	want := 1
+	if name == StreamMACD {
+		want = 3
+	}
+	if len(periods) != want {
+		return nil, fmt.Errorf("%s takes %d parameter(s), got %d", name, want, len(periods))
+	}
+
+	switch name {
+	case StreamSMA:
+		return NewSMAStream(periods[0]), nil
+	case StreamEMA:
+		return NewEMAStream(periods[0]), nil
+	case StreamRSI:
+		return NewRSIStream(periods[0]), nil
+	case StreamMACD:
+		return NewMACDStream(periods[0], periods[1], periods[2]), nil
+	case StreamATR:
+		return NewATRStream(periods[0]), nil
+	default:
+		return nil, fmt.Errorf("indicator %s is not available as a stream", name)
+	}
+}
+
+// StreamKey identifies an indicator and its parameters, e.g. "MACD(12,26,9)"
+func StreamKey(name string, params ...float64) string {
+	formatted := make([]string, len(params))
+	for i, p := range params {
+		formatted[i] = strconv.FormatFloat(p, 'f', -1, 64)
+	}
+	return name + "(" + strings.Join(formatted, ",") + ")"
+}
+
+// StreamConfig returns the stream name and parameters of a chart indicator config
+// Returns false for indicators that aren't available as streams or have invalid parameters
+func StreamConfig(config types.IndicatorConfig) (string, []float64, bool) {
+	spec, ok := LookupIndicator(config.Name)
+	if !ok || spec.Stream == "" {
+		return "", nil, false
+	}
+	params, err := spec.ResolveKnownParams(config.Params)
+	if err != nil {
+		return "", nil, false
+	}
+	return spec.Stream, spec.StreamParams(params), true
+}
+
+// Streamed returns the streamed values of an indicator for the market data
+// They are only returned if the stream has seen exactly the klines the data has,
+// up to the latest one; otherwise callers fall back to batch computation
+func Streamed(data *types.MarketData, interval, name string, params ...float64) ([]float64, bool) {
+	if data == nil || data.Indicators == nil {
+		return nil, false
+	}
+	klines := data.Klines[interval]
+	if len(klines) == 0 {
+		return nil, false
+	}
+
+	values, openTime, ok := data.Indicators.Indicator(interval, name, params...)
+	if !ok || openTime != klines[len(klines)-1].OpenTime {
+		return nil, false
+	}
+	return values, true
+}
+
+// LatestSMA returns the latest SMA of an interval, streamed if available
+func LatestSMA(data *types.MarketData, interval string, period int) *float64 {
+	if values, ok := Streamed(data, interval, StreamSMA, float64(period)); ok {
+		return &values[0]
+	}
+	return CalculateMA(data.Klines[interval], period)
+}
+
+// LatestEMA returns the latest EMA of an interval, streamed if available
+func LatestEMA(data *types.MarketData, interval string, period int) *float64 {
+	if values, ok := Streamed(data, interval, StreamEMA, float64(period)); ok {
+		return &values[0]
+	}
+	return CalculateEMA(data.Klines[interval], period)
+}
+
+// LatestRSI returns the latest RSI of an interval, streamed if available
+func LatestRSI(data *types.MarketData, interval string, period int) *float64 {
+	if values, ok := Streamed(data, interval, StreamRSI, float64(period)); ok {
+		return &values[0]
+	}
+	return GetLatestRSI(data.Klines[interval], period)
+}
+
+// LatestATR returns the latest ATR of an interval, streamed if available
+func LatestATR(data *types.MarketData, interval string, period int) *float64 {
+	if values, ok := Streamed(data, interval, StreamATR, float64(period)); ok {
+		return &values[0]
+	}
+	return CalculateATR(data.Klines[interval], period)
+}
+
+// LatestMACD returns the latest MACD values of an interval, streamed if available
+func LatestMACD(data *types.MarketData, interval string, shortPeriod, longPeriod, signalPeriod int) *struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+} {
+	values, ok := Streamed(data, interval, StreamMACD, float64(shortPeriod), float64(longPeriod), float64(signalPeriod))
+	if !ok {
+		return GetLatestMACD(data.Klines[interval], shortPeriod, longPeriod, signalPeriod)
+	}
+	return &struct {
+		MACD      float64
+		Signal    float64
+		Histogram float64
+	}{
+		MACD:      values[0],
+		Signal:    values[1],
+		Histogram: values[2],
+	}
+}
+
+// SMAStream is a streaming Simple Moving Average
+type SMAStream struct {
+	period int
+	window []float64 // ring buffer of the last period closes
+	next   int
+	count  int
+	sum    float64
+}
+
+// NewSMAStream creates a streaming SMA
+func NewSMAStream(period int) *SMAStream {
+	return &SMAStream{period: period, window: make([]float64, period)}
+}
+
+// Update adds the next closed kline
+func (s *SMAStream) Update(kline types.Kline) {
+	s.sum += kline.Close - s.window[s.next]
+	s.window[s.next] = kline.Close
+	s.next = (s.next + 1) % s.period
+	s.count++
+}
+
+// Values returns the latest SMA
+func (s *SMAStream) Values() ([]float64, bool) {
+	if s.count < s.period {
+		return nil, false
+	}
+	return []float64{s.sum / float64(s.period)}, true
+}
+
+// EMAStream is a streaming Exponential Moving Average, seeded like CalculateEMA
+type EMAStream struct {
+	period int
+	k      float64
+	value  float64
+	count  int
+}
+
+// NewEMAStream creates a streaming EMA
+func NewEMAStream(period int) *EMAStream {
+	return &EMAStream{period: period, k: 2.0 / float64(period+1)}
+}
+
+// Update adds the next closed kline
+func (s *EMAStream) Update(kline types.Kline) {
+	if s.count == 0 {
+		s.value = kline.Close
+	} else {
+		s.value = kline.Close*s.k + s.value*(1-s.k)
+	}
+	s.count++
+}
+
+// Values returns the latest EMA
+func (s *EMAStream) Values() ([]float64, bool) {
+	if s.count < s.period {
+		return nil, false
+	}
+	return []float64{s.value}, true
+}
+
+// RSIStream is a streaming Relative Strength Index with Wilder's smoothing
+type RSIStream struct {
+	period    int
+	prevClose float64
+	avgGain   float64
+	avgLoss   float64
+	count     int // klines seen
+}
+
+// NewRSIStream creates a streaming RSI
+func NewRSIStream(period int) *RSIStream {
+	return &RSIStream{period: period}
+}
+
+// Update adds the next closed kline
+func (s *RSIStream) Update(kline types.Kline) {
+	if s.count > 0 {
+		change := kline.Close - s.prevClose
+		gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+		if s.count <= s.period {
+			// The first average is a plain mean of period changes
+			s.avgGain += gain / float64(s.period)
+			s.avgLoss += loss / float64(s.period)
+		} else {
+			s.avgGain = (s.avgGain*float64(s.period-1) + gain) / float64(s.period)
+			s.avgLoss = (s.avgLoss*float64(s.period-1) + loss) / float64(s.period)
+		}
+	}
+	s.prevClose = kline.Close
+	s.count++
+}
+
+// Values returns the latest RSI
+func (s *RSIStream) Values() ([]float64, bool) {
+	if s.count < s.period+1 {
+		return nil, false
+	}
+	if s.avgLoss == 0 {
+		if s.avgGain > 0 {
+			return []float64{100}, true
+		}
+		return []float64{50}, true
+	}
+	return []float64{100 - 100/(1+s.avgGain/s.avgLoss)}, true
+}
+
+// MACDStream is a streaming MACD, seeded like CalculateMACD
+type MACDStream struct {
+	long         int
+	short        *EMAStream
+	longEMA      *EMAStream
+	signalPeriod int
+	signalK      float64
+	signal       float64
+	macd         float64
+	count        int
+}
+
+// NewMACDStream creates a streaming MACD
+func NewMACDStream(shortPeriod, longPeriod, signalPeriod int) *MACDStream {
+	return &MACDStream{
+		long:         longPeriod,
+		short:        NewEMAStream(shortPeriod),
+		longEMA:      NewEMAStream(longPeriod),
+		signalPeriod: signalPeriod,
+		signalK:      2.0 / float64(signalPeriod+1),
+	}
+}
+
+// Update adds the next closed kline
+func (s *MACDStream) Update(kline types.Kline) {
+	s.short.Update(kline)
+	s.longEMA.Update(kline)
+	s.macd = s.short.value - s.longEMA.value
+	s.count++
+
+	// The signal line starts as the mean of the first signalPeriod MACD values
+	switch {
+	case s.count < s.signalPeriod:
+		s.signal += s.macd
+	case s.count == s.signalPeriod:
+		s.signal = (s.signal + s.macd) / float64(s.signalPeriod)
+	default:
+		s.signal = s.macd*s.signalK + s.signal*(1-s.signalK)
+	}
+}
+
+// Values returns the latest MACD, signal and histogram
+func (s *MACDStream) Values() ([]float64, bool) {
+	if s.count < s.long {
+		return nil, false
+	}
+	signal := s.signal
+	if s.count < s.signalPeriod {
+		signal = 0 // Not enough MACD values for a signal line yet
+	}
+	return []float64{s.macd, signal, s.macd - signal}, true
+}
+
+// ATRStream is a streaming Average True Range with Wilder's smoothing
+type ATRStream struct {
+	period    int
+	prevClose float64
+	value     float64
+	count     int // klines seen
+}
+
+// NewATRStream creates a streaming ATR
+func NewATRStream(period int) *ATRStream {
+	return &ATRStream{period: period}
+}
+
+// Update adds the next closed kline
+func (s *ATRStream) Update(kline types.Kline) {
+	if s.count > 0 {
+		tr := math.Max(kline.High-kline.Low, math.Max(math.Abs(kline.High-s.prevClose), math.Abs(kline.Low-s.prevClose)))
+		if s.count <= s.period {
+			s.value += tr / float64(s.period)
+		} else {
+			s.value = (s.value*float64(s.period-1) + tr) / float64(s.period)
+		}
+	}
+	s.prevClose = kline.Close
+	s.count++
+}
+
+// Values returns the latest ATR
+func (s *ATRStream) Values() ([]float64, bool) {
+	if s.count < s.period+1 {
+		return nil, false
+	}
+	return []float64{s.value}, true
+}
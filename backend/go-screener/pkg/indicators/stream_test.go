@@ -0,0 +1,118 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// createWaveKlines returns klines whose closes oscillate, so gains and losses both occur
+func createWaveKlines(count int) []types.Kline {
+	klines := make([]types.Kline, count)
+	for i := range klines {
+		price := 100 + 10*math.Sin(float64(i)/5) + float64(i)*0.1
+		klines[i] = types.Kline{
+			OpenTime: int64(i * 60000),
+			Open:     price - 0.3,
+			High:     price + 1,
+			Low:      price - 1.2,
+			Close:    price,
+			Volume:   1000,
+		}
+	}
+	return klines
+}
+
+func TestStreams_MatchBatch(t *testing.T) {
+	klines := createWaveKlines(300)
+
+	batch := map[string]float64{
+		"SMA":  *CalculateMA(klines, 20),
+		"EMA":  *CalculateEMA(klines, 20),
+		"RSI":  *GetLatestRSI(klines, 14),
+		"ATR":  *CalculateATR(klines, 14),
+		"MACD": GetLatestMACD(klines, 12, 26, 9).Signal,
+	}
+	streams := map[string]Stream{
+		"SMA":  NewSMAStream(20),
+		"EMA":  NewEMAStream(20),
+		"RSI":  NewRSIStream(14),
+		"ATR":  NewATRStream(14),
+		"MACD": NewMACDStream(12, 26, 9),
+	}
+
+	for name, stream := range streams {
+		for _, kline := range klines {
+			stream.Update(kline)
+		}
+		values, ok := stream.Values()
+		if !ok {
+			t.Errorf("%s stream not ready after %d klines", name, len(klines))
+			continue
+		}
+
+		got := values[0]
+		if name == "MACD" {
+			got = values[1]
+		}
+		if math.Abs(got-batch[name]) > 1e-9 {
+			t.Errorf("%s stream = %v, batch = %v", name, got, batch[name])
+		}
+	}
+}
+
+func TestStreams_Warmup(t *testing.T) {
+	klines := createWaveKlines(15)
+
+	rsi := NewRSIStream(14)
+	for _, kline := range klines[:14] {
+		rsi.Update(kline)
+	}
+	if _, ok := rsi.Values(); ok {
+		t.Error("RSI(14) should need 15 klines")
+	}
+	rsi.Update(klines[14])
+	if _, ok := rsi.Values(); !ok {
+		t.Error("RSI(14) should be ready after 15 klines")
+	}
+
+	if _, err := NewStream(StreamMACD, []float64{12, 26}); err == nil {
+		t.Error("MACD with two params should be rejected")
+	}
+	if _, err := NewStream("VWAP", []float64{1}); err == nil {
+		t.Error("indicators without a stream should be rejected")
+	}
+}
+
+// fixedSource serves the same values for every indicator
+type fixedSource struct {
+	values   []float64
+	openTime int64
+}
+
+func (s fixedSource) Indicator(interval, name string, params ...float64) ([]float64, int64, bool) {
+	return s.values, s.openTime, true
+}
+
+func TestLatestEMA_FallsBackToBatch(t *testing.T) {
+	klines := createWaveKlines(50)
+	data := &types.MarketData{Klines: map[string][]types.Kline{"1m": klines}}
+	batch := *CalculateEMA(klines, 20)
+
+	if got := LatestEMA(data, "1m", 20); got == nil || *got != batch {
+		t.Errorf("without a source LatestEMA = %v, want %v", got, batch)
+	}
+
+	// Streamed values are used when they include the latest kline
+	data.Indicators = fixedSource{values: []float64{42}, openTime: klines[49].OpenTime}
+	if got := LatestEMA(data, "1m", 20); got == nil || *got != 42 {
+		t.Errorf("with a current stream LatestEMA = %v, want 42", got)
+	}
+
+	// A stream that is a candle behind is ignored
+	data.Indicators = fixedSource{values: []float64{42}, openTime: klines[48].OpenTime}
+	if got := LatestEMA(data, "1m", 20); got == nil || *got != batch {
+		t.Errorf("with a stale stream LatestEMA = %v, want %v", got, batch)
+	}
+}
@@ -0,0 +1,250 @@
+package indicators
+
+import (
+	"math"
+	"sort"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Market structure
+//
+// Swing points are pivots confirmed by the bars on both sides, so the last right
+// bars of a window can never hold one. Support/resistance zones cluster swing
+// points at similar prices, and the volume profile spreads each kline's volume
+// over the prices it traded at
+
+// SwingPoint is a swing high or swing low
+type SwingPoint struct {
+	Index    int     `json:"index"`
+	Price    float64 `json:"price"` // High of a swing high, low of a swing low
+	High     bool    `json:"high"`  // true for swing highs, false for swing lows
+	OpenTime int64   `json:"openTime"`
+}
+
+// FindSwingHighs finds the klines whose high is above the left bars before it
+// and not below the right bars after it
+func FindSwingHighs(klines []types.Kline, left, right int) []SwingPoint {
+	return findSwings(klines, left, right, true)
+}
+
+// FindSwingLows finds the klines whose low is below the left bars before it
+// and not above the right bars after it
+func FindSwingLows(klines []types.Kline, left, right int) []SwingPoint {
+	return findSwings(klines, left, right, false)
+}
+
+// FindSwingPoints finds swing highs and lows, ordered by index
+func FindSwingPoints(klines []types.Kline, left, right int) []SwingPoint {
+	points := append(FindSwingHighs(klines, left, right), FindSwingLows(klines, left, right)...)
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Index < points[j].Index })
+	return points
+}
+
+func findSwings(klines []types.Kline, left, right int, high bool) []SwingPoint {
+	if left < 1 || right < 1 {
+		return nil
+	}
+
+	price := func(i int) float64 {
+		if high {
+			return klines[i].High
+		}
+		return -klines[i].Low
+	}
+
+	var points []SwingPoint
+	for i := left; i < len(klines)-right; i++ {
+		p := price(i)
+		pivot := true
+		for j := i - left; j < i && pivot; j++ {
+			pivot = price(j) < p
+		}
+		for j := i + 1; j <= i+right && pivot; j++ {
+			pivot = price(j) <= p
+		}
+		if pivot {
+			points = append(points, SwingPoint{
+				Index:    i,
+				Price:    math.Abs(p),
+				High:     high,
+				OpenTime: klines[i].OpenTime,
+			})
+		}
+	}
+	return points
+}
+
+// Level types
+const (
+	LevelSupport    = "support"
+	LevelResistance = "resistance"
+)
+
+// PriceLevel is a support or resistance zone built from swing points at similar prices
+type PriceLevel struct {
+	Price     float64 `json:"price"` // Average price of the swing points
+	Low       float64 `json:"low"`   // Zone bounds
+	High      float64 `json:"high"`
+	Touches   int     `json:"touches"`   // Number of swing points in the zone
+	LastIndex int     `json:"lastIndex"` // Index of the most recent swing point
+	Type      string  `json:"type"`      // support below the last close, resistance above it
+}
+
+// FindSupportResistance clusters swing points into price zones, ordered by price
+// Swing points join a zone while within tolerance (a fraction of price, e.g. 0.005)
+// of its average
+func FindSupportResistance(klines []types.Kline, left, right int, tolerance float64) []PriceLevel {
+	points := FindSwingPoints(klines, left, right)
+	if len(points) == 0 {
+		return nil
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Price < points[j].Price })
+
+	var levels []PriceLevel
+	var sum float64
+	for _, point := range points {
+		if n := len(levels); n > 0 && point.Price-levels[n-1].Price <= tolerance*levels[n-1].Price {
+			level := &levels[n-1]
+			sum += point.Price
+			level.Touches++
+			level.Price = sum / float64(level.Touches)
+			level.High = point.Price
+			if point.Index > level.LastIndex {
+				level.LastIndex = point.Index
+			}
+			continue
+		}
+
+		sum = point.Price
+		levels = append(levels, PriceLevel{
+			Price:     point.Price,
+			Low:       point.Price,
+			High:      point.Price,
+			Touches:   1,
+			LastIndex: point.Index,
+		})
+	}
+
+	last := klines[len(klines)-1].Close
+	for i := range levels {
+		levels[i].Type = LevelResistance
+		if levels[i].Price < last {
+			levels[i].Type = LevelSupport
+		}
+	}
+	return levels
+}
+
+// NearestSupport returns the highest level below price, or nil if there is none
+func NearestSupport(levels []PriceLevel, price float64) *PriceLevel {
+	var nearest *PriceLevel
+	for i := range levels {
+		if levels[i].Price < price && (nearest == nil || levels[i].Price > nearest.Price) {
+			nearest = &levels[i]
+		}
+	}
+	return nearest
+}
+
+// NearestResistance returns the lowest level above price, or nil if there is none
+func NearestResistance(levels []PriceLevel, price float64) *PriceLevel {
+	var nearest *PriceLevel
+	for i := range levels {
+		if levels[i].Price > price && (nearest == nil || levels[i].Price < nearest.Price) {
+			nearest = &levels[i]
+		}
+	}
+	return nearest
+}
+
+// VolumeBin is the volume traded within a price range
+type VolumeBin struct {
+	Low    float64 `json:"low"`
+	High   float64 `json:"high"`
+	Volume float64 `json:"volume"`
+}
+
+// VolumeProfile contains volume profile results
+type VolumeProfile struct {
+	POC           float64     `json:"poc"`           // Point of control: middle of the bin with the most volume
+	ValueAreaHigh float64     `json:"valueAreaHigh"` // Top of the value area
+	ValueAreaLow  float64     `json:"valueAreaLow"`  // Bottom of the value area
+	Bins          []VolumeBin `json:"bins"`          // Ordered from the lowest price
+}
+
+// CalculateVolumeProfile calculates the volume profile of the klines over bins price ranges
+// Each kline's volume is spread evenly over its high-low range. The value area is
+// the smallest range around the POC holding valueArea (e.g. 0.7) of the volume
+func CalculateVolumeProfile(klines []types.Kline, bins int, valueArea float64) *VolumeProfile {
+	if len(klines) == 0 || bins < 1 {
+		return nil
+	}
+
+	high, low := windowRange(klines, len(klines)-1, len(klines))
+	if high <= low {
+		return nil
+	}
+	step := (high - low) / float64(bins)
+
+	profile := &VolumeProfile{Bins: make([]VolumeBin, bins)}
+	for b := range profile.Bins {
+		profile.Bins[b].Low = low + float64(b)*step
+		profile.Bins[b].High = low + float64(b+1)*step
+	}
+
+	binOf := func(price float64) int {
+		b := int((price - low) / step)
+		if b >= bins {
+			b = bins - 1
+		}
+		return b
+	}
+
+	var total float64
+	for _, k := range klines {
+		total += k.Volume
+		if k.High <= k.Low {
+			profile.Bins[binOf(k.Close)].Volume += k.Volume
+			continue
+		}
+		for b := binOf(k.Low); b <= binOf(k.High); b++ {
+			overlap := math.Min(k.High, profile.Bins[b].High) - math.Max(k.Low, profile.Bins[b].Low)
+			if overlap > 0 {
+				profile.Bins[b].Volume += k.Volume * overlap / (k.High - k.Low)
+			}
+		}
+	}
+
+	poc := 0
+	for b, bin := range profile.Bins {
+		if bin.Volume > profile.Bins[poc].Volume {
+			poc = b
+		}
+	}
+	profile.POC = (profile.Bins[poc].Low + profile.Bins[poc].High) / 2
+
+	// Grow the value area from the POC towards the busier neighbouring bin
+	lo, hi := poc, poc
+	inArea := profile.Bins[poc].Volume
+	for inArea < valueArea*total && (lo > 0 || hi < bins-1) {
+		below, above := -1.0, -1.0
+		if lo > 0 {
+			below = profile.Bins[lo-1].Volume
+		}
+		if hi < bins-1 {
+			above = profile.Bins[hi+1].Volume
+		}
+		if above >= below {
+			hi++
+			inArea += above
+		} else {
+			lo--
+			inArea += below
+		}
+	}
+	profile.ValueAreaLow = profile.Bins[lo].Low
+	profile.ValueAreaHigh = profile.Bins[hi].High
+
+	return profile
+}
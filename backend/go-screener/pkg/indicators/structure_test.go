@@ -0,0 +1,114 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// zigzag returns klines whose highs and lows follow the given close prices, 1 either side
+func zigzag(closes ...float64) []types.Kline {
+	klines := make([]types.Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = types.Kline{OpenTime: int64(i) * 60000, Open: c, High: c + 1, Low: c - 1, Close: c, Volume: 10}
+	}
+	return klines
+}
+
+func TestFindSwingPoints(t *testing.T) {
+	klines := zigzag(100, 102, 105, 103, 101, 98, 100, 104, 106, 103, 101)
+
+	highs := FindSwingHighs(klines, 2, 2)
+	if len(highs) != 2 || highs[0].Index != 2 || highs[1].Index != 8 {
+		t.Fatalf("unexpected swing highs %+v", highs)
+	}
+	if highs[0].Price != 106 || !highs[0].High || highs[0].OpenTime != 120000 {
+		t.Errorf("unexpected swing high %+v", highs[0])
+	}
+
+	lows := FindSwingLows(klines, 2, 2)
+	if len(lows) != 1 || lows[0].Index != 5 || lows[0].Price != 97 || lows[0].High {
+		t.Fatalf("unexpected swing lows %+v", lows)
+	}
+
+	points := FindSwingPoints(klines, 2, 2)
+	if len(points) != 3 || points[0].Index != 2 || points[1].Index != 5 || points[2].Index != 8 {
+		t.Errorf("expected points ordered by index, got %+v", points)
+	}
+
+	// The last right bars cannot be confirmed
+	if got := FindSwingHighs(zigzag(100, 101, 102, 103, 104), 2, 2); len(got) != 0 {
+		t.Errorf("expected no swing highs in a rising window, got %+v", got)
+	}
+	if FindSwingHighs(klines, 0, 2) != nil {
+		t.Error("expected nil for left < 1")
+	}
+}
+
+func TestFindSupportResistance(t *testing.T) {
+	// Two highs near 110, two lows near 95, closing in between
+	klines := zigzag(100, 105, 109, 104, 100, 96, 99, 103, 109.2, 104, 99, 96.2, 100, 102, 101)
+
+	levels := FindSupportResistance(klines, 2, 2, 0.005)
+	if len(levels) != 2 {
+		t.Fatalf("expected 2 levels, got %+v", levels)
+	}
+
+	support, resistance := levels[0], levels[1]
+	if support.Type != LevelSupport || support.Touches != 2 || support.LastIndex != 11 {
+		t.Errorf("unexpected support %+v", support)
+	}
+	if math.Abs(support.Price-95.1) > 1e-9 || support.Low != 95 || support.High != 95.2 {
+		t.Errorf("unexpected support zone %+v", support)
+	}
+	if resistance.Type != LevelResistance || resistance.Touches != 2 || math.Abs(resistance.Price-110.1) > 1e-9 {
+		t.Errorf("unexpected resistance %+v", resistance)
+	}
+
+	if got := NearestSupport(levels, 101); got == nil || got.Price != support.Price {
+		t.Errorf("expected nearest support %.2f, got %+v", support.Price, got)
+	}
+	if got := NearestResistance(levels, 101); got == nil || got.Price != resistance.Price {
+		t.Errorf("expected nearest resistance %.2f, got %+v", resistance.Price, got)
+	}
+	if NearestResistance(levels, 120) != nil {
+		t.Error("expected no resistance above every level")
+	}
+}
+
+func TestCalculateVolumeProfile(t *testing.T) {
+	klines := []types.Kline{
+		{High: 110, Low: 100, Volume: 100},
+		{High: 104, Low: 102, Volume: 300},
+		{High: 103, Low: 103, Close: 103, Volume: 50}, // Flat: all volume in one bin
+	}
+
+	profile := CalculateVolumeProfile(klines, 5, 0.7)
+	if profile == nil || len(profile.Bins) != 5 {
+		t.Fatalf("expected 5 bins, got %+v", profile)
+	}
+
+	var total float64
+	for _, bin := range profile.Bins {
+		total += bin.Volume
+	}
+	if math.Abs(total-450) > 1e-9 {
+		t.Errorf("expected profile to hold all 450 volume, got %.4f", total)
+	}
+
+	// Bin 102-104 holds 20 + 300 + 50
+	if profile.POC != 103 {
+		t.Errorf("expected POC 103, got %.4f", profile.POC)
+	}
+	if math.Abs(profile.Bins[1].Volume-370) > 1e-9 {
+		t.Errorf("expected 370 in the POC bin, got %.4f", profile.Bins[1].Volume)
+	}
+	if profile.ValueAreaLow != 102 || profile.ValueAreaHigh != 104 {
+		t.Errorf("expected value area 102-104, got %.4f-%.4f", profile.ValueAreaLow, profile.ValueAreaHigh)
+	}
+
+	if CalculateVolumeProfile(nil, 5, 0.7) != nil || CalculateVolumeProfile(klines, 0, 0.7) != nil {
+		t.Error("expected nil for empty klines or no bins")
+	}
+}
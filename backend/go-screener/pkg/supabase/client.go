@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/vyx/go-screener/pkg/types"
@@ -419,3 +420,98 @@ func (c *Client) GetUserFlyAppID(ctx context.Context, userID string) (*string, e
 
 	return &apps[0].ID, nil
 }
+
+// LoadSymbolStates fetches the persisted filter state of a trader
+func (c *Client) LoadSymbolStates(ctx context.Context, traderID string) ([]types.SymbolStateRecord, error) {
+	endpoint := fmt.Sprintf("%s/rest/v1/trader_symbol_state?trader_id=eq.%s&select=trader_id,symbol,state", c.baseURL, url.QueryEscape(traderID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("supabase API error: %s - %s", resp.Status, string(body))
+	}
+
+	var records []types.SymbolStateRecord
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return records, nil
+}
+
+// SaveSymbolStates upserts filter state records in a single request
+func (c *Client) SaveSymbolStates(ctx context.Context, records []types.SymbolStateRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	url := fmt.Sprintf("%s/rest/v1/trader_symbol_state?on_conflict=trader_id,symbol", c.baseURL)
+
+	payload, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal filter state: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.setHeaders(req)
+	req.Header.Set("Prefer", "resolution=merge-duplicates,return=minimal")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("supabase API error: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+// DeleteSymbolStates deletes a trader's persisted filter state for one symbol,
+// or for all symbols if symbol is empty
+func (c *Client) DeleteSymbolStates(ctx context.Context, traderID, symbol string) error {
+	endpoint := fmt.Sprintf("%s/rest/v1/trader_symbol_state?trader_id=eq.%s", c.baseURL, url.QueryEscape(traderID))
+	if symbol != "" {
+		endpoint += "&symbol=eq." + url.QueryEscape(symbol)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.setHeaders(req)
+	req.Header.Set("Prefer", "return=minimal")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("supabase API error: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ParseKline converts a raw Binance kline to our Kline type
+// Format: [openTime, open, high, low, close, volume, closeTime, quoteAssetVolume, numberOfTrades, takerBuyBaseAssetVolume, takerBuyQuoteAssetVolume, ignore]
+// The trailing ignore field is optional, so klines rebuilt from WebSocket events parse too
+func ParseKline(raw []interface{}) (Kline, error) {
+	if len(raw) < 11 {
+		return Kline{}, fmt.Errorf("invalid kline data: expected at least 11 fields, got %d", len(raw))
+	}
+
+	parseFloat := func(v interface{}) (float64, error) {
+		switch val := v.(type) {
+		case float64:
+			return val, nil
+		case string:
+			return strconv.ParseFloat(val, 64)
+		default:
+			return 0, fmt.Errorf("cannot parse float from %T", v)
+		}
+	}
+
+	parseInt := func(v interface{}) (int64, error) {
+		switch val := v.(type) {
+		case float64:
+			return int64(val), nil
+		case int64:
+			return val, nil
+		case string:
+			return strconv.ParseInt(val, 10, 64)
+		default:
+			return 0, fmt.Errorf("cannot parse int from %T", v)
+		}
+	}
+
+	openTime, err := parseInt(raw[0])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse openTime: %w", err)
+	}
+
+	open, err := parseFloat(raw[1])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse open: %w", err)
+	}
+
+	high, err := parseFloat(raw[2])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse high: %w", err)
+	}
+
+	low, err := parseFloat(raw[3])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse low: %w", err)
+	}
+
+	close, err := parseFloat(raw[4])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse close: %w", err)
+	}
+
+	volume, err := parseFloat(raw[5])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse volume: %w", err)
+	}
+
+	closeTime, err := parseInt(raw[6])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse closeTime: %w", err)
+	}
+
+	quoteAssetVolume, err := parseFloat(raw[7])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse quoteAssetVolume: %w", err)
+	}
+
+	numberOfTrades, err := parseInt(raw[8])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse numberOfTrades: %w", err)
+	}
+
+	takerBuyBaseAssetVolume, err := parseFloat(raw[9])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse takerBuyBaseAssetVolume: %w", err)
+	}
+
+	takerBuyQuoteAssetVolume, err := parseFloat(raw[10])
+	if err != nil {
+		return Kline{}, fmt.Errorf("failed to parse takerBuyQuoteAssetVolume: %w", err)
+	}
+
+	// Compute volume enrichment fields
+	buyVolume := takerBuyBaseAssetVolume
+	sellVolume := volume - buyVolume
+	volumeDelta := buyVolume - sellVolume
+
+	return Kline{
+		OpenTime:    openTime,
+		Open:        open,
+		High:        high,
+		Low:         low,
+		Close:       close,
+		Volume:      volume,
+		BuyVolume:   buyVolume,
+		SellVolume:  sellVolume,
+		VolumeDelta: volumeDelta,
+		QuoteVolume: quoteAssetVolume,
+		Trades:      int(numberOfTrades),
+		CloseTime:   closeTime,
+
+		// Legacy fields (internal use only)
+		TakerBuyBaseAssetVolume:  takerBuyBaseAssetVolume,
+		TakerBuyQuoteAssetVolume: takerBuyQuoteAssetVolume,
+		NumberOfTrades:           int(numberOfTrades),
+	}, nil
+}
+
+// ParseKlines converts raw Binance klines to our Kline type
+func ParseKlines(raw [][]interface{}) ([]Kline, error) {
+	klines := make([]Kline, 0, len(raw))
+	for i, r := range raw {
+		kline, err := ParseKline(r)
+		if err != nil {
+			return nil, fmt.Errorf("kline %d: %w", i, err)
+		}
+		klines = append(klines, kline)
+	}
+	return klines, nil
+}
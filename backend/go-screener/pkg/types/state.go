@@ -0,0 +1,229 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Default size limits of a filter's state for one symbol
+const (
+	DefaultStateMaxKeys  = 64
+	DefaultStateMaxBytes = 16 << 10 // 16 KB of JSON
+)
+
+// ErrStateLimit is returned when a write would take a symbol's state over its limits
+var ErrStateLimit = errors.New("filter state limit exceeded")
+
+// SymbolState is a filter's key/value state for one symbol
+// It survives between candle runs, so filters can remember things like a recent
+// RSI cross or a breakout they already fired on. Values are stored as JSON, so
+// they read back the same whether or not the state was persisted in between:
+// numbers come back as float64, structs as map[string]interface{}
+// A nil *SymbolState reads as empty and rejects writes
+type SymbolState struct {
+	mu       sync.Mutex
+	values   map[string]json.RawMessage
+	size     int // bytes of keys and JSON values
+	maxKeys  int
+	maxBytes int
+	dirty    bool // changed since the last TakeDirty
+}
+
+// NewSymbolState creates an empty state with the given limits (zero means the default)
+func NewSymbolState(maxKeys, maxBytes int) *SymbolState {
+	if maxKeys <= 0 {
+		maxKeys = DefaultStateMaxKeys
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultStateMaxBytes
+	}
+	return &SymbolState{
+		values:   make(map[string]json.RawMessage),
+		maxKeys:  maxKeys,
+		maxBytes: maxBytes,
+	}
+}
+
+// Get returns the value stored under key, or nil
+func (s *SymbolState) Get(key string) interface{} {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	raw, ok := s.values[key]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil
+	}
+	return value
+}
+
+// GetFloat returns a numeric value, or 0 if key is missing or not a number
+func (s *SymbolState) GetFloat(key string) float64 {
+	value, _ := s.Get(key).(float64)
+	return value
+}
+
+// GetBool returns a boolean value, or false if key is missing or not a bool
+func (s *SymbolState) GetBool(key string) bool {
+	value, _ := s.Get(key).(bool)
+	return value
+}
+
+// GetString returns a string value, or "" if key is missing or not a string
+func (s *SymbolState) GetString(key string) string {
+	value, _ := s.Get(key).(string)
+	return value
+}
+
+// Has reports whether a value is stored under key
+func (s *SymbolState) Has(key string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.values[key]
+	return ok
+}
+
+// Set stores a JSON-serializable value under key
+// Returns ErrStateLimit (wrapped) if the state would exceed its key or size limit
+func (s *SymbolState) Set(key string, value interface{}) error {
+	if s == nil {
+		return errors.New("filter state is not available")
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("filter state value for %q is not JSON-serializable: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.values[key]
+	size := s.size + len(raw)
+	if exists {
+		size -= len(old)
+	} else {
+		size += len(key)
+		if len(s.values) >= s.maxKeys {
+			return fmt.Errorf("%w: at most %d keys", ErrStateLimit, s.maxKeys)
+		}
+	}
+	if size > s.maxBytes {
+		return fmt.Errorf("%w: at most %d bytes", ErrStateLimit, s.maxBytes)
+	}
+
+	s.values[key] = raw
+	s.size = size
+	s.dirty = true
+	return nil
+}
+
+// Delete removes key
+func (s *SymbolState) Delete(key string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if raw, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.size -= len(key) + len(raw)
+		s.dirty = true
+	}
+}
+
+// Clear removes every key
+func (s *SymbolState) Clear() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.values) > 0 {
+		s.values = make(map[string]json.RawMessage)
+		s.size = 0
+		s.dirty = true
+	}
+}
+
+// Keys returns the stored keys, sorted
+func (s *SymbolState) Keys() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Snapshot returns a copy of the raw JSON values
+func (s *SymbolState) Snapshot() map[string]json.RawMessage {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := make(map[string]json.RawMessage, len(s.values))
+	for key, raw := range s.values {
+		values[key] = raw
+	}
+	return values
+}
+
+// Restore replaces the state with previously persisted values
+// Values over the limits are dropped
+func (s *SymbolState) Restore(values map[string]json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values = make(map[string]json.RawMessage, len(values))
+	s.size = 0
+	for key, raw := range values {
+		if len(s.values) >= s.maxKeys || s.size+len(key)+len(raw) > s.maxBytes {
+			continue
+		}
+		s.values[key] = raw
+		s.size += len(key) + len(raw)
+	}
+	s.dirty = false
+}
+
+// TakeDirty reports whether the state changed since the last call and resets the flag
+func (s *SymbolState) TakeDirty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dirty := s.dirty
+	s.dirty = false
+	return dirty
+}
+
+// MarkDirty flags the state as changed, so a failed save is retried on the next flush
+func (s *SymbolState) MarkDirty() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirty = true
+}
+
+// SymbolStateRecord is the persisted state of one trader and symbol
+type SymbolStateRecord struct {
+	TraderID string                     `json:"trader_id"`
+	Symbol   string                     `json:"symbol"`
+	State    map[string]json.RawMessage `json:"state"`
+}
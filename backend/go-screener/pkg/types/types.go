@@ -127,6 +127,33 @@ type Signal struct {
 	Source                string                 `json:"source"` // "browser" or "cloud"
 	FlyAppID              *string                `json:"fly_app_id,omitempty"` // References user_fly_apps.id for dedicated apps
 	IndicatorData         map[string]interface{} `json:"indicator_data,omitempty"` // Calculated indicator values for visualization
+	Metadata              map[string]interface{} `json:"metadata,omitempty"`       // Score, tags and values returned by the filter
+}
+
+// FilterResult is the optional rich result of a trader filter
+// Filters may return it instead of a bool to attach a score, tags and arbitrary values to the signal
+type FilterResult struct {
+	Matched  bool                   `json:"matched"`
+	Score    float64                `json:"score,omitempty"`
+	Tags     []string               `json:"tags,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// SignalMetadata flattens the result into signal metadata
+// Score and tags are stored under "score" and "tags" (omitted when zero/empty) and take
+// precedence over metadata keys of the same name
+func (r FilterResult) SignalMetadata() map[string]interface{} {
+	metadata := make(map[string]interface{}, len(r.Metadata)+2)
+	for key, value := range r.Metadata {
+		metadata[key] = value
+	}
+	if r.Score != 0 {
+		metadata["score"] = r.Score
+	}
+	if len(r.Tags) > 0 {
+		metadata["tags"] = r.Tags
+	}
+	return metadata
 }
 
 // MarketData contains all data needed for signal evaluation
@@ -136,6 +163,154 @@ type MarketData struct {
 	Ticker    *SimplifiedTicker     `json:"ticker"`
 	Klines    map[string][]Kline    `json:"klines"` // Key is interval (e.g., "5m", "1h")
 	Timestamp time.Time             `json:"timestamp"`
+
+	// State is the filter's persistent state for this symbol, set by the executor
+	State *SymbolState `json:"-"`
+
+	// Computed holds values a strategy's evaluate stored for its calculateSeries
+	// It lives for one symbol and run only
+	Computed map[string]interface{} `json:"-"`
+
+	// Indicators serves indicator values kept up to date as candles close, if available
+	Indicators IndicatorSource `json:"-"`
+
+	// Book holds features of the symbol's live order book, nil if it isn't tracked
+	Book *BookFeatures `json:"book,omitempty"`
+
+	// Footprints holds footprint candles by interval for symbols whose trades are tracked
+	Footprints map[string][]Footprint `json:"footprints,omitempty"`
+
+	// Tape serves the symbol's recent large trades, nil if its trades aren't tracked
+	Tape TradeTape `json:"-"`
+
+	// Futures holds the positioning of the symbol's perpetual future, nil if it isn't tracked
+	Futures *FuturesContext `json:"futures,omitempty"`
+}
+
+// BookFeatures are features of a local order book at one point in time
+type BookFeatures struct {
+	BestBid   float64    `json:"bestBid"`
+	BestAsk   float64    `json:"bestAsk"`
+	Mid       float64    `json:"mid"`
+	Spread    float64    `json:"spread"`
+	SpreadBps float64    `json:"spreadBps"` // Spread in basis points of the mid price
+	Levels    int        `json:"levels"`    // Price levels per side in BidDepth, AskDepth and Imbalance
+	BidDepth  float64    `json:"bidDepth"`  // Quantity resting on the top bid levels
+	AskDepth  float64    `json:"askDepth"`  // Quantity resting on the top ask levels
+	Imbalance float64    `json:"imbalance"` // (BidDepth - AskDepth) / (BidDepth + AskDepth), from -1 to 1
+	Walls     []BookWall `json:"walls"`     // Unusually large resting orders, nearest to the mid first
+	UpdateID  int64      `json:"updateId"`  // Last order book update included
+	Time      int64      `json:"time"`      // When the features were computed (ms)
+}
+
+// BookWall is a price level holding much more than the levels around it
+type BookWall struct {
+	Side     string  `json:"side"` // "bid" or "ask"
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Multiple float64 `json:"multiple"` // Quantity over the average level quantity on its side
+	Distance float64 `json:"distance"` // Percent distance from the mid price
+}
+
+// AggTrade is an aggregate trade: one taker order filled at one price
+type AggTrade struct {
+	ID           int64   `json:"id"`
+	Price        float64 `json:"price"`
+	Quantity     float64 `json:"quantity"`
+	Time         int64   `json:"time"`         // Trade time (ms)
+	IsBuyerMaker bool    `json:"isBuyerMaker"` // The taker sold into the bid
+}
+
+// Taker sides of a large trade
+const (
+	TakerBuy  = "buy"
+	TakerSell = "sell"
+)
+
+// LargeTrade is a trade flagged as unusually large for its symbol
+type LargeTrade struct {
+	ID       int64   `json:"id"`
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Notional float64 `json:"notional"` // Price * Quantity, in the quote asset
+	Side     string  `json:"side"`     // Taker side: TakerBuy or TakerSell
+	Time     int64   `json:"time"`     // Trade time (ms)
+	Multiple float64 `json:"multiple"` // Notional over the symbol's median trade, 0 until known
+}
+
+// TradeTape serves the recent large trades of one symbol
+type TradeTape interface {
+	// LargeTrades returns the large trades of the last minutes, oldest first
+	LargeTrades(minutes int) []LargeTrade
+}
+
+// FuturesContext is the derivatives positioning of a symbol's USDT-margined perpetual
+type FuturesContext struct {
+	MarkPrice         float64             `json:"markPrice"`
+	IndexPrice        float64             `json:"indexPrice"`
+	FundingRate       float64             `json:"fundingRate"` // Funding rate of the current period, 0.0001 is 0.01%
+	NextFundingTime   int64               `json:"nextFundingTime"`
+	OpenInterest      float64             `json:"openInterest"`      // Latest open interest in the base asset
+	OpenInterestValue float64             `json:"openInterestValue"` // Latest open interest in the quote asset
+	LongShortRatio    float64             `json:"longShortRatio"`    // Latest top trader long/short position ratio
+	OpenInterestHist  []OpenInterestPoint `json:"openInterestHist"`  // Oldest first
+	LongShortHist     []LongShortPoint    `json:"longShortHist"`     // Oldest first
+	Liquidations      []Liquidation       `json:"liquidations"`      // Recent forced orders, oldest first
+	UpdatedAt         int64               `json:"updatedAt"`         // Time of the latest poll (ms)
+}
+
+// OpenInterestPoint is the open interest at the end of one period
+type OpenInterestPoint struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"` // In the base asset
+	Quote float64 `json:"quote"` // In the quote asset
+}
+
+// LongShortPoint is the top trader long/short position ratio at the end of one period
+type LongShortPoint struct {
+	Time  int64   `json:"time"`
+	Ratio float64 `json:"ratio"` // Long / Short
+	Long  float64 `json:"long"`  // Share of positions that is long, 0 to 1
+	Short float64 `json:"short"` // Share of positions that is short, 0 to 1
+}
+
+// Liquidation is a forced order closing a position
+type Liquidation struct {
+	Side     string  `json:"side"` // Side of the liquidated position: "long" or "short"
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Notional float64 `json:"notional"` // Price * Quantity, in the quote asset
+	Time     int64   `json:"time"`     // Trade time (ms)
+}
+
+// Footprint is a candle's traded volume broken down by price level
+// Ask volume is bought by takers lifting the ask and bid volume sold by takers
+// hitting the bid, like Kline.BuyVolume and Kline.SellVolume
+type Footprint struct {
+	OpenTime  int64            `json:"openTime"`
+	CloseTime int64            `json:"closeTime"`
+	LevelSize float64          `json:"levelSize"` // Price range of each level
+	Levels    []FootprintLevel `json:"levels"`    // Lowest price first
+	BidVolume float64          `json:"bidVolume"`
+	AskVolume float64          `json:"askVolume"`
+	Delta     float64          `json:"delta"` // AskVolume - BidVolume
+	POC       float64          `json:"poc"`   // Price of the level with the most volume
+	Trades    int              `json:"trades"`
+}
+
+// FootprintLevel is the volume traded in one price level of a footprint candle
+type FootprintLevel struct {
+	Price     float64 `json:"price"` // Lowest price of the level
+	BidVolume float64 `json:"bidVolume"`
+	AskVolume float64 `json:"askVolume"`
+	Trades    int     `json:"trades"`
+}
+
+// IndicatorSource serves the latest values of streamed indicators for one symbol
+type IndicatorSource interface {
+	// Indicator returns the latest values of a named indicator on an interval and
+	// the open time of the last kline they include; false if it isn't streamed
+	Indicator(interval, name string, params ...float64) ([]float64, int64, bool)
 }
 
 // SimplifiedTicker is the format used by the API (numbers instead of strings)
@@ -0,0 +1,623 @@
+package yaegi
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	gotypes "go/types"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity is how serious a diagnostic is
+type Severity string
+
+const (
+	// SeverityError marks code that won't compile or can't work as written
+	SeverityError Severity = "error"
+	// SeverityWarning marks code that runs but is probably wrong
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic rules
+const (
+	RuleSyntax           = "syntax"
+	RuleCompile          = "compile"
+	RuleImport           = "import"
+	RuleMissingReturn    = "missing-return"
+	RuleUnusedVariable   = "unused-variable"
+	RuleLookahead        = "lookahead"
+	RuleInfiniteLoop     = "infinite-loop"
+	RuleUnknownIndicator = "unknown-indicator"
+)
+
+// Diagnostic is a problem found in filter code
+// Line and Column are 1-based positions in the filter body as the user wrote it
+type Diagnostic struct {
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+// HasErrors reports whether any diagnostic is an error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeCode checks filter code without executing it
+// Besides compile errors it reports paths that don't return, unused variables,
+// reads past the latest kline, loops that can't exit and unknown indicator helpers
+func (e *Executor) AnalyzeCode(code string) []Diagnostic {
+	var importErr *ImportError
+	if err := e.policy.CheckImports(code); errors.As(err, &importErr) {
+		// Nothing else resolves until the imports are fixed
+		diagnostics := make([]Diagnostic, len(importErr.Violations))
+		for idx, v := range importErr.Violations {
+			diagnostics[idx] = Diagnostic{
+				Line:     v.Line,
+				Column:   v.Column,
+				Severity: SeverityError,
+				Rule:     RuleImport,
+				Message:  fmt.Sprintf("import %q is not allowed; allowed imports: %s", v.Path, strings.Join(importErr.Allowed, ", ")),
+			}
+		}
+		return diagnostics
+	}
+
+	// The compile also decides which signature a filter body is wrapped in
+	signature, compileErr := e.compileCheck(code)
+
+	legacy := IsLegacySignal(code)
+
+	var src *wrappedSource
+	if legacy {
+		// checkSignal programs compile as written, with a package clause if they lack one
+		src = newModuleSource(legacyHeader(code), code)
+	} else if IsModule(code) {
+		src = newModuleSource(wrapModule(code))
+	} else {
+		src = newWrappedSource(WrapFunc(signature, code), signature)
+	}
+
+	file, err := parser.ParseFile(src.fset, "", src.text, parser.AllErrors)
+	if err != nil {
+		var list scanner.ErrorList
+		if !errors.As(err, &list) {
+			return []Diagnostic{{Line: 1, Column: 1, Severity: SeverityError, Rule: RuleSyntax, Message: err.Error()}}
+		}
+		// Errors cascade after the first one on a line
+		list.RemoveMultiples()
+		diagnostics := make([]Diagnostic, len(list))
+		for idx, e := range list {
+			line, column := src.bodyPosition(e.Pos.Line, e.Pos.Column)
+			diagnostics[idx] = Diagnostic{Line: line, Column: column, Severity: SeverityError, Rule: RuleSyntax, Message: e.Msg}
+		}
+		return diagnostics
+	}
+
+	a := &analyzer{src: src, indicatorsName: indicatorsName(code)}
+	for _, decl := range file.Decls {
+		// Filter bodies are only the evaluate function, modules are checked as a whole
+		// and checkSignal programs only report syntax and compile errors
+		if fn, ok := decl.(*ast.FuncDecl); ok && !legacy && (src.module || fn.Name.Name == "evaluate") {
+			a.checkFunc(fn)
+		}
+	}
+
+	// Static checks explain the common compile failures better, so compile errors
+	// come last and are skipped if already reported at the same position
+	if compileErr != nil {
+		if d := src.compileDiagnostic(compileErr); !a.reportedAt(d.Line, d.Column) {
+			a.diagnostics = append(a.diagnostics, d)
+		}
+	}
+
+	sort.SliceStable(a.diagnostics, func(i, j int) bool {
+		if a.diagnostics[i].Line != a.diagnostics[j].Line {
+			return a.diagnostics[i].Line < a.diagnostics[j].Line
+		}
+		return a.diagnostics[i].Column < a.diagnostics[j].Column
+	})
+	return a.diagnostics
+}
+
+// compileCheck compiles the filter in a throwaway interpreter and returns the signature
+// its body compiled with, or whose failure is reported
+func (e *Executor) compileCheck(code string) (string, error) {
+	// Already compiled code is known to be valid
+	e.filtersMu.RLock()
+	compiled, ok := e.filters[HashCode(code)]
+	e.filtersMu.RUnlock()
+	if ok {
+		return compiled.signature, nil
+	}
+
+	_, signature, err := compileCode(e.policy.NewInterpreter, code, "")
+	return signature, err
+}
+
+// wrappedSource is filter code wrapped into a file, with the mapping back to the body
+type wrappedSource struct {
+	fset   *token.FileSet
+	text   string
+	offset int      // lines before the first body line
+	lines  []string // body lines
+	module bool     // the body is a strategy module rather than a filter body
+}
+
+func newWrappedSource(text, signature string) *wrappedSource {
+	header := signature + " {\n"
+	idx := strings.Index(text, header)
+	if idx < 0 {
+		return &wrappedSource{fset: token.NewFileSet(), text: text, lines: strings.Split(text, "\n")}
+	}
+
+	// The body is indented with a tab and runs up to the wrapper's closing brace
+	body := strings.TrimPrefix(text[idx+len(header):], "\t")
+	body = strings.TrimSuffix(body, "\n}\n")
+	return &wrappedSource{
+		fset:   token.NewFileSet(),
+		text:   text,
+		offset: strings.Count(text[:idx], "\n") + 1,
+		lines:  strings.Split(body, "\n"),
+	}
+}
+
+// newModuleSource maps a wrapped strategy module back to the code as written
+func newModuleSource(header, body string) *wrappedSource {
+	return &wrappedSource{
+		fset:   token.NewFileSet(),
+		text:   header + body,
+		offset: strings.Count(header, "\n"),
+		lines:  strings.Split(body, "\n"),
+		module: true,
+	}
+}
+
+// bodyPosition maps a position in the wrapped file to the filter body
+// Positions in the wrapper itself are clamped to the start or end of the body
+func (s *wrappedSource) bodyPosition(line, column int) (int, int) {
+	line -= s.offset
+	switch {
+	case line < 1:
+		return 1, 1
+	case line > len(s.lines):
+		last := len(s.lines)
+		return last, len(s.lines[last-1]) + 1
+	case line == 1 && column > 1 && !s.module:
+		// The first body line is indented with a tab in the wrapper
+		column--
+	}
+	return line, column
+}
+
+// compilePosition matches the "line:column: message" prefix of yaegi errors
+var compilePosition = regexp.MustCompile(`^(?:[^\s:]*:)?(\d+):(\d+): (.*)`)
+
+// compileDiagnostic converts a yaegi compile error into a diagnostic
+func (s *wrappedSource) compileDiagnostic(err error) Diagnostic {
+	msg := err.Error()
+	if idx := strings.IndexByte(msg, '\n'); idx >= 0 {
+		msg = msg[:idx]
+	}
+
+	d := Diagnostic{Line: 1, Column: 1, Severity: SeverityError, Rule: RuleCompile, Message: msg}
+	if m := compilePosition.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		column, _ := strconv.Atoi(m[2])
+		d.Line, d.Column = s.bodyPosition(line, column)
+		d.Message = m[3]
+	}
+	return d
+}
+
+// analyzer runs the static checks over the evaluate function
+type analyzer struct {
+	src            *wrappedSource
+	indicatorsName string
+	diagnostics    []Diagnostic
+}
+
+func (a *analyzer) report(pos token.Pos, severity Severity, rule, format string, args ...interface{}) {
+	p := a.src.fset.Position(pos)
+	line, column := a.src.bodyPosition(p.Line, p.Column)
+	a.diagnostics = append(a.diagnostics, Diagnostic{
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// reportedAt reports whether an error was already reported at the position
+func (a *analyzer) reportedAt(line, column int) bool {
+	for _, d := range a.diagnostics {
+		if d.Severity == SeverityError && d.Line == line && d.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *analyzer) checkFunc(fn *ast.FuncDecl) {
+	if fn.Body == nil {
+		return
+	}
+
+	if fn.Type.Results != nil && !isTerminating(fn.Body, "") {
+		pos := fn.Body.Lbrace
+		if n := len(fn.Body.List); n > 0 {
+			pos = fn.Body.List[n-1].Pos()
+		}
+		a.report(pos, SeverityError, RuleMissingReturn, "missing return: %s must return on every path", fn.Name.Name)
+	}
+
+	a.checkUnused(fn.Body)
+	a.checkLoops(fn.Body)
+	a.checkIndexing(fn.Body)
+	a.checkIndicatorCalls(fn.Body)
+}
+
+// checkUnused reports local variables that are declared but never read
+// Identifiers are resolved through the parser's object resolution
+func (a *analyzer) checkUnused(body *ast.BlockStmt) {
+	var declared []*ast.Ident
+	used := make(map[*ast.Object]bool)
+	writes := make(map[*ast.Ident]bool)
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range node.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					writes[ident] = true
+				}
+			}
+		case *ast.IncDecStmt:
+			if ident, ok := node.X.(*ast.Ident); ok {
+				writes[ident] = true
+			}
+		case *ast.Ident:
+			obj := node.Obj
+			if obj == nil || obj.Kind != ast.Var || node.Name == "_" {
+				return true
+			}
+			if obj.Pos() == node.Pos() {
+				switch obj.Decl.(type) {
+				case *ast.AssignStmt, *ast.ValueSpec:
+					declared = append(declared, node)
+				}
+			} else if !writes[node] {
+				used[obj] = true
+			}
+		}
+		return true
+	})
+
+	for _, ident := range declared {
+		if !used[ident.Obj] {
+			a.report(ident.Pos(), SeverityWarning, RuleUnusedVariable, "%s declared and not used", ident.Name)
+		}
+	}
+}
+
+// checkLoops reports loops without a condition that never break or return
+// Such a loop runs until the filter is stopped for exceeding its timeout
+func (a *analyzer) checkLoops(body *ast.BlockStmt) {
+	labels := make(map[ast.Stmt]string)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.LabeledStmt:
+			labels[node.Stmt] = node.Label.Name
+		case *ast.ForStmt:
+			if node.Cond != nil && !isTrue(node.Cond) {
+				return true
+			}
+			if !hasBreak(node.Body, labels[node]) && !hasReturn(node.Body) {
+				a.report(node.Pos(), SeverityError, RuleInfiniteLoop, "loop never exits: add a condition, break or return")
+			}
+		}
+		return true
+	})
+}
+
+// checkIndexing reports indexes that read past the latest element of a series
+func (a *analyzer) checkIndexing(body *ast.BlockStmt) {
+	// loopVars maps the index variable of loops that reach the last element to the series
+	loopVars := make(map[*ast.Object]string)
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.RangeStmt:
+			if key, ok := node.Key.(*ast.Ident); ok && key.Obj != nil && node.Tok == token.DEFINE {
+				loopVars[key.Obj] = gotypes.ExprString(node.X)
+			}
+		case *ast.ForStmt:
+			if obj, series := fullRangeLoop(node); obj != nil {
+				loopVars[obj] = series
+			}
+		case *ast.IndexExpr:
+			series := gotypes.ExprString(node.X)
+
+			if extra, ok := lenOffset(node.Index, series); ok && extra >= 0 {
+				a.report(node.Pos(), SeverityError, RuleLookahead,
+					"%s reads past the latest element; the latest is %s[len(%s)-1]",
+					gotypes.ExprString(node), series, series)
+				return true
+			}
+
+			if ident, extra, ok := identOffset(node.Index); ok && extra > 0 && ident.Obj != nil && loopVars[ident.Obj] == series {
+				a.report(node.Pos(), SeverityWarning, RuleLookahead,
+					"%s looks %d bar(s) ahead of %s and reads past the latest element on the last iteration",
+					gotypes.ExprString(node), extra, ident.Name)
+			}
+		}
+		return true
+	})
+}
+
+// checkIndicatorCalls reports references to helpers the indicators package doesn't export
+func (a *analyzer) checkIndicatorCalls(body *ast.BlockStmt) {
+	helpers := GetCustomSymbols()[IndicatorsImportPath+"/indicators"]
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		// A resolved object means a local variable shadows the package
+		if !ok || pkg.Name != a.indicatorsName || pkg.Obj != nil {
+			return true
+		}
+		if _, ok := helpers[sel.Sel.Name]; ok {
+			return true
+		}
+
+		msg := fmt.Sprintf("unknown indicator helper %s.%s", pkg.Name, sel.Sel.Name)
+		if suggestion := closestName(sel.Sel.Name, helpers); suggestion != "" {
+			msg += fmt.Sprintf("; did you mean %s.%s?", pkg.Name, suggestion)
+		}
+		a.report(sel.Pos(), SeverityError, RuleUnknownIndicator, "%s", msg)
+		return true
+	})
+}
+
+// indicatorsName returns the name the filter refers to the indicators package by
+func indicatorsName(code string) string {
+	specs, _ := hoistImports(code)
+	for _, spec := range specs {
+		if spec.path == IndicatorsImportPath && spec.name != "" {
+			return spec.name
+		}
+	}
+	return "indicators"
+}
+
+// isTerminating reports whether a statement ends its function, following the
+// terminating statement rules of the Go spec
+func isTerminating(stmt ast.Stmt, label string) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.BranchStmt:
+		return s.Tok == token.GOTO
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		ident, ok := call.Fun.(*ast.Ident)
+		return ok && ident.Name == "panic"
+	case *ast.BlockStmt:
+		return len(s.List) > 0 && isTerminating(s.List[len(s.List)-1], "")
+	case *ast.IfStmt:
+		return s.Else != nil && isTerminating(s.Body, "") && isTerminating(s.Else, "")
+	case *ast.LabeledStmt:
+		return isTerminating(s.Stmt, s.Label.Name)
+	case *ast.ForStmt:
+		return s.Cond == nil && !hasBreak(s.Body, label)
+	case *ast.SwitchStmt:
+		return clausesTerminate(s.Body, label)
+	case *ast.TypeSwitchStmt:
+		return clausesTerminate(s.Body, label)
+	case *ast.SelectStmt:
+		return clausesTerminate(s.Body, label)
+	}
+	return false
+}
+
+// clausesTerminate reports whether a switch or select has a default clause and
+// every clause ends in a terminating statement or falls through
+func clausesTerminate(body *ast.BlockStmt, label string) bool {
+	hasDefault := false
+	for _, stmt := range body.List {
+		var list []ast.Stmt
+		switch clause := stmt.(type) {
+		case *ast.CaseClause:
+			hasDefault = hasDefault || clause.List == nil
+			list = clause.Body
+		case *ast.CommClause:
+			hasDefault = hasDefault || clause.Comm == nil
+			list = clause.Body
+		}
+		if len(list) == 0 {
+			return false
+		}
+		last := list[len(list)-1]
+		if branch, ok := last.(*ast.BranchStmt); ok && branch.Tok == token.FALLTHROUGH {
+			continue
+		}
+		if !isTerminating(last, "") || hasBreak(&ast.BlockStmt{List: list}, label) {
+			return false
+		}
+	}
+	return hasDefault
+}
+
+// hasBreak reports whether the body breaks out of the statement it belongs to
+// Unlabeled breaks inside nested loops, switches and selects don't count
+func hasBreak(body *ast.BlockStmt, label string) bool {
+	found := false
+	var visit func(root ast.Node, nested bool)
+	visit = func(root ast.Node, nested bool) {
+		ast.Inspect(root, func(n ast.Node) bool {
+			if found {
+				return false
+			}
+			switch node := n.(type) {
+			case *ast.BranchStmt:
+				if node.Tok == token.BREAK && ((node.Label == nil && !nested) || (node.Label != nil && node.Label.Name == label)) {
+					found = true
+				}
+			case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+				if n != root {
+					visit(n, true)
+					return false
+				}
+			case *ast.FuncLit:
+				return false
+			}
+			return true
+		})
+	}
+	visit(body, false)
+	return found
+}
+
+// hasReturn reports whether the body returns from the filter
+func hasReturn(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.ReturnStmt:
+			found = true
+		case *ast.FuncLit:
+			return false
+		}
+		return !found
+	})
+	return found
+}
+
+// isTrue reports whether the expression is the constant true
+func isTrue(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "true"
+}
+
+// lenOffset matches len(series) + n and returns n
+func lenOffset(expr ast.Expr, series string) (int, bool) {
+	base, extra := splitOffset(expr)
+	call, ok := base.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return 0, false
+	}
+	fn, ok := call.Fun.(*ast.Ident)
+	if !ok || fn.Name != "len" || gotypes.ExprString(call.Args[0]) != series {
+		return 0, false
+	}
+	return extra, true
+}
+
+// identOffset matches ident + n and returns the identifier and n
+func identOffset(expr ast.Expr) (*ast.Ident, int, bool) {
+	base, extra := splitOffset(expr)
+	ident, ok := base.(*ast.Ident)
+	return ident, extra, ok
+}
+
+// splitOffset splits expr + n or expr - n with an integer literal n
+func splitOffset(expr ast.Expr) (ast.Expr, int) {
+	expr = ast.Unparen(expr)
+	bin, ok := expr.(*ast.BinaryExpr)
+	if !ok || (bin.Op != token.ADD && bin.Op != token.SUB) {
+		return expr, 0
+	}
+	lit, ok := ast.Unparen(bin.Y).(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return expr, 0
+	}
+	n, err := strconv.Atoi(lit.Value)
+	if err != nil {
+		return expr, 0
+	}
+	if bin.Op == token.SUB {
+		n = -n
+	}
+	return ast.Unparen(bin.X), n
+}
+
+// fullRangeLoop matches for i := ...; i < len(series); i++ loops
+// and returns the index variable and the series
+func fullRangeLoop(loop *ast.ForStmt) (*ast.Object, string) {
+	cond, ok := loop.Cond.(*ast.BinaryExpr)
+	if !ok {
+		return nil, ""
+	}
+	ident, ok := cond.X.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return nil, ""
+	}
+	call, ok := ast.Unparen(cond.Y).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil, ""
+	}
+	series := gotypes.ExprString(call.Args[0])
+	extra, ok := lenOffset(cond.Y, series)
+	if !ok {
+		return nil, ""
+	}
+	// i < len(s) and i <= len(s)-1 both reach the last element
+	if (cond.Op == token.LSS && extra == 0) || (cond.Op == token.LEQ && extra == -1) {
+		return ident.Obj, series
+	}
+	return nil, ""
+}
+
+// closestName suggests the known name closest to an unknown one
+func closestName(name string, known map[string]reflect.Value) string {
+	best, bestDist := "", 4 // suggest only close matches
+	lower := strings.ToLower(name)
+	for candidate := range known {
+		if dist := editDistance(lower, strings.ToLower(candidate)); dist < bestDist || (dist == bestDist && candidate < best) {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,119 @@
+package yaegi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/traefik/yaegi/interp"
+)
+
+var (
+	// ErrExecutionTimeout is returned when user code exceeds its time budget
+	ErrExecutionTimeout = errors.New("user code exceeded its time budget")
+
+	// ErrExecutionAborted is returned when user code was stopped because another
+	// execution of the same compiled code timed out
+	ErrExecutionAborted = errors.New("user code execution aborted")
+)
+
+// Execution states used to decide who owns the outcome of a guarded run
+const (
+	runRunning int32 = iota
+	runFinished
+	runAbandoned
+	runDetached
+)
+
+var (
+	abandonedTotal    int64 // executions abandoned because their context ended (atomic)
+	abandonedInFlight int64 // abandoned executions whose goroutine has not returned yet (atomic)
+)
+
+// StopInterpreter halts all interpreted code currently running in i
+// Interrupted calls return zero values, so results obtained while stopping must be discarded
+func StopInterpreter(i *interp.Interpreter) {
+	// EvalWithContext stops every running frame of the interpreter when its context
+	// is done; an already-cancelled context with a blocking statement triggers that
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = i.EvalWithContext(ctx, "select {}")
+}
+
+// RunWithContext runs fn on a worker goroutine until it returns or ctx ends
+// When ctx's deadline passes first, stop is called to halt the interpreted code so the
+// worker actually exits; until it does it is reported as an abandoned execution.
+// A cancelled ctx, such as a run that hit its signal limit or shutdown, is a normal
+// early return: fn is left to finish on its own and nothing is stopped
+// kind labels the metrics ("filter", "series")
+func RunWithContext(ctx context.Context, kind string, stop func(), fn func()) error {
+	// Don't start work nobody is waiting for
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	state := runRunning
+	done := make(chan struct{})
+	var panicErr error
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicErr = fmt.Errorf("panic in %s code: %v", kind, r)
+			}
+
+			if !atomic.CompareAndSwapInt32(&state, runRunning, runFinished) && atomic.LoadInt32(&state) == runAbandoned {
+				// Caller already gave up on us
+				atomic.AddInt64(&abandonedInFlight, -1)
+				AbandonedInFlight.WithLabelValues(kind).Dec()
+			}
+			close(done)
+		}()
+
+		fn()
+	}()
+
+	select {
+	case <-done:
+		return panicErr
+	case <-ctx.Done():
+	}
+
+	next := runAbandoned
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		next = runDetached
+	}
+	if !atomic.CompareAndSwapInt32(&state, runRunning, next) {
+		// Finished at the same moment the context ended
+		<-done
+		return panicErr
+	}
+	if next == runDetached {
+		return ctx.Err()
+	}
+
+	atomic.AddInt64(&abandonedTotal, 1)
+	atomic.AddInt64(&abandonedInFlight, 1)
+	AbandonedExecutions.WithLabelValues(kind).Inc()
+	AbandonedInFlight.WithLabelValues(kind).Inc()
+
+	if stop != nil {
+		stop()
+	}
+	return ErrExecutionTimeout
+}
+
+// AbandonedStats holds counts of user code executions that outlived their context
+type AbandonedStats struct {
+	Total    int64 `json:"total"`
+	InFlight int64 `json:"inFlight"` // still running after being abandoned
+}
+
+// GetAbandonedStats returns abandoned execution counts across all user code
+func GetAbandonedStats() AbandonedStats {
+	return AbandonedStats{
+		Total:    atomic.LoadInt64(&abandonedTotal),
+		InFlight: atomic.LoadInt64(&abandonedInFlight),
+	}
+}
@@ -0,0 +1,23 @@
+// Package yaegi is the strategy runtime shared by every deployment that runs
+// trader code: the go-screener backend and the per-user fly-machine.
+//
+// The code contract is the same everywhere. Trader filter code is the body of
+//
+//	func evaluate(data *types.MarketData) bool
+//
+// or, for filters returning a score, tags and metadata, of
+//
+//	func evaluate(data *types.MarketData) types.FilterResult
+//
+// Bodies are compiled with the FilterResult signature first and bool if that fails.
+// Imports at the top of the body are hoisted into the file; only packages allowed
+// by the SandboxPolicy resolve. The types and indicators packages are always
+// available through the symbol table returned by GetCustomSymbols.
+//
+// Deployments that hold raw Binance data convert it with MarketDataFromRaw so
+// filters see identical typed data wherever they run.
+//
+// Traders stored before this contract define checkSignal(symbol, ticker, klines)
+// instead. They still compile, with the raw-kline helpers of pkg/yaegi/legacy, and
+// run on raw data through ExecuteLegacySignalWithContext.
+package yaegi
@@ -1,108 +1,435 @@
 package yaegi
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"go/scanner"
+	"go/token"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/traefik/yaegi/interp"
-	"github.com/traefik/yaegi/stdlib"
 	"github.com/vyx/go-screener/pkg/indicators"
 	"github.com/vyx/go-screener/pkg/types"
 )
 
+// FilterFunc is a compiled trader filter
+// Plain bool filters are adapted to return a FilterResult with only Matched set
+type FilterFunc func(*types.MarketData) types.FilterResult
+
+// compiledFilter holds a compiled filter and the interpreter that owns it
+type compiledFilter struct {
+	key         string
+	fn          FilterFunc
+	series      SeriesFunc       // nil unless the code is a module defining calculateSeries
+	legacy      LegacySignalFunc // nil unless the code is a checkSignal program
+	interpreter *interp.Interpreter
+	signature   string // evaluate signature the body compiled with, "" for modules
+	aborted     int32  // set once the interpreter has been stopped (atomic)
+	lastUsed    int64  // UnixNano of the last lookup, for LRU eviction (atomic)
+}
+
+// DefaultMaxFilters caps the compiled filters cached by an executor
+// One-off executions would otherwise grow the cache without bound
+const DefaultMaxFilters = 500
+
 // Executor handles execution of custom Go code using Yaegi
+// Compiled filters are cached by code hash and shared across symbols and runs,
+// evicting the least recently used beyond maxFilters
 type Executor struct {
 	interpreter *interp.Interpreter
+	policy      *SandboxPolicy
+
+	// Compiled filter cache
+	filters    map[string]*compiledFilter // code hash -> compiled filter
+	maxFilters int
+	filtersMu  sync.RWMutex
+	compileMu  sync.Mutex // Serializes compilation so concurrent misses compile once
+
+	hits         int64 // cache hit counter (atomic)
+	misses       int64 // cache miss counter (atomic)
+	compilations int64 // successful compilations (atomic)
+	evictions    int64 // invalidated entries (atomic)
 }
 
-// NewExecutor creates a new Yaegi executor with pre-loaded symbols
+// NewExecutor creates a new Yaegi executor using the default sandbox policy
 func NewExecutor() (*Executor, error) {
-	i := interp.New(interp.Options{})
-
-	// Load standard library
-	if err := i.Use(stdlib.Symbols); err != nil {
-		return nil, fmt.Errorf("failed to load stdlib: %w", err)
-	}
+	return NewExecutorWithPolicy(DefaultSandboxPolicy())
+}
 
-	// Load our custom symbols (types and indicators)
-	if err := i.Use(GetCustomSymbols()); err != nil {
-		return nil, fmt.Errorf("failed to load custom symbols: %w", err)
+// NewExecutorWithPolicy creates a new Yaegi executor restricted to the policy's imports
+func NewExecutorWithPolicy(policy *SandboxPolicy) (*Executor, error) {
+	i, err := policy.NewInterpreter()
+	if err != nil {
+		return nil, err
 	}
 
-	return &Executor{interpreter: i}, nil
+	return &Executor{
+		interpreter: i,
+		policy:      policy,
+		filters:     make(map[string]*compiledFilter),
+		maxFilters:  DefaultMaxFilters,
+	}, nil
 }
 
-// ExecuteFilter runs a trader's filter code and returns whether it matches
-func (e *Executor) ExecuteFilter(code string, data *types.MarketData) (bool, error) {
-	// Create a fresh interpreter for each execution to avoid redeclaration issues
-	i := interp.New(interp.Options{})
+// Policy returns the sandbox policy applied to filter code
+func (e *Executor) Policy() *SandboxPolicy {
+	return e.policy
+}
 
-	// Load standard library
-	if err := i.Use(stdlib.Symbols); err != nil {
-		return false, fmt.Errorf("failed to load stdlib: %w", err)
-	}
+// Signatures a filter body is wrapped in, tried in this order
+const (
+	resultSignature = "func evaluate(data *types.MarketData) types.FilterResult"
+	boolSignature   = "func evaluate(data *types.MarketData) bool"
+)
 
-	// Load custom symbols
-	if err := i.Use(GetCustomSymbols()); err != nil {
-		return false, fmt.Errorf("failed to load custom symbols: %w", err)
+// usesFilterResult reports whether the code refers to types.FilterResult
+// It only picks which failed signature's error to report, never the signature itself
+func usesFilterResult(code string) bool {
+	src := []byte(code)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+
+	// Look for the token sequence: types . FilterResult
+	var prev []string
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return false
+		}
+		if tok == token.PERIOD {
+			lit = "."
+		}
+		prev = append(prev, lit)
+		if n := len(prev); n >= 3 && prev[n-3] == "types" && prev[n-2] == "." && prev[n-1] == "FilterResult" {
+			return true
+		}
 	}
+}
 
-	// Wrap the code in a function that we can call
-	wrappedCode := fmt.Sprintf(`
+// WrapFunc wraps a function body into a main package importing types and indicators
+// Imports at the top of the body are moved into the file's import block
+func WrapFunc(signature, body string) string {
+	specs, body := hoistImports(body)
+	return fmt.Sprintf(`
 package main
 
 import (
 	"github.com/vyx/go-screener/pkg/types"
 	"github.com/vyx/go-screener/pkg/indicators"
-)
+%s)
 
-func evaluate(data *types.MarketData) bool {
+%s {
 	%s
 }
-`, code)
+`, formatImports(specs), signature, body)
+}
 
-	// Evaluate the wrapped code
-	_, err := i.Eval(wrappedCode)
+// formatImports renders hoisted imports for the wrapper's import block
+func formatImports(specs []importSpec) string {
+	var b strings.Builder
+	for _, spec := range specs {
+		// The wrapper already imports these
+		if spec.name == "" && (spec.path == TypesImportPath || spec.path == IndicatorsImportPath) {
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s %q\n", spec.name, spec.path)
+	}
+	return b.String()
+}
+
+// HashCode returns the cache key for a piece of filter code
+func HashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompileFilter returns the compiled evaluate function for the filter code
+// The first call for a given code compiles it; later calls reuse the cached function
+func (e *Executor) CompileFilter(code string) (FilterFunc, error) {
+	compiled, err := e.compile(code)
 	if err != nil {
-		return false, fmt.Errorf("failed to compile filter code: %w", err)
+		return nil, err
+	}
+	return compiled.fn, nil
+}
+
+// compile returns the cached compilation for the filter code, compiling it on a miss
+func (e *Executor) compile(code string) (*compiledFilter, error) {
+	key := HashCode(code)
+
+	if compiled, ok := e.lookupFilter(key); ok {
+		return compiled, nil
 	}
 
-	// Get the evaluate function
-	v, err := i.Eval("evaluate")
+	// Only one compilation at a time; re-check in case another goroutine just compiled it
+	e.compileMu.Lock()
+	defer e.compileMu.Unlock()
+
+	if compiled, ok := e.lookupFilter(key); ok {
+		return compiled, nil
+	}
+
+	atomic.AddInt64(&e.misses, 1)
+	FilterCacheMisses.Inc()
+
+	start := time.Now()
+	compiled, err := e.compileFilter(code)
 	if err != nil {
-		return false, fmt.Errorf("failed to get evaluate function: %w", err)
+		FilterCompileErrors.Inc()
+		return nil, err
+	}
+	compiled.key = key
+	compiled.lastUsed = time.Now().UnixNano()
+	FilterCompileDuration.Observe(time.Since(start).Seconds())
+
+	e.filtersMu.Lock()
+	e.filters[key] = compiled
+	evicted := e.evictLeastUsed()
+	entries := len(e.filters)
+	e.filtersMu.Unlock()
+
+	if evicted > 0 {
+		atomic.AddInt64(&e.evictions, int64(evicted))
+		FilterCacheEvictions.Add(float64(evicted))
 	}
 
-	// Call the function with the data
-	fn := v.Interface().(func(*types.MarketData) bool)
-	result := fn(data)
+	atomic.AddInt64(&e.compilations, 1)
+	FilterCompilations.Inc()
+	FilterCacheEntries.Set(float64(entries))
 
-	return result, nil
+	return compiled, nil
 }
 
-// ExecuteFilterWithTimeout runs a filter with a timeout
-func (e *Executor) ExecuteFilterWithTimeout(code string, data *types.MarketData, timeout time.Duration) (bool, error) {
-	resultChan := make(chan bool, 1)
-	errorChan := make(chan error, 1)
+// lookupFilter returns a cached filter and records the hit
+func (e *Executor) lookupFilter(key string) (*compiledFilter, bool) {
+	e.filtersMu.RLock()
+	compiled, ok := e.filters[key]
+	e.filtersMu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	atomic.StoreInt64(&compiled.lastUsed, time.Now().UnixNano())
+	atomic.AddInt64(&e.hits, 1)
+	FilterCacheHits.Inc()
+	return compiled, true
+}
+
+// evictLeastUsed drops the least recently used filters beyond maxFilters and returns how many
+// Executions still holding an evicted filter finish with it; callers hold filtersMu
+func (e *Executor) evictLeastUsed() int {
+	evicted := 0
+	for e.maxFilters > 0 && len(e.filters) > e.maxFilters {
+		var oldest *compiledFilter
+		for _, compiled := range e.filters {
+			if oldest == nil || atomic.LoadInt64(&compiled.lastUsed) < atomic.LoadInt64(&oldest.lastUsed) {
+				oldest = compiled
+			}
+		}
+		delete(e.filters, oldest.key)
+		evicted++
+	}
+	return evicted
+}
+
+// compileFilter compiles filter code in a dedicated interpreter
+func (e *Executor) compileFilter(code string) (*compiledFilter, error) {
+	if err := e.policy.CheckImports(code); err != nil {
+		return nil, err
+	}
 
-	go func() {
-		result, err := e.ExecuteFilter(code, data)
+	// Each filter gets its own interpreter to avoid redeclaration issues
+	i, signature, err := compileCode(e.policy.NewInterpreter, code, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile filter code: %w", err)
+	}
+
+	if signature == legacySignature {
+		fn, check, err := loadLegacySignal(i)
 		if err != nil {
-			errorChan <- err
-			return
+			return nil, err
 		}
-		resultChan <- result
-	}()
+		return &compiledFilter{
+			fn:          fn,
+			legacy:      check,
+			interpreter: i,
+			signature:   signature,
+		}, nil
+	}
 
-	select {
-	case result := <-resultChan:
-		return result, nil
-	case err := <-errorChan:
+	fn, series, err := loadStrategy(i, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &compiledFilter{
+		fn:          fn,
+		series:      series,
+		interpreter: i,
+		signature:   signature,
+	}, nil
+}
+
+// knownSignature returns the signature cached code compiled with, "" if unknown
+func (e *Executor) knownSignature(code string) string {
+	e.filtersMu.RLock()
+	defer e.filtersMu.RUnlock()
+	if compiled, ok := e.filters[HashCode(code)]; ok {
+		return compiled.signature
+	}
+	return ""
+}
+
+// InvalidateFilter drops the cached compilation for the given filter code
+// Called when a trader is reloaded or removed so stale versions don't linger
+func (e *Executor) InvalidateFilter(code string) {
+	key := HashCode(code)
+
+	e.filtersMu.Lock()
+	_, ok := e.filters[key]
+	delete(e.filters, key)
+	entries := len(e.filters)
+	e.filtersMu.Unlock()
+
+	if ok {
+		atomic.AddInt64(&e.evictions, 1)
+		FilterCacheEvictions.Inc()
+		FilterCacheEntries.Set(float64(entries))
+	}
+}
+
+// abortFilter stops every running execution of a compiled filter and evicts it
+// A stopped interpreter cannot be trusted for further results, so the next
+// execution recompiles from source
+func (e *Executor) abortFilter(compiled *compiledFilter) {
+	if !atomic.CompareAndSwapInt32(&compiled.aborted, 0, 1) {
+		return // Already stopped by another timed-out execution
+	}
+
+	e.filtersMu.Lock()
+	if e.filters[compiled.key] == compiled {
+		delete(e.filters, compiled.key)
+		atomic.AddInt64(&e.evictions, 1)
+		FilterCacheEvictions.Inc()
+	}
+	entries := len(e.filters)
+	e.filtersMu.Unlock()
+	FilterCacheEntries.Set(float64(entries))
+
+	StopInterpreter(compiled.interpreter)
+}
+
+// ClearCache drops all cached compilations
+func (e *Executor) ClearCache() {
+	e.filtersMu.Lock()
+	evicted := len(e.filters)
+	e.filters = make(map[string]*compiledFilter)
+	e.filtersMu.Unlock()
+
+	atomic.AddInt64(&e.evictions, int64(evicted))
+	FilterCacheEvictions.Add(float64(evicted))
+	FilterCacheEntries.Set(0)
+}
+
+// CacheStats holds compiled filter cache statistics
+type CacheStats struct {
+	Entries      int     `json:"entries"`
+	Hits         int64   `json:"hits"`
+	Misses       int64   `json:"misses"`
+	Compilations int64   `json:"compilations"`
+	Evictions    int64   `json:"evictions"`
+	HitRate      float64 `json:"hitRate"`
+}
+
+// CacheStats returns compiled filter cache statistics
+func (e *Executor) CacheStats() CacheStats {
+	e.filtersMu.RLock()
+	entries := len(e.filters)
+	e.filtersMu.RUnlock()
+
+	hits := atomic.LoadInt64(&e.hits)
+	misses := atomic.LoadInt64(&e.misses)
+
+	hitRate := 0.0
+	if total := hits + misses; total > 0 {
+		hitRate = float64(hits) / float64(total) * 100
+	}
+
+	return CacheStats{
+		Entries:      entries,
+		Hits:         hits,
+		Misses:       misses,
+		Compilations: atomic.LoadInt64(&e.compilations),
+		Evictions:    atomic.LoadInt64(&e.evictions),
+		HitRate:      hitRate,
+	}
+}
+
+// ExecuteFilter runs a trader's filter code and returns whether it matches
+func (e *Executor) ExecuteFilter(code string, data *types.MarketData) (bool, error) {
+	fn, err := e.CompileFilter(code)
+	if err != nil {
 		return false, err
-	case <-time.After(timeout):
-		return false, fmt.Errorf("filter execution timed out after %v", timeout)
 	}
+
+	// Call the function with the data
+	return fn(data).Matched, nil
+}
+
+// ExecuteFilterWithContext runs a filter until it returns or ctx ends
+// If ctx ends first the filter's interpreter is stopped, so runaway code does not
+// keep a goroutine spinning after the caller has given up on it
+func (e *Executor) ExecuteFilterWithContext(ctx context.Context, code string, data *types.MarketData) (bool, error) {
+	result, err := e.EvaluateFilterWithContext(ctx, code, data)
+	return result.Matched, err
+}
+
+// EvaluateFilterWithContext runs a filter like ExecuteFilterWithContext and returns
+// its full result, including the score, tags and metadata of rich filters
+func (e *Executor) EvaluateFilterWithContext(ctx context.Context, code string, data *types.MarketData) (types.FilterResult, error) {
+	compiled, err := e.compile(code)
+	if err != nil {
+		return types.FilterResult{}, err
+	}
+
+	var result types.FilterResult
+	err = RunWithContext(ctx, "filter", func() { e.abortFilter(compiled) }, func() {
+		result = compiled.fn(data)
+	})
+	if err != nil {
+		return types.FilterResult{}, err
+	}
+
+	// Another execution of this filter timed out while we were running and stopped
+	// the interpreter; our result is a zero value, not a real answer
+	if atomic.LoadInt32(&compiled.aborted) == 1 {
+		return types.FilterResult{}, ErrExecutionAborted
+	}
+
+	return result, nil
+}
+
+// ExecuteFilterWithTimeout runs a filter with a timeout
+func (e *Executor) ExecuteFilterWithTimeout(code string, data *types.MarketData, timeout time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result, err := e.ExecuteFilterWithContext(ctx, code, data)
+	if errors.Is(err, ErrExecutionTimeout) {
+		return false, fmt.Errorf("filter execution timed out after %v: %w", timeout, err)
+	}
+	return result, err
 }
 
 // GetCustomSymbols returns our custom symbols for Yaegi (exported for use in other packages)
@@ -114,25 +441,40 @@ func GetCustomSymbols() map[string]map[string]reflect.Value {
 			"SimplifiedTicker":  reflect.ValueOf((*types.SimplifiedTicker)(nil)),
 			"MarketData":        reflect.ValueOf((*types.MarketData)(nil)),
 			"KlineInterval":     reflect.ValueOf((*types.KlineInterval)(nil)),
+			"FilterResult":      reflect.ValueOf((*types.FilterResult)(nil)),
+			"SymbolState":       reflect.ValueOf((*types.SymbolState)(nil)),
+			"BookFeatures":      reflect.ValueOf((*types.BookFeatures)(nil)),
+			"BookWall":          reflect.ValueOf((*types.BookWall)(nil)),
+			"AggTrade":          reflect.ValueOf((*types.AggTrade)(nil)),
+			"Footprint":         reflect.ValueOf((*types.Footprint)(nil)),
+			"FootprintLevel":    reflect.ValueOf((*types.FootprintLevel)(nil)),
+			"LargeTrade":        reflect.ValueOf((*types.LargeTrade)(nil)),
+			"TradeTape":         reflect.ValueOf((*types.TradeTape)(nil)),
+			"FuturesContext":    reflect.ValueOf((*types.FuturesContext)(nil)),
+			"OpenInterestPoint": reflect.ValueOf((*types.OpenInterestPoint)(nil)),
+			"LongShortPoint":    reflect.ValueOf((*types.LongShortPoint)(nil)),
+			"Liquidation":       reflect.ValueOf((*types.Liquidation)(nil)),
 		},
 		"github.com/vyx/go-screener/pkg/indicators/indicators": {
 			// Moving Averages
-			"CalculateMA":       reflect.ValueOf(indicators.CalculateMA),
-			"CalculateMASeries": reflect.ValueOf(indicators.CalculateMASeries),
-			"CalculateEMA":      reflect.ValueOf(indicators.CalculateEMA),
+			"CalculateMA":        reflect.ValueOf(indicators.CalculateMA),
+			"CalculateMASeries":  reflect.ValueOf(indicators.CalculateMASeries),
+			"CalculateEMA":       reflect.ValueOf(indicators.CalculateEMA),
 			"CalculateEMASeries": reflect.ValueOf(indicators.CalculateEMASeries),
+			"CalculateWMA":       reflect.ValueOf(indicators.CalculateWMA),
+			"CalculateWMASeries": reflect.ValueOf(indicators.CalculateWMASeries),
 
 			// RSI
-			"CalculateRSI":  reflect.ValueOf(indicators.CalculateRSI),
-			"GetLatestRSI":  reflect.ValueOf(indicators.GetLatestRSI),
+			"CalculateRSI": reflect.ValueOf(indicators.CalculateRSI),
+			"GetLatestRSI": reflect.ValueOf(indicators.GetLatestRSI),
 
 			// MACD
-			"CalculateMACD":  reflect.ValueOf(indicators.CalculateMACD),
-			"GetLatestMACD":  reflect.ValueOf(indicators.GetLatestMACD),
+			"CalculateMACD": reflect.ValueOf(indicators.CalculateMACD),
+			"GetLatestMACD": reflect.ValueOf(indicators.GetLatestMACD),
 
 			// Bollinger Bands
-			"CalculateBollingerBands":  reflect.ValueOf(indicators.CalculateBollingerBands),
-			"GetLatestBollingerBands":  reflect.ValueOf(indicators.GetLatestBollingerBands),
+			"CalculateBollingerBands": reflect.ValueOf(indicators.CalculateBollingerBands),
+			"GetLatestBollingerBands": reflect.ValueOf(indicators.GetLatestBollingerBands),
 
 			// Volume
 			"CalculateAvgVolume": reflect.ValueOf(indicators.CalculateAvgVolume),
@@ -145,42 +487,163 @@ func GetCustomSymbols() map[string]map[string]reflect.Value {
 			// Stochastic
 			"CalculateStochastic": reflect.ValueOf(indicators.CalculateStochastic),
 
+			// Momentum
+			"CalculateCCI":             reflect.ValueOf(indicators.CalculateCCI),
+			"CalculateCCISeries":       reflect.ValueOf(indicators.CalculateCCISeries),
+			"CalculateWilliamsR":       reflect.ValueOf(indicators.CalculateWilliamsR),
+			"CalculateWilliamsRSeries": reflect.ValueOf(indicators.CalculateWilliamsRSeries),
+			"CalculateROC":             reflect.ValueOf(indicators.CalculateROC),
+			"CalculateROCSeries":       reflect.ValueOf(indicators.CalculateROCSeries),
+
+			// Volatility
+			"CalculateATR":              reflect.ValueOf(indicators.CalculateATR),
+			"CalculateATRSeries":        reflect.ValueOf(indicators.CalculateATRSeries),
+			"CalculateKeltnerChannels":  reflect.ValueOf(indicators.CalculateKeltnerChannels),
+			"GetLatestKeltnerChannels":  reflect.ValueOf(indicators.GetLatestKeltnerChannels),
+			"CalculateDonchianChannels": reflect.ValueOf(indicators.CalculateDonchianChannels),
+			"GetLatestDonchianChannels": reflect.ValueOf(indicators.GetLatestDonchianChannels),
+			"ChannelResult":             reflect.ValueOf((*indicators.ChannelResult)(nil)),
+
+			// Trend strength
+			"CalculateADX":   reflect.ValueOf(indicators.CalculateADX),
+			"GetLatestADX":   reflect.ValueOf(indicators.GetLatestADX),
+			"ADXResult":      reflect.ValueOf((*indicators.ADXResult)(nil)),
+			"CalculateAroon": reflect.ValueOf(indicators.CalculateAroon),
+			"GetLatestAroon": reflect.ValueOf(indicators.GetLatestAroon),
+			"AroonResult":    reflect.ValueOf((*indicators.AroonResult)(nil)),
+
+			// Streamed when the engine keeps them, calculated from data.Klines otherwise
+			"LatestSMA":  reflect.ValueOf(indicators.LatestSMA),
+			"LatestEMA":  reflect.ValueOf(indicators.LatestEMA),
+			"LatestRSI":  reflect.ValueOf(indicators.LatestRSI),
+			"LatestMACD": reflect.ValueOf(indicators.LatestMACD),
+			"LatestATR":  reflect.ValueOf(indicators.LatestATR),
+
+			// On-Balance Volume
+			"CalculateOBV":       reflect.ValueOf(indicators.CalculateOBV),
+			"CalculateOBVSeries": reflect.ValueOf(indicators.CalculateOBVSeries),
+
+			// Market structure
+			"FindSwingHighs":         reflect.ValueOf(indicators.FindSwingHighs),
+			"FindSwingLows":          reflect.ValueOf(indicators.FindSwingLows),
+			"FindSwingPoints":        reflect.ValueOf(indicators.FindSwingPoints),
+			"FindSupportResistance":  reflect.ValueOf(indicators.FindSupportResistance),
+			"NearestSupport":         reflect.ValueOf(indicators.NearestSupport),
+			"NearestResistance":      reflect.ValueOf(indicators.NearestResistance),
+			"CalculateVolumeProfile": reflect.ValueOf(indicators.CalculateVolumeProfile),
+			"SwingPoint":             reflect.ValueOf((*indicators.SwingPoint)(nil)),
+			"PriceLevel":             reflect.ValueOf((*indicators.PriceLevel)(nil)),
+			"VolumeProfile":          reflect.ValueOf((*indicators.VolumeProfile)(nil)),
+			"VolumeBin":              reflect.ValueOf((*indicators.VolumeBin)(nil)),
+			"LevelSupport":           reflect.ValueOf(indicators.LevelSupport),
+			"LevelResistance":        reflect.ValueOf(indicators.LevelResistance),
+
+			// Order flow (taker buy/sell volume)
+			"CalculateCVD":              reflect.ValueOf(indicators.CalculateCVD),
+			"CalculateCVDSeries":        reflect.ValueOf(indicators.CalculateCVDSeries),
+			"CalculateSessionCVDSeries": reflect.ValueOf(indicators.CalculateSessionCVDSeries),
+			"CalculateRollingCVD":       reflect.ValueOf(indicators.CalculateRollingCVD),
+			"CalculateRollingCVDSeries": reflect.ValueOf(indicators.CalculateRollingCVDSeries),
+			"CalculateImbalance":        reflect.ValueOf(indicators.CalculateImbalance),
+			"CalculateImbalanceSeries":  reflect.ValueOf(indicators.CalculateImbalanceSeries),
+			"CalculateBuySellRatio":     reflect.ValueOf(indicators.CalculateBuySellRatio),
+			"DetectCVDDivergences":      reflect.ValueOf(indicators.DetectCVDDivergences),
+			"DetectAbsorption":          reflect.ValueOf(indicators.DetectAbsorption),
+			"DetectAbsorptionAt":        reflect.ValueOf(indicators.DetectAbsorptionAt),
+			"DefaultAbsorptionOptions":  reflect.ValueOf(indicators.DefaultAbsorptionOptions),
+			"AbsorptionOptions":         reflect.ValueOf((*indicators.AbsorptionOptions)(nil)),
+			"Absorption":                reflect.ValueOf((*indicators.Absorption)(nil)),
+
+			// Large trades (trade tape)
+			"LargeTrades":        reflect.ValueOf(indicators.LargeTrades),
+			"LargeBuys":          reflect.ValueOf(indicators.LargeBuys),
+			"LargeSells":         reflect.ValueOf(indicators.LargeSells),
+			"LargeTradeNotional": reflect.ValueOf(indicators.LargeTradeNotional),
+
+			// Futures positioning
+			"OpenInterestChange":  reflect.ValueOf(indicators.OpenInterestChange),
+			"Liquidations":        reflect.ValueOf(indicators.Liquidations),
+			"LongLiquidations":    reflect.ValueOf(indicators.LongLiquidations),
+			"ShortLiquidations":   reflect.ValueOf(indicators.ShortLiquidations),
+			"LiquidationNotional": reflect.ValueOf(indicators.LiquidationNotional),
+
+			// Indicator registry
+			"CalculateIndicator":   reflect.ValueOf(indicators.CalculateIndicator),
+			"IndicatorChartSeries": reflect.ValueOf(indicators.IndicatorChartSeries),
+			"LookupIndicator":      reflect.ValueOf(indicators.LookupIndicator),
+			"IndicatorCatalog":     reflect.ValueOf(indicators.IndicatorCatalog),
+			"IndicatorSpec":        reflect.ValueOf((*indicators.IndicatorSpec)(nil)),
+			"IndicatorParams":      reflect.ValueOf((*indicators.IndicatorParams)(nil)),
+			"IndicatorOutput":      reflect.ValueOf((*indicators.IndicatorOutput)(nil)),
+			"ParamSpec":            reflect.ValueOf((*indicators.ParamSpec)(nil)),
+
+			// Divergences
+			"DetectDivergences":            reflect.ValueOf(indicators.DetectDivergences),
+			"DetectRSIDivergences":         reflect.ValueOf(indicators.DetectRSIDivergences),
+			"DetectMACDDivergences":        reflect.ValueOf(indicators.DetectMACDDivergences),
+			"DetectOBVDivergences":         reflect.ValueOf(indicators.DetectOBVDivergences),
+			"DetectVolumeDeltaDivergences": reflect.ValueOf(indicators.DetectVolumeDeltaDivergences),
+			"LatestDivergence":             reflect.ValueOf(indicators.LatestDivergence),
+			"DivergenceSeries":             reflect.ValueOf(indicators.DivergenceSeries),
+			"DefaultDivergenceOptions":     reflect.ValueOf(indicators.DefaultDivergenceOptions),
+			"DivergenceOptions":            reflect.ValueOf((*indicators.DivergenceOptions)(nil)),
+			"Divergence":                   reflect.ValueOf((*indicators.Divergence)(nil)),
+			"DivergenceRegularBullish":     reflect.ValueOf(indicators.DivergenceRegularBullish),
+			"DivergenceHiddenBullish":      reflect.ValueOf(indicators.DivergenceHiddenBullish),
+			"DivergenceRegularBearish":     reflect.ValueOf(indicators.DivergenceRegularBearish),
+			"DivergenceHiddenBearish":      reflect.ValueOf(indicators.DivergenceHiddenBearish),
+
 			// Patterns
 			"DetectEngulfingPattern": reflect.ValueOf(indicators.DetectEngulfingPattern),
+			"DetectPatterns":         reflect.ValueOf(indicators.DetectPatterns),
+			"DetectPatternsAt":       reflect.ValueOf(indicators.DetectPatternsAt),
+			"HasPattern":             reflect.ValueOf(indicators.HasPattern),
+			"DefaultPatternOptions":  reflect.ValueOf(indicators.DefaultPatternOptions),
+			"PatternOptions":         reflect.ValueOf((*indicators.PatternOptions)(nil)),
+			"PatternMatch":           reflect.ValueOf((*indicators.PatternMatch)(nil)),
+
+			// Pattern names and directions
+			"PatternDoji":               reflect.ValueOf(indicators.PatternDoji),
+			"PatternDragonflyDoji":      reflect.ValueOf(indicators.PatternDragonflyDoji),
+			"PatternGravestoneDoji":     reflect.ValueOf(indicators.PatternGravestoneDoji),
+			"PatternLongLeggedDoji":     reflect.ValueOf(indicators.PatternLongLeggedDoji),
+			"PatternHammer":             reflect.ValueOf(indicators.PatternHammer),
+			"PatternShootingStar":       reflect.ValueOf(indicators.PatternShootingStar),
+			"PatternBullishEngulfing":   reflect.ValueOf(indicators.PatternBullishEngulfing),
+			"PatternBearishEngulfing":   reflect.ValueOf(indicators.PatternBearishEngulfing),
+			"PatternBullishHarami":      reflect.ValueOf(indicators.PatternBullishHarami),
+			"PatternBearishHarami":      reflect.ValueOf(indicators.PatternBearishHarami),
+			"PatternMorningStar":        reflect.ValueOf(indicators.PatternMorningStar),
+			"PatternEveningStar":        reflect.ValueOf(indicators.PatternEveningStar),
+			"PatternThreeWhiteSoldiers": reflect.ValueOf(indicators.PatternThreeWhiteSoldiers),
+			"PatternThreeBlackCrows":    reflect.ValueOf(indicators.PatternThreeBlackCrows),
+			"PatternInsideBar":          reflect.ValueOf(indicators.PatternInsideBar),
+			"PatternOutsideBar":         reflect.ValueOf(indicators.PatternOutsideBar),
+			"PatternBullishPinBar":      reflect.ValueOf(indicators.PatternBullishPinBar),
+			"PatternBearishPinBar":      reflect.ValueOf(indicators.PatternBearishPinBar),
+			"PatternBullish":            reflect.ValueOf(indicators.PatternBullish),
+			"PatternBearish":            reflect.ValueOf(indicators.PatternBearish),
+			"PatternNeutral":            reflect.ValueOf(indicators.PatternNeutral),
 		},
 	}
 }
 
 // ValidateCode validates that the code compiles without executing it
 func (e *Executor) ValidateCode(code string) error {
-	// Create a fresh interpreter for validation
-	i := interp.New(interp.Options{})
-
-	// Load standard library
-	if err := i.Use(stdlib.Symbols); err != nil {
-		return fmt.Errorf("failed to load stdlib: %w", err)
+	// Already compiled code is known to be valid
+	e.filtersMu.RLock()
+	_, ok := e.filters[HashCode(code)]
+	e.filtersMu.RUnlock()
+	if ok {
+		return nil
 	}
 
-	// Load custom symbols
-	if err := i.Use(GetCustomSymbols()); err != nil {
-		return fmt.Errorf("failed to load custom symbols: %w", err)
+	if err := e.policy.CheckImports(code); err != nil {
+		return err
 	}
 
-	wrappedCode := fmt.Sprintf(`
-package main
-
-import (
-	"github.com/vyx/go-screener/pkg/types"
-	"github.com/vyx/go-screener/pkg/indicators"
-)
-
-func evaluate(data *types.MarketData) bool {
-	%s
-}
-`, code)
-
-	_, err := i.Eval(wrappedCode)
-	if err != nil {
+	// Compile in a fresh interpreter for validation
+	if _, _, err := compileCode(e.policy.NewInterpreter, code, ""); err != nil {
 		return fmt.Errorf("code validation failed: %w", err)
 	}
 
@@ -0,0 +1,1129 @@
+package yaegi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/traefik/yaegi/interp"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// createTestMarketData creates MarketData with a simple rising 5m series
+func createTestMarketData(symbol string, closes ...float64) *types.MarketData {
+	klines := make([]types.Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = types.Kline{OpenTime: int64(i * 1000), Open: c, High: c, Low: c, Close: c, CloseTime: int64((i + 1) * 1000)}
+	}
+
+	return &types.MarketData{
+		Symbol:    symbol,
+		Ticker:    &types.SimplifiedTicker{LastPrice: closes[len(closes)-1]},
+		Klines:    map[string][]types.Kline{"5m": klines},
+		Timestamp: time.Now(),
+	}
+}
+
+const maFilter = `
+	klines := data.Klines["5m"]
+	ma := indicators.CalculateMA(klines, 2)
+	return ma != nil && *ma > 2
+`
+
+func TestExecutor_ExecuteFilter_CachesCompilation(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	tests := []struct {
+		symbol string
+		closes []float64
+		want   bool
+	}{
+		{"BTCUSDT", []float64{1, 2, 3}, true},
+		{"ETHUSDT", []float64{3, 2, 1}, false},
+		{"SOLUSDT", []float64{1, 5, 5}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := executor.ExecuteFilter(maFilter, createTestMarketData(tt.symbol, tt.closes...))
+		if err != nil {
+			t.Fatalf("ExecuteFilter(%s) failed: %v", tt.symbol, err)
+		}
+		if got != tt.want {
+			t.Errorf("ExecuteFilter(%s) = %v, want %v", tt.symbol, got, tt.want)
+		}
+	}
+
+	stats := executor.CacheStats()
+	if stats.Compilations != 1 {
+		t.Errorf("Compilations = %d, want 1", stats.Compilations)
+	}
+	if stats.Misses != 1 {
+		t.Errorf("Misses = %d, want 1", stats.Misses)
+	}
+	if stats.Hits != 2 {
+		t.Errorf("Hits = %d, want 2", stats.Hits)
+	}
+	if stats.Entries != 1 {
+		t.Errorf("Entries = %d, want 1", stats.Entries)
+	}
+}
+
+func TestExecutor_CompileFilter_Concurrent(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			matched, err := executor.ExecuteFilter(maFilter, createTestMarketData("BTCUSDT", 1, 2, 3))
+			if err != nil {
+				t.Errorf("ExecuteFilter failed: %v", err)
+				return
+			}
+			if !matched {
+				t.Errorf("ExecuteFilter = false, want true")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stats := executor.CacheStats(); stats.Compilations != 1 {
+		t.Errorf("Compilations = %d, want 1 for concurrent callers", stats.Compilations)
+	}
+}
+
+func TestExecutor_CompileFilter_ErrorNotCached(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	badCode := `return notDefined > 0`
+	for i := 0; i < 2; i++ {
+		if _, err := executor.CompileFilter(badCode); err == nil {
+			t.Fatal("CompileFilter should fail for invalid code")
+		}
+	}
+
+	stats := executor.CacheStats()
+	if stats.Entries != 0 {
+		t.Errorf("Entries = %d, want 0", stats.Entries)
+	}
+	if stats.Misses != 2 {
+		t.Errorf("Misses = %d, want 2", stats.Misses)
+	}
+}
+
+func TestExecutor_InvalidateFilter(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	if _, err := executor.CompileFilter(maFilter); err != nil {
+		t.Fatalf("CompileFilter failed: %v", err)
+	}
+	if _, err := executor.CompileFilter(`return true`); err != nil {
+		t.Fatalf("CompileFilter failed: %v", err)
+	}
+
+	executor.InvalidateFilter(maFilter)
+
+	stats := executor.CacheStats()
+	if stats.Entries != 1 {
+		t.Errorf("Entries = %d, want 1 after invalidation", stats.Entries)
+	}
+	if stats.Evictions != 1 {
+		t.Errorf("Evictions = %d, want 1", stats.Evictions)
+	}
+
+	// Next use recompiles
+	if _, err := executor.CompileFilter(maFilter); err != nil {
+		t.Fatalf("CompileFilter failed: %v", err)
+	}
+	if stats := executor.CacheStats(); stats.Compilations != 3 {
+		t.Errorf("Compilations = %d, want 3", stats.Compilations)
+	}
+
+	executor.ClearCache()
+	if stats := executor.CacheStats(); stats.Entries != 0 {
+		t.Errorf("Entries = %d, want 0 after ClearCache", stats.Entries)
+	}
+}
+
+func TestExecutor_CacheEvictsLeastUsed(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+	executor.maxFilters = 2
+
+	for _, code := range []string{maFilter, `return true`, maFilter, `return false`} {
+		if _, err := executor.CompileFilter(code); err != nil {
+			t.Fatalf("CompileFilter failed: %v", err)
+		}
+	}
+
+	// maFilter was used after return true, so return true goes first
+	stats := executor.CacheStats()
+	if stats.Entries != 2 || stats.Evictions != 1 {
+		t.Errorf("Entries = %d, Evictions = %d, want 2 and 1", stats.Entries, stats.Evictions)
+	}
+	if _, err := executor.CompileFilter(maFilter); err != nil {
+		t.Fatalf("CompileFilter failed: %v", err)
+	}
+	if stats := executor.CacheStats(); stats.Compilations != 3 {
+		t.Errorf("Compilations = %d, want 3 with maFilter still cached", stats.Compilations)
+	}
+}
+
+func TestExecutor_ValidateCode(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	if err := executor.ValidateCode(maFilter); err != nil {
+		t.Errorf("ValidateCode(valid) = %v, want nil", err)
+	}
+	if err := executor.ValidateCode(`return "nope"`); err == nil {
+		t.Error("ValidateCode(invalid) = nil, want error")
+	}
+
+	// Validation does not populate the cache
+	if stats := executor.CacheStats(); stats.Entries != 0 {
+		t.Errorf("Entries = %d, want 0", stats.Entries)
+	}
+}
+
+func TestExecutor_ExecuteFilterWithTimeout_StopsRunawayCode(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	runaway := `
+	x := 0
+	for {
+		x++
+	}
+	return x > 0
+`
+	before := GetAbandonedStats()
+
+	_, err = executor.ExecuteFilterWithTimeout(runaway, createTestMarketData("BTCUSDT", 1, 2, 3), 50*time.Millisecond)
+	if !errors.Is(err, ErrExecutionTimeout) {
+		t.Fatalf("err = %v, want ErrExecutionTimeout", err)
+	}
+
+	after := GetAbandonedStats()
+	if after.Total != before.Total+1 {
+		t.Errorf("abandoned total = %d, want %d", after.Total, before.Total+1)
+	}
+
+	// The interpreter was stopped, so the abandoned goroutine should exit promptly
+	deadline := time.Now().Add(2 * time.Second)
+	for GetAbandonedStats().InFlight != before.InFlight {
+		if time.Now().After(deadline) {
+			t.Fatalf("abandoned execution still running: in-flight = %d", GetAbandonedStats().InFlight)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The stopped compilation is evicted
+	if stats := executor.CacheStats(); stats.Entries != 0 {
+		t.Errorf("Entries = %d, want 0 after timeout", stats.Entries)
+	}
+}
+
+func TestExecutor_ExecuteFilterWithContext_Canceled(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := executor.ExecuteFilterWithContext(ctx, maFilter, createTestMarketData("BTCUSDT", 1, 2, 3)); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestExecutor_EvaluateFilterWithContext_CancelDoesNotAbort(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	code := `import "time"
+	start := time.Now()
+	for data.Symbol == "SLOWUSDT" && time.Since(start) < 200*time.Millisecond {
+	}
+	return true
+`
+	before := GetAbandonedStats()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := executor.EvaluateFilterWithContext(ctx, code, createTestMarketData("SLOWUSDT", 1)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	// The cancelled run isn't a timeout: nothing is stopped, evicted or counted
+	if stats := GetAbandonedStats(); stats.Total != before.Total {
+		t.Errorf("abandoned total = %d, want %d", stats.Total, before.Total)
+	}
+	if stats := executor.CacheStats(); stats.Entries != 1 {
+		t.Errorf("Entries = %d, want the compiled filter kept", stats.Entries)
+	}
+	result, err := executor.EvaluateFilterWithContext(context.Background(), code, createTestMarketData("BTCUSDT", 1))
+	if err != nil || !result.Matched {
+		t.Errorf("run after a cancel = %+v, %v, want a match", result, err)
+	}
+}
+
+func TestExecutor_ValidateCode_ImportNotAllowed(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	code := `import "math"
+import (
+	"os"
+	exec "os/exec"
+)
+	return math.Abs(1) > 0 && os.Getpid() > 0 && exec.Command("true") != nil
+`
+	err = executor.ValidateCode(code)
+
+	var importErr *ImportError
+	if !errors.As(err, &importErr) {
+		t.Fatalf("err = %v, want *ImportError", err)
+	}
+
+	want := []ImportViolation{
+		{Path: "os", Line: 3, Column: 2},
+		{Path: "os/exec", Line: 4, Column: 7},
+	}
+	if len(importErr.Violations) != len(want) {
+		t.Fatalf("Violations = %+v, want %+v", importErr.Violations, want)
+	}
+	for i, v := range want {
+		if importErr.Violations[i] != v {
+			t.Errorf("Violations[%d] = %+v, want %+v", i, importErr.Violations[i], v)
+		}
+	}
+
+	// Compilation enforces the same policy
+	if _, err := executor.CompileFilter(code); !errors.As(err, &importErr) {
+		t.Errorf("CompileFilter err = %v, want *ImportError", err)
+	}
+}
+
+func TestExecutor_ExecuteFilter_AllowedImports(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	code := `import (
+	"math"
+	"strings"
+)
+	return math.Abs(-1) == 1 && strings.HasSuffix(data.Symbol, "USDT")
+`
+	matched, err := executor.ExecuteFilter(code, createTestMarketData("BTCUSDT", 1, 2, 3))
+	if err != nil {
+		t.Fatalf("ExecuteFilter failed: %v", err)
+	}
+	if !matched {
+		t.Error("ExecuteFilter = false, want true")
+	}
+}
+
+func TestSandboxPolicy_UnlistedPackagesNotLoaded(t *testing.T) {
+	policy := NewSandboxPolicy([]string{"strings"})
+
+	if !policy.Allows(TypesImportPath) || !policy.Allows(IndicatorsImportPath) {
+		t.Error("types and indicators should always be allowed")
+	}
+	if policy.Allows("math") {
+		t.Error("math should not be allowed by a strings-only policy")
+	}
+
+	// Even bypassing the import check, the interpreter cannot resolve the package
+	i, err := policy.NewInterpreter()
+	if err != nil {
+		t.Fatalf("NewInterpreter failed: %v", err)
+	}
+	if _, err := i.Eval(`import "os"`); err == nil {
+		t.Error("importing os should fail in a sandboxed interpreter")
+	}
+	if _, err := i.Eval(`import "strings"`); err != nil {
+		t.Errorf("importing strings failed: %v", err)
+	}
+}
+
+func TestExecutor_EvaluateFilter_RichResult(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	code := `
+	klines := data.Klines["5m"]
+	last := klines[len(klines)-1].Close
+	return types.FilterResult{
+		Matched:  last > 2,
+		Score:    last * 10,
+		Tags:     []string{"breakout"},
+		Metadata: map[string]interface{}{"last": last},
+	}
+`
+	result, err := executor.EvaluateFilterWithContext(context.Background(), code, createTestMarketData("BTCUSDT", 1, 2, 3))
+	if err != nil {
+		t.Fatalf("EvaluateFilterWithContext failed: %v", err)
+	}
+	if !result.Matched || result.Score != 30 {
+		t.Errorf("result = %+v, want matched with score 30", result)
+	}
+
+	metadata := result.SignalMetadata()
+	if metadata["score"] != 30.0 || metadata["last"] != 3.0 {
+		t.Errorf("SignalMetadata() = %v", metadata)
+	}
+	if tags, ok := metadata["tags"].([]string); !ok || len(tags) != 1 || tags[0] != "breakout" {
+		t.Errorf("tags = %v, want [breakout]", metadata["tags"])
+	}
+
+	// Plain bool filters keep working and report only Matched
+	result, err = executor.EvaluateFilterWithContext(context.Background(), maFilter, createTestMarketData("BTCUSDT", 1, 2, 3))
+	if err != nil {
+		t.Fatalf("EvaluateFilterWithContext failed: %v", err)
+	}
+	if !result.Matched || len(result.SignalMetadata()) != 0 {
+		t.Errorf("result = %+v, want matched without metadata", result)
+	}
+
+	// ExecuteFilter reduces rich results to their matched flag
+	if matched, err := executor.ExecuteFilter(code, createTestMarketData("BTCUSDT", 3, 2, 1)); err != nil || matched {
+		t.Errorf("ExecuteFilter = %v, %v, want false, nil", matched, err)
+	}
+}
+
+func TestExecutor_CompileFilter_SignatureFallback(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	// A bool filter that builds a FilterResult still returns bool
+	code := `
+	result := types.FilterResult{Matched: len(data.Klines["5m"]) > 2}
+	return result.Matched
+`
+	matched, err := executor.ExecuteFilter(code, createTestMarketData("BTCUSDT", 1, 2, 3))
+	if err != nil {
+		t.Fatalf("ExecuteFilter failed: %v", err)
+	}
+	if !matched {
+		t.Error("ExecuteFilter = false, want true")
+	}
+	if signature := executor.knownSignature(code); signature != boolSignature {
+		t.Errorf("cached signature = %q, want the bool signature", signature)
+	}
+	if diagnostics := executor.AnalyzeCode(code); len(diagnostics) != 0 {
+		t.Errorf("AnalyzeCode = %+v, want no diagnostics", diagnostics)
+	}
+
+	// Bodies failing both signatures report the error of the one they meant
+	if _, err := executor.CompileFilter(`return notDefined`); err == nil || !strings.Contains(err.Error(), "notDefined") {
+		t.Errorf("CompileFilter error = %v, want the undefined name", err)
+	}
+}
+
+func TestCompileCode_SignatureFromBody(t *testing.T) {
+	policy := NewSandboxPolicy(nil)
+	compiles := 0
+	newInterpreter := func() (*interp.Interpreter, error) {
+		compiles++
+		return policy.NewInterpreter()
+	}
+
+	tests := []struct {
+		name      string
+		code      string
+		signature string
+		compiles  int
+	}{
+		{name: "bool body", code: "return true", signature: boolSignature, compiles: 1},
+		{name: "result body", code: "return types.FilterResult{Matched: true}", signature: resultSignature, compiles: 1},
+		{name: "bool body building a result", code: "r := types.FilterResult{Matched: true}\nreturn r.Matched", signature: boolSignature, compiles: 2},
+		{name: "syntax error", code: "return 1 +", signature: boolSignature, compiles: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiles = 0
+			_, signature, _ := compileCode(newInterpreter, tt.code, "")
+			if signature != tt.signature {
+				t.Errorf("signature = %q, want %q", signature, tt.signature)
+			}
+			if compiles != tt.compiles {
+				t.Errorf("compiled %d times, want %d", compiles, tt.compiles)
+			}
+		})
+	}
+}
+
+func TestExecutor_OrderBookFilter(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	code := `
+	if data.Book == nil {
+		return false
+	}
+	for _, wall := range data.Book.Walls {
+		if wall.Side == "bid" && wall.Distance < 1 {
+			return data.Book.Imbalance > 0.3
+		}
+	}
+	return false
+`
+	data := createTestMarketData("BTCUSDT", 1, 2, 3)
+	matched, err := executor.ExecuteFilter(code, data)
+	if err != nil {
+		t.Fatalf("ExecuteFilter failed: %v", err)
+	}
+	if matched {
+		t.Error("expected no match without order book features")
+	}
+
+	data.Book = &types.BookFeatures{
+		Imbalance: 0.5,
+		Walls:     []types.BookWall{{Side: "bid", Price: 2.99, Quantity: 500, Distance: 0.5}},
+	}
+	if matched, err = executor.ExecuteFilter(code, data); err != nil || !matched {
+		t.Errorf("expected a match near a bid wall, got %v (%v)", matched, err)
+	}
+}
+
+func TestExecutor_FuturesFilter(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	code := `
+	if data.Futures == nil {
+		return false
+	}
+	oiChange := indicators.OpenInterestChange(data, 2)
+	shorts := indicators.LiquidationNotional(indicators.ShortLiquidations(data, 15))
+	return data.Futures.FundingRate < 0 && oiChange != nil && *oiChange > 5 && shorts > 100000
+`
+	data := createTestMarketData("BTCUSDT", 1, 2, 3)
+	matched, err := executor.ExecuteFilter(code, data)
+	if err != nil {
+		t.Fatalf("ExecuteFilter failed: %v", err)
+	}
+	if matched {
+		t.Error("expected no match without futures data")
+	}
+
+	data.Timestamp = time.Now()
+	data.Futures = &types.FuturesContext{
+		FundingRate:      -0.0002,
+		OpenInterestHist: []types.OpenInterestPoint{{Value: 100}, {Value: 104}, {Value: 110}},
+		Liquidations: []types.Liquidation{
+			{Side: "short", Notional: 150000, Time: data.Timestamp.Add(-5 * time.Minute).UnixMilli()},
+		},
+	}
+	if matched, err = executor.ExecuteFilter(code, data); err != nil || !matched {
+		t.Errorf("expected a match on a short squeeze, got %v (%v)", matched, err)
+	}
+}
+
+func TestMarketDataFromRaw(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	// Websocket klines arrive as JSON numbers and strings with 11 fields
+	raw := func(openTime float64, close string) []interface{} {
+		return []interface{}{openTime, close, close, close, close, "10", openTime + 999, "100", float64(5), "4", "40"}
+	}
+	ticker := map[string]interface{}{"lastPrice": "3", "priceChangePercent": 1.5, "quoteVolume": "1000"}
+	klines := map[string][][]interface{}{"5m": {raw(0, "1"), raw(1000, "2"), raw(2000, "3")}}
+
+	data, err := MarketDataFromRaw("BTCUSDT", ticker, klines)
+	if err != nil {
+		t.Fatalf("MarketDataFromRaw failed: %v", err)
+	}
+	if data.Ticker.LastPrice != 3 || data.Ticker.PriceChangePercent != 1.5 || data.Ticker.QuoteVolume != 1000 {
+		t.Errorf("ticker = %+v", data.Ticker)
+	}
+	if got := data.Klines["5m"]; len(got) != 3 || got[2].Close != 3 || got[2].CloseTime != 2999 {
+		t.Errorf("klines = %+v", got)
+	}
+
+	// The same filter matches the raw data and the typed data
+	matched, err := executor.ExecuteFilter(maFilter, data)
+	if err != nil {
+		t.Fatalf("ExecuteFilter failed: %v", err)
+	}
+	if !matched {
+		t.Error("expected filter to match data built from raw klines")
+	}
+
+	if _, err := MarketDataFromRaw("BTCUSDT", ticker, map[string][][]interface{}{"5m": {{float64(0), "1"}}}); err == nil {
+		t.Error("expected error for truncated kline")
+	}
+}
+
+func TestExecutor_LegacySignal(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	// Stored fly-machine traders are full programs against raw data
+	code := `package main
+
+import (
+	"strconv"
+
+	"github.com/yourusername/trader-machine/internal/indicators"
+)
+
+func checkSignal(symbol string, ticker map[string]interface{}, klines map[string][][]interface{}) bool {
+	price, _ := strconv.ParseFloat(ticker["lastPrice"].(string), 64)
+	return symbol == "BTCUSDT" && price > 2 && indicators.GetLatestSMA(klines["5m"], 2) > 2
+}
+`
+	if !IsLegacySignal(code) || IsLegacySignal(maFilter) {
+		t.Fatal("IsLegacySignal misclassified code")
+	}
+
+	raw := func(openTime float64, close string) []interface{} {
+		return []interface{}{openTime, close, close, close, close, "10", openTime + 999, "100", float64(5), "4", "40"}
+	}
+	ticker := map[string]interface{}{"lastPrice": "3"}
+	klines := map[string][][]interface{}{"5m": {raw(0, "1"), raw(1000, "2"), raw(2000, "3")}}
+
+	matched, err := executor.ExecuteLegacySignalWithContext(context.Background(), code, "BTCUSDT", ticker, klines)
+	if err != nil {
+		t.Fatalf("ExecuteLegacySignalWithContext failed: %v", err)
+	}
+	if !matched {
+		t.Error("expected checkSignal to match raw data")
+	}
+
+	// The same program runs as a filter on typed data
+	matched, err = executor.ExecuteFilter(code, createTestMarketData("BTCUSDT", 1, 2, 3))
+	if err != nil {
+		t.Fatalf("ExecuteFilter failed: %v", err)
+	}
+	if !matched {
+		t.Error("expected checkSignal to match typed data")
+	}
+
+	if err := executor.ValidateCode(code); err != nil {
+		t.Errorf("ValidateCode failed: %v", err)
+	}
+
+	// The helpers are only importable by checkSignal programs
+	body := `
+	import "github.com/yourusername/trader-machine/internal/indicators"
+	return indicators.GetLatestSMA(nil, 2) > 0
+`
+	var importErr *ImportError
+	if err := executor.ValidateCode(body); !errors.As(err, &importErr) {
+		t.Errorf("ValidateCode error = %v, want an import error", err)
+	}
+}
+
+func TestExecutor_AnalyzeCode(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		want     []Diagnostic // only rule, line, column and severity are compared
+		hasError bool
+	}{
+		{
+			name: "clean filter",
+			code: maFilter,
+		},
+		{
+			name:     "syntax error",
+			code:     "return 1 +",
+			want:     []Diagnostic{{Line: 1, Column: 11, Severity: SeverityError, Rule: RuleSyntax}},
+			hasError: true,
+		},
+		{
+			name:     "compile error",
+			code:     "return foo",
+			want:     []Diagnostic{{Line: 1, Column: 8, Severity: SeverityError, Rule: RuleCompile}},
+			hasError: true,
+		},
+		{
+			name:     "disallowed import",
+			code:     "import \"os\"\nreturn len(os.Args) > 0",
+			want:     []Diagnostic{{Line: 1, Column: 8, Severity: SeverityError, Rule: RuleImport}},
+			hasError: true,
+		},
+		{
+			name:     "missing return",
+			code:     "if data.Ticker.LastPrice > 1 {\n\treturn true\n}",
+			want:     []Diagnostic{{Line: 1, Column: 1, Severity: SeverityError, Rule: RuleMissingReturn}},
+			hasError: true,
+		},
+		{
+			name: "unused variable",
+			code: "klines := data.Klines[\"5m\"]\nunused := 1\nunused = 2\nreturn len(klines) > 0",
+			want: []Diagnostic{{Line: 2, Column: 1, Severity: SeverityWarning, Rule: RuleUnusedVariable}},
+		},
+		{
+			name:     "index past latest kline",
+			code:     "klines := data.Klines[\"5m\"]\nreturn klines[len(klines)].Close > 0",
+			want:     []Diagnostic{{Line: 2, Column: 8, Severity: SeverityError, Rule: RuleLookahead}},
+			hasError: true,
+		},
+		{
+			name: "loop looks ahead",
+			code: "klines := data.Klines[\"5m\"]\nfor i := range klines {\n\tif klines[i+1].Close > klines[i].Close {\n\t\treturn true\n\t}\n}\nreturn false",
+			want: []Diagnostic{{Line: 3, Column: 5, Severity: SeverityWarning, Rule: RuleLookahead}},
+		},
+		{
+			name: "bounded loop reads next kline",
+			code: "klines := data.Klines[\"5m\"]\nfor i := 0; i < len(klines)-1; i++ {\n\tif klines[i+1].Close > klines[i].Close {\n\t\treturn true\n\t}\n}\nreturn false",
+		},
+		{
+			name:     "unbounded loop",
+			code:     "for {\n\tif data.Ticker.LastPrice > 0 {\n\t\tcontinue\n\t}\n}",
+			want:     []Diagnostic{{Line: 1, Column: 1, Severity: SeverityError, Rule: RuleInfiniteLoop}},
+			hasError: true,
+		},
+		{
+			name: "loop with break",
+			code: "n := 0\nfor {\n\tn++\n\tif n > 3 {\n\t\tbreak\n\t}\n}\nreturn n > 3",
+		},
+		{
+			name:     "unknown indicator",
+			code:     "return indicators.CalculateRSl(data.Klines[\"5m\"], 14) != nil",
+			want:     []Diagnostic{{Line: 1, Column: 8, Severity: SeverityError, Rule: RuleUnknownIndicator}},
+			hasError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := executor.AnalyzeCode(tt.code)
+			if HasErrors(diagnostics) != tt.hasError {
+				t.Errorf("HasErrors = %v, want %v (%+v)", !tt.hasError, tt.hasError, diagnostics)
+			}
+			if len(diagnostics) != len(tt.want) {
+				t.Fatalf("diagnostics = %+v, want %+v", diagnostics, tt.want)
+			}
+			for idx, want := range tt.want {
+				got := diagnostics[idx]
+				got.Message = ""
+				if got != want {
+					t.Errorf("diagnostic %d = %+v (%s), want %+v", idx, got, diagnostics[idx].Message, want)
+				}
+			}
+		})
+	}
+
+	// Unknown helpers come with a suggestion
+	diagnostics := executor.AnalyzeCode("return indicators.CalculateRSl(data.Klines[\"5m\"], 14) != nil")
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "did you mean indicators.CalculateRSI?") {
+		t.Errorf("diagnostics = %+v, want a suggestion for CalculateRSI", diagnostics)
+	}
+}
+
+func TestExecutor_AnalyzeCode_LegacySignal(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	// Stored checkSignal programs often have no package clause
+	code := `import "github.com/yourusername/trader-machine/internal/indicators"
+
+func checkSignal(symbol string, ticker map[string]interface{}, klines map[string][][]interface{}) bool {
+	return indicators.GetLatestSMA(klines["5m"], 2) > 2
+}
+`
+	if diagnostics := executor.AnalyzeCode(code); len(diagnostics) != 0 {
+		t.Errorf("diagnostics = %+v, want none", diagnostics)
+	}
+	if err := executor.ValidateCode(code); err != nil {
+		t.Errorf("ValidateCode failed: %v", err)
+	}
+
+	broken := strings.Replace(code, "> 2", "> undefinedThreshold", 1)
+	diagnostics := executor.AnalyzeCode(broken)
+	if len(diagnostics) != 1 || diagnostics[0].Rule != RuleCompile || diagnostics[0].Line != 4 {
+		t.Errorf("diagnostics = %+v, want a compile error on line 4", diagnostics)
+	}
+}
+
+func TestTracer_Evaluate(t *testing.T) {
+	// fmt is not allowed by default
+	executor, err := NewExecutorWithPolicy(NewSandboxPolicy(append([]string{"fmt"}, DefaultAllowedImports...)))
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	code := `import "fmt"
+	klines := data.Klines["5m"]
+	ma := indicators.CalculateMA(klines, 2)
+	fmt.Println("ma", *ma)
+	return *ma > 2
+`
+	tracer, err := executor.NewTracer(code)
+	if err != nil {
+		t.Fatalf("NewTracer failed: %v", err)
+	}
+
+	result, err := tracer.Evaluate(context.Background(), createTestMarketData("ETHUSDT", 1, 2, 3), 0)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if !result.Matched {
+		t.Error("expected filter to match")
+	}
+	if _, err := tracer.Evaluate(context.Background(), createTestMarketData("BTCUSDT", 3, 2, 1), 0); err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+
+	traces := tracer.Traces()
+	if len(traces) != 2 || traces[0].Symbol != "BTCUSDT" || traces[1].Symbol != "ETHUSDT" {
+		t.Fatalf("traces = %+v, want one per symbol ordered by symbol", traces)
+	}
+
+	trace := traces[1]
+	if len(trace.Calls) != 1 || trace.Calls[0].Helper != "CalculateMA" {
+		t.Fatalf("calls = %+v, want one CalculateMA call", trace.Calls)
+	}
+	if got := trace.Calls[0].Result; got != 2.5 {
+		t.Errorf("CalculateMA result = %v, want 2.5", got)
+	}
+	if got := trace.Calls[0].Params[1]; got != 2 {
+		t.Errorf("CalculateMA period = %v, want 2", got)
+	}
+	if trace.Output != "ma 2.5\n" {
+		t.Errorf("output = %q, want %q", trace.Output, "ma 2.5\n")
+	}
+	if !trace.Result.Matched || traces[0].Result.Matched {
+		t.Errorf("results = %v/%v, want true for ETHUSDT only", trace.Result.Matched, traces[0].Result.Matched)
+	}
+
+	// Output of untraced runs is discarded
+	if _, err := executor.ExecuteFilter(code, createTestMarketData("ETHUSDT", 1, 2, 3)); err != nil {
+		t.Fatalf("ExecuteFilter failed: %v", err)
+	}
+}
+
+func TestTracer_Evaluate_TimeoutOnlyFailsThatRun(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	tracer, err := executor.NewTracer(`
+	for data.Symbol == "SLOWUSDT" {
+	}
+	return true
+`)
+	if err != nil {
+		t.Fatalf("NewTracer failed: %v", err)
+	}
+
+	if _, err := tracer.Evaluate(context.Background(), createTestMarketData("SLOWUSDT", 1), 50*time.Millisecond); !errors.Is(err, ErrExecutionTimeout) {
+		t.Fatalf("Evaluate error = %v, want ErrExecutionTimeout", err)
+	}
+
+	// Later symbols run on a fresh interpreter
+	result, err := tracer.Evaluate(context.Background(), createTestMarketData("BTCUSDT", 1), 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Evaluate after timeout failed: %v", err)
+	}
+	if !result.Matched {
+		t.Error("expected filter to match after an earlier run timed out")
+	}
+}
+
+// memoryPersister is a StatePersister backed by a map
+type memoryPersister struct {
+	records map[string]types.SymbolStateRecord // traderID/symbol -> record
+	saveErr error                              // returned by SaveSymbolStates when set
+}
+
+func (p *memoryPersister) LoadSymbolStates(ctx context.Context, traderID string) ([]types.SymbolStateRecord, error) {
+	var records []types.SymbolStateRecord
+	for _, record := range p.records {
+		if record.TraderID == traderID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (p *memoryPersister) SaveSymbolStates(ctx context.Context, records []types.SymbolStateRecord) error {
+	if p.saveErr != nil {
+		return p.saveErr
+	}
+	for _, record := range records {
+		p.records[record.TraderID+"/"+record.Symbol] = record
+	}
+	return nil
+}
+
+func (p *memoryPersister) DeleteSymbolStates(ctx context.Context, traderID, symbol string) error {
+	for key, record := range p.records {
+		if record.TraderID == traderID && (symbol == "" || record.Symbol == symbol) {
+			delete(p.records, key)
+		}
+	}
+	return nil
+}
+
+func TestExecutor_StatefulFilter(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	// Fire only once per breakout above 2
+	code := `
+	klines := data.Klines["5m"]
+	above := klines[len(klines)-1].Close > 2
+	fired := data.State.GetBool("fired")
+	data.State.Set("fired", above)
+	data.State.Set("runs", data.State.GetFloat("runs")+1)
+	return above && !fired
+`
+	persister := &memoryPersister{records: make(map[string]types.SymbolStateRecord)}
+	store := NewStateStore(0, 0)
+	store.SetPersister(persister)
+
+	run := func(closes ...float64) bool {
+		data := createTestMarketData("BTCUSDT", closes...)
+		data.State = store.Get("trader-1", "BTCUSDT")
+		matched, err := executor.ExecuteFilter(code, data)
+		if err != nil {
+			t.Fatalf("ExecuteFilter failed: %v", err)
+		}
+		return matched
+	}
+
+	if !run(1, 2, 3) {
+		t.Error("first breakout should fire")
+	}
+	if run(1, 2, 3) {
+		t.Error("same breakout should not fire twice")
+	}
+
+	// State survives a restart through the persister
+	if err := store.Flush(context.Background(), "trader-1"); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	store = NewStateStore(0, 0)
+	store.SetPersister(persister)
+	if err := store.Restore(context.Background(), "trader-1"); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if run(1, 2, 3) {
+		t.Error("restored state should remember the breakout")
+	}
+	if got := store.Snapshot("trader-1")["BTCUSDT"]["runs"]; got != 3.0 {
+		t.Errorf("runs = %v, want 3", got)
+	}
+
+	// After a reset the next breakout fires again
+	if err := store.Reset(context.Background(), "trader-1", "BTCUSDT"); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if len(persister.records) != 0 {
+		t.Errorf("persisted records = %d after reset, want 0", len(persister.records))
+	}
+	if !run(1, 2, 3) {
+		t.Error("breakout should fire after reset")
+	}
+}
+
+func TestStateStore_FlushRetriesFailedSave(t *testing.T) {
+	persister := &memoryPersister{records: make(map[string]types.SymbolStateRecord), saveErr: errors.New("database down")}
+	store := NewStateStore(0, 0)
+	store.SetPersister(persister)
+
+	if err := store.Get("trader-1", "BTCUSDT").Set("count", 1); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := store.Flush(context.Background(), "trader-1"); err == nil {
+		t.Fatal("Flush succeeded with a failing persister")
+	}
+
+	// The unsaved change is still pending and goes out with the next flush
+	persister.saveErr = nil
+	if err := store.Flush(context.Background(), "trader-1"); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if record, ok := persister.records["trader-1/BTCUSDT"]; !ok || string(record.State["count"]) != "1" {
+		t.Errorf("persisted records = %+v, want BTCUSDT count 1", persister.records)
+	}
+}
+
+func TestSymbolState_Limits(t *testing.T) {
+	state := types.NewSymbolState(2, 32)
+
+	if err := state.Set("a", 1); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := state.Set("b", "short"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := state.Set("c", 3); !errors.Is(err, types.ErrStateLimit) {
+		t.Errorf("third key err = %v, want ErrStateLimit", err)
+	}
+	if err := state.Set("b", strings.Repeat("x", 40)); !errors.Is(err, types.ErrStateLimit) {
+		t.Errorf("oversized value err = %v, want ErrStateLimit", err)
+	}
+	if got := state.GetString("b"); got != "short" {
+		t.Errorf("b = %q after rejected write, want %q", got, "short")
+	}
+
+	// Overwriting and deleting free up room
+	state.Delete("a")
+	if err := state.Set("c", 3); err != nil {
+		t.Errorf("Set after Delete failed: %v", err)
+	}
+
+	var missing *types.SymbolState
+	if missing.Get("a") != nil || missing.Set("a", 1) == nil {
+		t.Error("nil state should read empty and reject writes")
+	}
+}
+
+func TestStateStore_Copy(t *testing.T) {
+	store := NewStateStore(0, 0)
+	if err := store.Get("trader", "BTCUSDT").Set("count", 1); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Dry runs see the state but their writes don't reach the store
+	copied := store.Copy("trader", "BTCUSDT")
+	if got := copied.GetFloat("count"); got != 1 {
+		t.Errorf("copied count = %v, want 1", got)
+	}
+	if err := copied.Set("count", 2); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := store.Get("trader", "BTCUSDT").GetFloat("count"); got != 1 {
+		t.Errorf("stored count = %v after writing the copy, want 1", got)
+	}
+
+	if snapshot := store.Snapshot("trader"); len(snapshot) != 1 {
+		t.Errorf("snapshot = %v, want only BTCUSDT", snapshot)
+	}
+	if copied := store.Copy("trader", "ETHUSDT"); len(copied.Keys()) != 0 {
+		t.Errorf("copy of missing state has keys %v", copied.Keys())
+	}
+	if len(store.Snapshot("trader")) != 1 {
+		t.Error("copying missing state should not create it")
+	}
+}
+
+func TestExecutor_StrategyModule(t *testing.T) {
+	executor, err := NewExecutor()
+	if err != nil {
+		t.Fatalf("NewExecutor failed: %v", err)
+	}
+
+	code := `import "math"
+
+func closes(data *types.MarketData) []float64 {
+	klines := data.Klines["5m"]
+	values := make([]float64, len(klines))
+	for i, k := range klines {
+		values[i] = k.Close
+	}
+	return values
+}
+
+func evaluate(data *types.MarketData) bool {
+	values := closes(data)
+	data.Computed["closes"] = values
+	return math.Max(values[len(values)-1], 0) > 2
+}
+
+func calculateSeries(data *types.MarketData) map[string]interface{} {
+	values, ok := data.Computed["closes"].([]float64)
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{"close": values}
+}
+`
+	if !IsModule(code) {
+		t.Fatal("code with a top-level evaluate should be a module")
+	}
+	if IsModule("return data.Ticker.LastPrice > 0") {
+		t.Error("filter body should not be a module")
+	}
+
+	data := createTestMarketData("BTCUSDT", 1, 2, 3)
+	matched, err := executor.ExecuteFilter(code, data)
+	if err != nil {
+		t.Fatalf("ExecuteFilter failed: %v", err)
+	}
+	if !matched {
+		t.Error("module filter should match")
+	}
+
+	// The series reuse the closes the filter computed
+	series, err := executor.CalculateSeriesWithContext(context.Background(), code, data)
+	if err != nil {
+		t.Fatalf("CalculateSeriesWithContext failed: %v", err)
+	}
+	if got, _ := series["close"].([]float64); len(got) != 3 || got[2] != 3 {
+		t.Errorf("series close = %v, want [1 2 3]", series["close"])
+	}
+	if stats := executor.CacheStats(); stats.Compilations != 1 {
+		t.Errorf("compilations = %d, want 1", stats.Compilations)
+	}
+
+	// Filter bodies and modules without calculateSeries have no series
+	if _, err := executor.CalculateSeriesWithContext(context.Background(), "return true", data); !errors.Is(err, ErrNoSeriesFunc) {
+		t.Errorf("filter body err = %v, want ErrNoSeriesFunc", err)
+	}
+
+	// Diagnostics point at the module as written
+	diagnostics := executor.AnalyzeCode("func evaluate(data *types.MarketData) bool {\n\tif true {\n\t\treturn true\n\t}\n}\n")
+	if len(diagnostics) != 1 || diagnostics[0].Rule != RuleMissingReturn || diagnostics[0].Line != 2 || diagnostics[0].Column != 2 {
+		t.Errorf("diagnostics = %+v, want missing return at 2:2", diagnostics)
+	}
+}
@@ -0,0 +1,221 @@
+package yaegi
+
+import (
+	"context"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/traefik/yaegi/interp"
+	"github.com/vyx/go-screener/pkg/types"
+	"github.com/vyx/go-screener/pkg/yaegi/legacy"
+)
+
+// Legacy checkSignal code
+//
+// Before traders shared the evaluate contract, fly-machine ran full programs defining
+//
+//	func checkSignal(symbol string, ticker map[string]interface{}, klines map[string][][]interface{}) bool
+//
+// on raw Binance data, with raw-kline helpers imported from LegacyIndicatorsImportPath.
+// Stored traders written that way still compile: the program is evaluated as is, with
+// the helpers from pkg/yaegi/legacy, and checkSignal is adapted to a FilterFunc
+
+// LegacyIndicatorsImportPath is the helper package checkSignal code imports
+const LegacyIndicatorsImportPath = "github.com/yourusername/trader-machine/internal/indicators"
+
+// legacySignature marks cached code compiled as a checkSignal program
+const legacySignature = "func checkSignal(symbol string, ticker map[string]interface{}, klines map[string][][]interface{}) bool"
+
+// LegacySignalFunc is a compiled checkSignal function
+type LegacySignalFunc func(symbol string, ticker map[string]interface{}, klines map[string][][]interface{}) bool
+
+// IsLegacySignal reports whether the code is a checkSignal program rather than an
+// evaluate filter body or module
+func IsLegacySignal(code string) bool {
+	src := []byte(code)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+
+	depth := 0
+	prev := token.ILLEGAL
+	for {
+		_, tok, lit := s.Scan()
+		switch tok {
+		case token.EOF:
+			return false
+		case token.LBRACE, token.LPAREN, token.LBRACK:
+			depth++
+		case token.RBRACE, token.RPAREN, token.RBRACK:
+			depth--
+		case token.IDENT:
+			if depth == 0 && prev == token.FUNC && lit == "checkSignal" {
+				return true
+			}
+		}
+		prev = tok
+	}
+}
+
+// legacyHeader returns the package clause a checkSignal program is compiled with when
+// it has none, so the code as written parses as a file
+func legacyHeader(code string) string {
+	src := []byte(code)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+	if _, tok, _ := s.Scan(); tok == token.PACKAGE {
+		return ""
+	}
+	return "package main\n"
+}
+
+// legacySymbols returns the helpers checkSignal code imports
+func legacySymbols() interp.Exports {
+	return interp.Exports{
+		LegacyIndicatorsImportPath + "/indicators": {
+			"GetCloses":                 reflect.ValueOf(legacy.GetCloses),
+			"GetOpens":                  reflect.ValueOf(legacy.GetOpens),
+			"GetHighs":                  reflect.ValueOf(legacy.GetHighs),
+			"GetLows":                   reflect.ValueOf(legacy.GetLows),
+			"GetVolumes":                reflect.ValueOf(legacy.GetVolumes),
+			"GetLatestSMA":              reflect.ValueOf(legacy.GetLatestSMA),
+			"GetLatestEMA":              reflect.ValueOf(legacy.GetLatestEMA),
+			"GetLatestWMA":              reflect.ValueOf(legacy.GetLatestWMA),
+			"GetLatestVWAP":             reflect.ValueOf(legacy.GetLatestVWAP),
+			"GetLatestRSI":              reflect.ValueOf(legacy.GetLatestRSI),
+			"GetLatestMACD":             reflect.ValueOf(legacy.GetLatestMACD),
+			"GetLatestStochastic":       reflect.ValueOf(legacy.GetLatestStochastic),
+			"GetLatestCCI":              reflect.ValueOf(legacy.GetLatestCCI),
+			"GetLatestWilliamsR":        reflect.ValueOf(legacy.GetLatestWilliamsR),
+			"GetLatestROC":              reflect.ValueOf(legacy.GetLatestROC),
+			"GetLatestBollingerBands":   reflect.ValueOf(legacy.GetLatestBollingerBands),
+			"GetLatestATR":              reflect.ValueOf(legacy.GetLatestATR),
+			"GetLatestKeltnerChannels":  reflect.ValueOf(legacy.GetLatestKeltnerChannels),
+			"GetLatestDonchianChannels": reflect.ValueOf(legacy.GetLatestDonchianChannels),
+			"GetLatestVolume":           reflect.ValueOf(legacy.GetLatestVolume),
+			"GetLatestVolumeChange":     reflect.ValueOf(legacy.GetLatestVolumeChange),
+			"GetLatestOBV":              reflect.ValueOf(legacy.GetLatestOBV),
+			"GetLatestVolumeMA":         reflect.ValueOf(legacy.GetLatestVolumeMA),
+			"GetLatestADX":              reflect.ValueOf(legacy.GetLatestADX),
+			"GetLatestAroon":            reflect.ValueOf(legacy.GetLatestAroon),
+			"GetPriceChange":            reflect.ValueOf(legacy.GetPriceChange),
+			"GetPriceChangePercent":     reflect.ValueOf(legacy.GetPriceChangePercent),
+			"GetHighestHigh":            reflect.ValueOf(legacy.GetHighestHigh),
+			"GetLowestLow":              reflect.ValueOf(legacy.GetLowestLow),
+		},
+	}
+}
+
+// compileLegacy evaluates a checkSignal program in a fresh interpreter with the legacy helpers
+func compileLegacy(newInterpreter func() (*interp.Interpreter, error), code string) (*interp.Interpreter, string, error) {
+	i, err := newInterpreter()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := i.Use(legacySymbols()); err != nil {
+		return nil, "", fmt.Errorf("failed to load legacy symbols: %w", err)
+	}
+	if _, err := i.Eval(legacyHeader(code) + code); err != nil {
+		return nil, legacySignature, err
+	}
+	return i, legacySignature, nil
+}
+
+// loadLegacySignal returns the checkSignal function compiled in the interpreter and
+// a filter running it on raw data rebuilt from MarketData
+func loadLegacySignal(i *interp.Interpreter) (FilterFunc, LegacySignalFunc, error) {
+	v, err := i.Eval("checkSignal")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get checkSignal function: %w", err)
+	}
+
+	fn, ok := v.Interface().(func(string, map[string]interface{}, map[string][][]interface{}) bool)
+	if !ok {
+		return nil, nil, fmt.Errorf("checkSignal must be %s", legacySignature)
+	}
+
+	filter := func(data *types.MarketData) types.FilterResult {
+		return types.FilterResult{Matched: fn(data.Symbol, RawTicker(data.Ticker), RawKlines(data.Klines))}
+	}
+	return filter, fn, nil
+}
+
+// ExecuteLegacySignalWithContext runs checkSignal code on raw Binance data until it
+// returns or ctx ends. The data is passed through unconverted, so programs see the
+// exact ticker and kline values they were written against
+func (e *Executor) ExecuteLegacySignalWithContext(ctx context.Context, code, symbol string, ticker map[string]interface{}, klines map[string][][]interface{}) (bool, error) {
+	compiled, err := e.compile(code)
+	if err != nil {
+		return false, err
+	}
+	if compiled.legacy == nil {
+		return false, fmt.Errorf("code does not define checkSignal")
+	}
+
+	var matched bool
+	err = RunWithContext(ctx, "filter", func() { e.abortFilter(compiled) }, func() {
+		matched = compiled.legacy(symbol, ticker, klines)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if atomic.LoadInt32(&compiled.aborted) == 1 {
+		return false, ErrExecutionAborted
+	}
+
+	return matched, nil
+}
+
+// RawTicker rebuilds a raw 24h ticker from filter input, with prices as strings
+// like Binance sends them
+func RawTicker(ticker *types.SimplifiedTicker) map[string]interface{} {
+	if ticker == nil {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"lastPrice":          formatFloat(ticker.LastPrice),
+		"priceChangePercent": formatFloat(ticker.PriceChangePercent),
+		"quoteVolume":        formatFloat(ticker.QuoteVolume),
+	}
+}
+
+// RawKlines rebuilds raw Binance kline arrays from filter input
+// Times and trade counts are JSON numbers and prices strings, as decoded from the API
+func RawKlines(klines map[string][]types.Kline) map[string][][]interface{} {
+	raw := make(map[string][][]interface{}, len(klines))
+	for interval, series := range klines {
+		rows := make([][]interface{}, len(series))
+		for idx, k := range series {
+			rows[idx] = []interface{}{
+				float64(k.OpenTime),
+				formatFloat(k.Open),
+				formatFloat(k.High),
+				formatFloat(k.Low),
+				formatFloat(k.Close),
+				formatFloat(k.Volume),
+				float64(k.CloseTime),
+				formatFloat(k.QuoteVolume),
+				float64(k.Trades),
+				formatFloat(k.BuyVolume),
+				formatFloat(k.TakerBuyQuoteAssetVolume),
+			}
+		}
+		raw[interval] = rows
+	}
+	return raw
+}
+
+// formatFloat renders a float the way Binance encodes decimal strings
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -1,4 +1,6 @@
-package indicators
+// Package legacy holds the raw-kline indicator helpers that checkSignal code imported
+// before traders moved to the evaluate(data *types.MarketData) contract
+package legacy
 
 import (
 	"fmt"
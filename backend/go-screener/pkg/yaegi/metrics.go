@@ -0,0 +1,76 @@
+package yaegi
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics for user code compilation and execution
+var (
+	FilterCacheHits = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "filter_cache_hits_total",
+			Help: "Total number of filter executions served from the compiled filter cache",
+		},
+	)
+
+	FilterCacheMisses = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "filter_cache_misses_total",
+			Help: "Total number of filter executions that required compilation",
+		},
+	)
+
+	FilterCacheEvictions = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "filter_cache_evictions_total",
+			Help: "Total number of compiled filters dropped from the cache",
+		},
+	)
+
+	FilterCacheEntries = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "filter_cache_entries",
+			Help: "Number of compiled filters currently cached",
+		},
+	)
+
+	FilterCompilations = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "filter_compilations_total",
+			Help: "Total number of successful filter compilations",
+		},
+	)
+
+	FilterCompileErrors = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "filter_compile_errors_total",
+			Help: "Total number of filter compilation failures",
+		},
+	)
+
+	FilterCompileDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "filter_compile_duration_seconds",
+			Help:    "Duration of filter compilations",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	// Cancellation metrics
+	AbandonedExecutions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "user_code_abandoned_executions_total",
+			Help: "Total number of user code executions abandoned after their context ended",
+		},
+		[]string{"kind"},
+	)
+
+	AbandonedInFlight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "user_code_abandoned_inflight",
+			Help: "Abandoned user code executions whose goroutine has not exited yet",
+		},
+		[]string{"kind"},
+	)
+)
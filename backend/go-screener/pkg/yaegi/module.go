@@ -0,0 +1,225 @@
+package yaegi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"sync/atomic"
+
+	"github.com/traefik/yaegi/interp"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Strategy modules
+//
+// Trader code is either a filter body (the body of evaluate) or a strategy module:
+// one source unit declaring evaluate, optionally calculateSeries, and any helper
+// functions both share
+//
+//	import "math"
+//
+//	func rsi(data *types.MarketData) []float64 { ... }
+//
+//	func evaluate(data *types.MarketData) bool {
+//		series := rsi(data)
+//		data.Computed["rsi"] = series
+//		return series[len(series)-1] < 30
+//	}
+//
+//	func calculateSeries(data *types.MarketData) map[string]interface{} {
+//		return map[string]interface{}{"rsi": toPoints(data.Computed["rsi"].([]float64))}
+//	}
+//
+// The module is compiled once. For a matched symbol calculateSeries receives the same
+// MarketData evaluate ran on, so values the filter stored in data.Computed are reused
+// instead of calculated again. The types and indicators packages are imported for you
+
+// SeriesFunc is a compiled calculateSeries function of a strategy module
+type SeriesFunc func(*types.MarketData) map[string]interface{}
+
+// ErrNoSeriesFunc is returned when asking for series of code that doesn't define calculateSeries
+var ErrNoSeriesFunc = errors.New("strategy has no calculateSeries function")
+
+// IsModule reports whether the code is a strategy module rather than a filter body
+// Modules declare evaluate as a top-level function
+func IsModule(code string) bool {
+	src := []byte(code)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+
+	depth := 0
+	prev := token.ILLEGAL
+	for {
+		_, tok, lit := s.Scan()
+		switch tok {
+		case token.EOF:
+			return false
+		case token.LBRACE, token.LPAREN, token.LBRACK:
+			depth++
+		case token.RBRACE, token.RPAREN, token.RBRACK:
+			depth--
+		case token.IDENT:
+			if depth == 0 && prev == token.FUNC && lit == "evaluate" {
+				return true
+			}
+		}
+		prev = tok
+	}
+}
+
+// compileCode compiles trader code in a fresh interpreter from newInterpreter and
+// returns it with the signature a filter body compiled with ("" for modules).
+// A body naming types.FilterResult is compiled returning it, any other body returning
+// bool, and the other signature is only tried if that fails to type-check; a known
+// signature is compiled alone. On failure the first signature's error is returned with
+// it. checkSignal programs compile as is and return legacySignature
+func compileCode(newInterpreter func() (*interp.Interpreter, error), code, signature string) (*interp.Interpreter, string, error) {
+	if IsLegacySignal(code) {
+		return compileLegacy(newInterpreter, code)
+	}
+
+	if IsModule(code) {
+		i, err := newInterpreter()
+		if err != nil {
+			return nil, "", err
+		}
+		header, body := wrapModule(code)
+		if _, err := i.Eval(header + body); err != nil {
+			return nil, "", err
+		}
+		return i, "", nil
+	}
+
+	fallback := ""
+	switch {
+	case signature != "":
+	case usesFilterResult(code):
+		signature, fallback = resultSignature, boolSignature
+	default:
+		signature, fallback = boolSignature, resultSignature
+	}
+
+	i, err := compileBody(newInterpreter, signature, code)
+	var syntaxErr scanner.ErrorList
+	if err == nil || fallback == "" || errors.As(err, &syntaxErr) {
+		return i, signature, err
+	}
+	// A failed compilation leaves declarations behind, so the fallback starts fresh
+	if i, fallbackErr := compileBody(newInterpreter, fallback, code); fallbackErr == nil {
+		return i, fallback, nil
+	}
+	return nil, signature, err
+}
+
+// compileBody compiles a filter body wrapped in the signature in a fresh interpreter
+func compileBody(newInterpreter func() (*interp.Interpreter, error), signature, code string) (*interp.Interpreter, error) {
+	i, err := newInterpreter()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := i.Eval(WrapFunc(signature, code)); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// wrapModule returns the generated package header and the module code that follows it
+// The module's imports are merged into the header's import block; their text is
+// blanked in place so line numbers are unchanged
+func wrapModule(code string) (string, string) {
+	specs, body := hoistImports(code)
+	header := fmt.Sprintf(`package main
+
+import (
+	"github.com/vyx/go-screener/pkg/types"
+	"github.com/vyx/go-screener/pkg/indicators"
+%s)
+`, formatImports(specs))
+	return header, body
+}
+
+// loadStrategy returns the evaluate function of trader code compiled in the interpreter
+// and, for modules that define one, its calculateSeries function
+func loadStrategy(i *interp.Interpreter, code string) (FilterFunc, SeriesFunc, error) {
+	v, err := i.Eval("evaluate")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get evaluate function: %w", err)
+	}
+
+	var evaluate FilterFunc
+	switch fn := v.Interface().(type) {
+	case func(*types.MarketData) types.FilterResult:
+		evaluate = fn
+	case func(*types.MarketData) bool:
+		evaluate = func(data *types.MarketData) types.FilterResult {
+			return types.FilterResult{Matched: fn(data)}
+		}
+	default:
+		return nil, nil, fmt.Errorf("evaluate is not the correct type")
+	}
+
+	// Filters can always store values for calculateSeries
+	filter := func(data *types.MarketData) types.FilterResult {
+		if data.Computed == nil {
+			data.Computed = make(map[string]interface{})
+		}
+		return evaluate(data)
+	}
+
+	if !IsModule(code) {
+		return filter, nil, nil
+	}
+
+	v, err = i.Eval("calculateSeries")
+	if err != nil {
+		return filter, nil, nil // Series are optional
+	}
+	fn, ok := v.Interface().(func(*types.MarketData) map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("calculateSeries must be func(data *types.MarketData) map[string]interface{}")
+	}
+
+	return filter, fn, nil
+}
+
+// HasSeries reports whether the trader code defines calculateSeries
+func (e *Executor) HasSeries(code string) (bool, error) {
+	compiled, err := e.compile(code)
+	if err != nil {
+		return false, err
+	}
+	return compiled.series != nil, nil
+}
+
+// CalculateSeriesWithContext runs the calculateSeries function of a strategy module
+// It uses the same compilation as the filter, so pass the MarketData the filter ran
+// on to reuse the values it computed. Returns ErrNoSeriesFunc if there is none
+func (e *Executor) CalculateSeriesWithContext(ctx context.Context, code string, data *types.MarketData) (map[string]interface{}, error) {
+	compiled, err := e.compile(code)
+	if err != nil {
+		return nil, err
+	}
+	if compiled.series == nil {
+		return nil, ErrNoSeriesFunc
+	}
+
+	var result map[string]interface{}
+	err = RunWithContext(ctx, "series", func() { e.abortFilter(compiled) }, func() {
+		result = compiled.series(data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The interpreter was stopped while we were running; the result is not real
+	if atomic.LoadInt32(&compiled.aborted) == 1 {
+		return nil, ErrExecutionAborted
+	}
+
+	return result, nil
+}
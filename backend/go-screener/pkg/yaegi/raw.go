@@ -0,0 +1,63 @@
+package yaegi
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// MarketDataFromRaw builds filter input from raw Binance data
+// klines maps intervals to raw kline arrays; ticker holds 24h ticker fields as
+// numbers or numeric strings (lastPrice, priceChangePercent, quoteVolume)
+func MarketDataFromRaw(symbol string, ticker map[string]interface{}, klines map[string][][]interface{}) (*types.MarketData, error) {
+	data := &types.MarketData{
+		Symbol:    symbol,
+		Ticker:    &types.SimplifiedTicker{},
+		Klines:    make(map[string][]types.Kline, len(klines)),
+		Timestamp: time.Now(),
+	}
+
+	for interval, raw := range klines {
+		parsed, err := types.ParseKlines(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s klines for %s: %w", interval, symbol, err)
+		}
+		data.Klines[interval] = parsed
+	}
+
+	fields := []struct {
+		key string
+		dst *float64
+	}{
+		{"lastPrice", &data.Ticker.LastPrice},
+		{"priceChangePercent", &data.Ticker.PriceChangePercent},
+		{"quoteVolume", &data.Ticker.QuoteVolume},
+	}
+	for _, field := range fields {
+		value, ok := ticker[field.key]
+		if !ok || value == nil {
+			continue
+		}
+		parsed, err := toFloat(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticker %s for %s: %w", field.key, symbol, err)
+		}
+		*field.dst = parsed
+	}
+
+	return data, nil
+}
+
+// toFloat converts a JSON number or numeric string to float64
+func toFloat(v interface{}) (float64, error) {
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case string:
+		return strconv.ParseFloat(val, 64)
+	default:
+		return 0, fmt.Errorf("cannot parse float from %T", v)
+	}
+}
@@ -0,0 +1,277 @@
+package yaegi
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/traefik/yaegi/interp"
+	"github.com/traefik/yaegi/stdlib"
+)
+
+// Import paths of our own packages exposed to user code through GetCustomSymbols
+const (
+	TypesImportPath      = "github.com/vyx/go-screener/pkg/types"
+	IndicatorsImportPath = "github.com/vyx/go-screener/pkg/indicators"
+)
+
+// DefaultAllowedImports are the standard library packages user code may import
+var DefaultAllowedImports = []string{"math", "sort", "strings", "strconv", "time"}
+
+// SandboxPolicy decides which packages user code may import
+// Only symbols of allowed packages are loaded into the interpreter, and imports are
+// checked before compilation so users get a clear error instead of an unresolved package
+type SandboxPolicy struct {
+	allowed map[string]bool
+}
+
+// NewSandboxPolicy creates a policy allowing the given standard library packages
+// The types and indicators packages are always allowed since filters are wrapped with them
+func NewSandboxPolicy(allowedImports []string) *SandboxPolicy {
+	allowed := map[string]bool{
+		TypesImportPath:      true,
+		IndicatorsImportPath: true,
+	}
+	for _, path := range allowedImports {
+		if path = strings.TrimSpace(path); path != "" {
+			allowed[path] = true
+		}
+	}
+	return &SandboxPolicy{allowed: allowed}
+}
+
+// DefaultSandboxPolicy returns the policy used when none is configured
+func DefaultSandboxPolicy() *SandboxPolicy {
+	return NewSandboxPolicy(DefaultAllowedImports)
+}
+
+// Allows reports whether user code may import the package
+func (p *SandboxPolicy) Allows(path string) bool {
+	return p.allowed[path]
+}
+
+// AllowedImports returns the allowed import paths, sorted
+func (p *SandboxPolicy) AllowedImports() []string {
+	paths := make([]string, 0, len(p.allowed))
+	for path := range p.allowed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Symbols returns the standard library and custom symbols of the allowed packages
+func (p *SandboxPolicy) Symbols() interp.Exports {
+	exports := make(interp.Exports)
+	for _, symbols := range []interp.Exports{stdlib.Symbols, GetCustomSymbols()} {
+		for key, values := range symbols {
+			// Keys are "<import path>/<package name>"
+			sep := strings.LastIndex(key, "/")
+			if sep > 0 && p.Allows(key[:sep]) {
+				exports[key] = values
+			}
+		}
+	}
+	return exports
+}
+
+// NewInterpreter creates an interpreter that can only resolve the allowed packages
+// Anything user code prints is discarded
+func (p *SandboxPolicy) NewInterpreter() (*interp.Interpreter, error) {
+	return newInterpreter(p.Symbols(), io.Discard)
+}
+
+// newInterpreter creates a sandboxed interpreter with the given symbols
+// fmt.Print and the print builtins write to stdout
+func newInterpreter(symbols interp.Exports, stdout io.Writer) (*interp.Interpreter, error) {
+	// An empty source filesystem stops yaegi from importing packages from disk
+	i := interp.New(interp.Options{
+		SourcecodeFilesystem: emptyFS{},
+		Stdout:               stdout,
+		Stderr:               stdout,
+	})
+
+	if err := i.Use(symbols); err != nil {
+		return nil, fmt.Errorf("failed to load sandbox symbols: %w", err)
+	}
+
+	return i, nil
+}
+
+// CheckImports returns an *ImportError if the code imports a package outside the policy
+// checkSignal programs may also import the legacy indicator helpers
+func (p *SandboxPolicy) CheckImports(code string) error {
+	legacy := IsLegacySignal(code)
+
+	var violations []ImportViolation
+	for _, decl := range scanImports(code) {
+		for _, spec := range decl.specs {
+			if legacy && spec.path == LegacyIndicatorsImportPath {
+				continue
+			}
+			if !p.Allows(spec.path) {
+				violations = append(violations, ImportViolation{
+					Path:   spec.path,
+					Line:   spec.line,
+					Column: spec.column,
+				})
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ImportError{Violations: violations, Allowed: p.AllowedImports()}
+	}
+	return nil
+}
+
+// ImportViolation is a single disallowed import in user code
+type ImportViolation struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// ImportError is returned when user code imports packages outside the sandbox policy
+type ImportError struct {
+	Violations []ImportViolation
+	Allowed    []string
+}
+
+func (e *ImportError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for idx, v := range e.Violations {
+		parts[idx] = fmt.Sprintf("%q (line %d, column %d)", v.Path, v.Line, v.Column)
+	}
+	return fmt.Sprintf("import not allowed: %s; allowed imports: %s",
+		strings.Join(parts, ", "), strings.Join(e.Allowed, ", "))
+}
+
+// importSpec is one imported package in user code
+type importSpec struct {
+	name   string // explicit package name, if any
+	path   string
+	line   int
+	column int
+}
+
+// importDecl is an import declaration and its byte range in user code
+type importDecl struct {
+	specs      []importSpec
+	start, end int
+	leading    bool // appears before any other code
+}
+
+// scanImports finds import declarations anywhere in user code
+// It works on tokens rather than a syntax tree because filter bodies are not valid
+// Go files on their own, and we want to report imports even in code that won't parse
+func scanImports(code string) []importDecl {
+	src := []byte(code)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+
+	var decls []importDecl
+	leading := true
+
+	for {
+		pos, tok, _ := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON {
+			continue
+		}
+		if tok != token.IMPORT {
+			// package clauses may precede imports in full source files
+			if tok != token.PACKAGE && tok != token.IDENT {
+				leading = false
+			}
+			continue
+		}
+
+		decl := importDecl{start: file.Offset(pos), leading: leading}
+		grouped := false
+		name := ""
+
+	specs:
+		for {
+			pos, tok, lit := s.Scan()
+			switch tok {
+			case token.LPAREN:
+				grouped = true
+			case token.IDENT, token.PERIOD:
+				if tok == token.PERIOD {
+					lit = "."
+				}
+				name = lit
+			case token.STRING:
+				path, err := strconv.Unquote(lit)
+				if err != nil {
+					path = lit
+				}
+				position := file.Position(pos)
+				decl.specs = append(decl.specs, importSpec{
+					name:   name,
+					path:   path,
+					line:   position.Line,
+					column: position.Column,
+				})
+				decl.end = file.Offset(pos) + len(lit)
+				name = ""
+				if !grouped {
+					break specs
+				}
+			case token.SEMICOLON:
+				// Separates specs inside a group
+			case token.RPAREN:
+				decl.end = file.Offset(pos) + 1
+				break specs
+			default:
+				// Malformed declaration; stop here and let the compiler report it
+				decl.end = file.Offset(pos)
+				break specs
+			}
+		}
+
+		decls = append(decls, decl)
+	}
+
+	return decls
+}
+
+// hoistImports removes the import declarations at the top of a function body
+// so they can be moved into the wrapping file's import block
+// Removed text is replaced with spaces so line numbers in compiler errors still match
+func hoistImports(code string) ([]importSpec, string) {
+	var specs []importSpec
+	body := []byte(code)
+
+	for _, decl := range scanImports(code) {
+		if !decl.leading {
+			continue
+		}
+		specs = append(specs, decl.specs...)
+		for idx := decl.start; idx < decl.end && idx < len(body); idx++ {
+			if body[idx] != '\n' {
+				body[idx] = ' '
+			}
+		}
+	}
+
+	return specs, string(body)
+}
+
+// emptyFS is a source filesystem with no packages in it
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
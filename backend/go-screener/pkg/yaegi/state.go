@@ -0,0 +1,221 @@
+package yaegi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// StatePersister saves filter state outside the process
+type StatePersister interface {
+	LoadSymbolStates(ctx context.Context, traderID string) ([]types.SymbolStateRecord, error)
+	SaveSymbolStates(ctx context.Context, records []types.SymbolStateRecord) error
+	DeleteSymbolStates(ctx context.Context, traderID, symbol string) error
+}
+
+// StateStore holds the per-trader, per-symbol state injected into filters as data.State
+// State lives in memory; with a persister it is restored when a trader is loaded and
+// saved after each run. A nil store keeps no state and filters see a nil data.State
+type StateStore struct {
+	mu        sync.Mutex
+	traders   map[string]map[string]*types.SymbolState // traderID -> symbol -> state
+	restored  map[string]bool                          // traders whose persisted state was loaded
+	maxKeys   int
+	maxBytes  int
+	persister StatePersister
+}
+
+// NewStateStore creates an in-memory store with per-symbol limits (zero means the default)
+func NewStateStore(maxKeys, maxBytes int) *StateStore {
+	return &StateStore{
+		traders:  make(map[string]map[string]*types.SymbolState),
+		restored: make(map[string]bool),
+		maxKeys:  maxKeys,
+		maxBytes: maxBytes,
+	}
+}
+
+// SetPersister enables persistence
+func (s *StateStore) SetPersister(p StatePersister) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.persister = p
+}
+
+// Get returns the state of a trader's filter for a symbol, creating it if needed
+func (s *StateStore) Get(traderID, symbol string) *types.SymbolState {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(traderID, symbol)
+}
+
+// Copy returns a detached copy of a trader's state for a symbol
+// Changes to the copy are never stored or persisted, so dry runs can use it
+func (s *StateStore) Copy(traderID, symbol string) *types.SymbolState {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	state := s.traders[traderID][symbol]
+	s.mu.Unlock()
+
+	copied := types.NewSymbolState(s.maxKeys, s.maxBytes)
+	if state != nil {
+		copied.Restore(state.Snapshot())
+	}
+	return copied
+}
+
+func (s *StateStore) get(traderID, symbol string) *types.SymbolState {
+	symbols, ok := s.traders[traderID]
+	if !ok {
+		symbols = make(map[string]*types.SymbolState)
+		s.traders[traderID] = symbols
+	}
+	state, ok := symbols[symbol]
+	if !ok {
+		state = types.NewSymbolState(s.maxKeys, s.maxBytes)
+		symbols[symbol] = state
+	}
+	return state
+}
+
+// Snapshot returns a trader's state by symbol
+func (s *StateStore) Snapshot(traderID string) map[string]map[string]interface{} {
+	if s == nil {
+		return map[string]map[string]interface{}{}
+	}
+	s.mu.Lock()
+	symbols := make(map[string]*types.SymbolState, len(s.traders[traderID]))
+	for symbol, state := range s.traders[traderID] {
+		symbols[symbol] = state
+	}
+	s.mu.Unlock()
+
+	snapshot := make(map[string]map[string]interface{}, len(symbols))
+	for symbol, state := range symbols {
+		keys := state.Keys()
+		if len(keys) == 0 {
+			continue
+		}
+		values := make(map[string]interface{}, len(keys))
+		for _, key := range keys {
+			values[key] = state.Get(key)
+		}
+		snapshot[symbol] = values
+	}
+	return snapshot
+}
+
+// Restore loads a trader's persisted state the first time the trader is seen
+func (s *StateStore) Restore(ctx context.Context, traderID string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	persister := s.persister
+	if persister == nil || s.restored[traderID] {
+		s.mu.Unlock()
+		return nil
+	}
+	s.restored[traderID] = true
+	s.mu.Unlock()
+
+	records, err := persister.LoadSymbolStates(ctx, traderID)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.restored, traderID)
+		s.mu.Unlock()
+		return fmt.Errorf("failed to load filter state: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range records {
+		s.get(traderID, record.Symbol).Restore(record.State)
+	}
+	return nil
+}
+
+// Flush persists the symbols whose state changed since the last flush
+func (s *StateStore) Flush(ctx context.Context, traderID string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	persister := s.persister
+	symbols := make(map[string]*types.SymbolState, len(s.traders[traderID]))
+	for symbol, state := range s.traders[traderID] {
+		symbols[symbol] = state
+	}
+	s.mu.Unlock()
+
+	if persister == nil {
+		return nil
+	}
+
+	var records []types.SymbolStateRecord
+	var flushed []*types.SymbolState
+	for symbol, state := range symbols {
+		if state.TakeDirty() {
+			records = append(records, types.SymbolStateRecord{
+				TraderID: traderID,
+				Symbol:   symbol,
+				State:    state.Snapshot(),
+			})
+			flushed = append(flushed, state)
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	if err := persister.SaveSymbolStates(ctx, records); err != nil {
+		// Nothing was saved, so the next flush has to try these symbols again
+		for _, state := range flushed {
+			state.MarkDirty()
+		}
+		return fmt.Errorf("failed to save filter state: %w", err)
+	}
+	return nil
+}
+
+// Reset clears a trader's state for one symbol, or for all symbols if symbol is empty
+// Persisted state is deleted as well
+func (s *StateStore) Reset(ctx context.Context, traderID, symbol string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if symbol == "" {
+		delete(s.traders, traderID)
+	} else if symbols, ok := s.traders[traderID]; ok {
+		delete(symbols, symbol)
+	}
+	persister := s.persister
+	s.mu.Unlock()
+
+	if persister == nil {
+		return nil
+	}
+	if err := persister.DeleteSymbolStates(ctx, traderID, symbol); err != nil {
+		return fmt.Errorf("failed to delete filter state: %w", err)
+	}
+	return nil
+}
+
+// Drop forgets a trader's in-memory state; persisted state is kept
+func (s *StateStore) Drop(traderID string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.traders, traderID)
+	delete(s.restored, traderID)
+}
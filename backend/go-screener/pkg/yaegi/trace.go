@@ -0,0 +1,342 @@
+package yaegi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/traefik/yaegi/interp"
+	"github.com/vyx/go-screener/pkg/types"
+)
+
+// Limits that keep traces of chatty filters to a reasonable size
+const (
+	maxTraceCalls       = 500
+	maxTraceOutputBytes = 64 << 10 // 64 KB
+	maxTraceSliceValues = 5        // trailing values kept for long slices
+)
+
+// TraceCall is one indicator helper call made by a traced filter
+type TraceCall struct {
+	Helper string        `json:"helper"`
+	Params []interface{} `json:"params"`
+	Result interface{}   `json:"result"`
+}
+
+// Trace records how a filter reached its result for one symbol
+type Trace struct {
+	Symbol     string             `json:"symbol"`
+	Calls      []TraceCall        `json:"calls"`
+	Truncated  bool               `json:"truncated,omitempty"` // calls beyond maxTraceCalls were dropped
+	Output     string             `json:"output,omitempty"`
+	Result     types.FilterResult `json:"result"`
+	Error      string             `json:"error,omitempty"`
+	DurationMs float64            `json:"durationMs"`
+}
+
+// Tracer runs a filter in trace mode
+// The filter is compiled against an instrumented copy of the symbol table that
+// records every indicator helper call, and printed output is captured instead of
+// discarded. Runs are serialized so each trace only holds its own calls; tracers
+// are not cached and are meant for debugging, not for the hot path
+type Tracer struct {
+	mu      sync.Mutex
+	compile func() (*tracedFilter, error)
+	filter  *tracedFilter // nil after a run was stopped; the next run recompiles
+
+	tracesMu sync.Mutex
+	traces   []Trace
+}
+
+// tracedFilter is one instrumented compilation of the traced code
+// A stopped run leaves its interpreter behind along with the recorder and output
+// its abandoned goroutine may still write to
+type tracedFilter struct {
+	fn          FilterFunc
+	interpreter *interp.Interpreter
+	recorder    *traceRecorder
+	output      *limitedBuffer
+}
+
+// NewTracer compiles filter code for tracing
+func (e *Executor) NewTracer(code string) (*Tracer, error) {
+	if err := e.policy.CheckImports(code); err != nil {
+		return nil, err
+	}
+
+	signature := e.knownSignature(code)
+	compile := func() (*tracedFilter, error) {
+		recorder := &traceRecorder{}
+		output := &limitedBuffer{limit: maxTraceOutputBytes}
+
+		symbols := e.policy.Symbols()
+		key := IndicatorsImportPath + "/indicators"
+		symbols[key] = recorder.instrument(symbols[key])
+
+		newTraceInterpreter := func() (*interp.Interpreter, error) {
+			return newInterpreter(symbols, output)
+		}
+		i, sig, err := compileCode(newTraceInterpreter, code, signature)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile filter code: %w", err)
+		}
+		signature = sig
+
+		var fn FilterFunc
+		if IsLegacySignal(code) {
+			fn, _, err = loadLegacySignal(i)
+		} else {
+			fn, _, err = loadStrategy(i, code)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &tracedFilter{fn: fn, interpreter: i, recorder: recorder, output: output}, nil
+	}
+
+	filter, err := compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tracer{compile: compile, filter: filter}, nil
+}
+
+// Evaluate runs the filter like Executor.EvaluateFilterWithContext and records a trace
+// timeout bounds the run itself and starts once earlier runs of the tracer are done;
+// zero leaves the run bounded by ctx only
+func (t *Tracer) Evaluate(ctx context.Context, data *types.MarketData, timeout time.Duration) (types.FilterResult, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := time.Now()
+	var result types.FilterResult
+	var err error
+
+	// A stopped interpreter can't be trusted, so a run after a timeout recompiles
+	filter := t.filter
+	if filter == nil {
+		filter, err = t.compile()
+		t.filter = filter
+	}
+
+	var calls []TraceCall
+	var truncated bool
+	var output string
+	if err == nil {
+		runCtx := ctx
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			runCtx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		start = time.Now()
+
+		filter.recorder.reset()
+		filter.output.Reset()
+
+		var stopped int32
+		var runResult types.FilterResult
+		err = RunWithContext(runCtx, "filter", func() {
+			atomic.StoreInt32(&stopped, 1)
+			StopInterpreter(filter.interpreter)
+		}, func() {
+			runResult = filter.fn(data)
+		})
+		if err == nil {
+			result = runResult
+		}
+
+		if atomic.LoadInt32(&stopped) == 1 || errors.Is(err, context.Canceled) {
+			// Only this run is lost; the next one gets a fresh interpreter, also when
+			// a cancelled run is still finishing on the old one
+			t.filter = nil
+		}
+		calls, truncated = filter.recorder.snapshot()
+		output = filter.output.String()
+	}
+	if err != nil {
+		result = types.FilterResult{}
+	}
+
+	trace := Trace{
+		Symbol:     data.Symbol,
+		Calls:      calls,
+		Truncated:  truncated,
+		Output:     output,
+		Result:     result,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+
+	t.tracesMu.Lock()
+	t.traces = append(t.traces, trace)
+	t.tracesMu.Unlock()
+
+	return result, err
+}
+
+// Traces returns the traces recorded so far, ordered by symbol
+func (t *Tracer) Traces() []Trace {
+	t.tracesMu.Lock()
+	traces := make([]Trace, len(t.traces))
+	copy(traces, t.traces)
+	t.tracesMu.Unlock()
+
+	sort.SliceStable(traces, func(i, j int) bool { return traces[i].Symbol < traces[j].Symbol })
+	return traces
+}
+
+// traceRecorder collects the helper calls of the current traced run
+type traceRecorder struct {
+	mu        sync.Mutex
+	calls     []TraceCall
+	truncated bool
+}
+
+func (r *traceRecorder) reset() {
+	r.mu.Lock()
+	r.calls = nil
+	r.truncated = false
+	r.mu.Unlock()
+}
+
+func (r *traceRecorder) snapshot() ([]TraceCall, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([]TraceCall, len(r.calls))
+	copy(calls, r.calls)
+	return calls, r.truncated
+}
+
+func (r *traceRecorder) record(call TraceCall) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.calls) >= maxTraceCalls {
+		r.truncated = true
+		return
+	}
+	r.calls = append(r.calls, call)
+}
+
+// instrument wraps every function in a symbol table so its calls are recorded
+func (r *traceRecorder) instrument(symbols map[string]reflect.Value) map[string]reflect.Value {
+	instrumented := make(map[string]reflect.Value, len(symbols))
+	for name, fn := range symbols {
+		if fn.Kind() != reflect.Func {
+			instrumented[name] = fn
+			continue
+		}
+		instrumented[name] = r.wrap(name, fn)
+	}
+	return instrumented
+}
+
+// wrap returns a function with fn's signature that records each call
+func (r *traceRecorder) wrap(name string, fn reflect.Value) reflect.Value {
+	variadic := fn.Type().IsVariadic()
+	return reflect.MakeFunc(fn.Type(), func(args []reflect.Value) []reflect.Value {
+		var results []reflect.Value
+		if variadic {
+			results = fn.CallSlice(args)
+		} else {
+			results = fn.Call(args)
+		}
+
+		call := TraceCall{Helper: name, Params: make([]interface{}, len(args))}
+		for idx, arg := range args {
+			call.Params[idx] = traceValue(arg)
+		}
+		switch len(results) {
+		case 0:
+		case 1:
+			call.Result = traceValue(results[0])
+		default:
+			values := make([]interface{}, len(results))
+			for idx, result := range results {
+				values[idx] = traceValue(result)
+			}
+			call.Result = values
+		}
+		r.record(call)
+
+		return results
+	})
+}
+
+// traceSlice summarizes a long slice by its length and trailing values
+type traceSlice struct {
+	Len  int           `json:"len"`
+	Last []interface{} `json:"last,omitempty"`
+}
+
+// traceValue converts a helper argument or result into a JSON-friendly value
+// Klines are summarized by count, long slices by their trailing values
+func traceValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return traceValue(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem() == reflect.TypeOf(types.Kline{}) {
+			return traceSlice{Len: v.Len()}
+		}
+		if v.Len() <= maxTraceSliceValues {
+			return v.Interface()
+		}
+		summary := traceSlice{Len: v.Len()}
+		for idx := v.Len() - maxTraceSliceValues; idx < v.Len(); idx++ {
+			summary.Last = append(summary.Last, traceValue(v.Index(idx)))
+		}
+		return summary
+	}
+	if !v.CanInterface() {
+		return v.String()
+	}
+	return v.Interface()
+}
+
+// limitedBuffer captures output up to a size limit and drops the rest
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *limitedBuffer) Reset() {
+	b.mu.Lock()
+	b.buf.Reset()
+	b.mu.Unlock()
+}
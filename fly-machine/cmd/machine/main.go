@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/vyx/go-screener/pkg/yaegi"
 	"github.com/yourusername/trader-machine/internal/binance"
 	"github.com/yourusername/trader-machine/internal/config"
 	"github.com/yourusername/trader-machine/internal/database"
@@ -35,6 +36,11 @@ func main() {
 	// 2. Setup logger with RFC3339 timestamps
 	logger.Setup(cfg.LogLevel)
 
+	// Restrict the packages signal code may import
+	if err := executor.SetSandboxPolicy(yaegi.NewSandboxPolicy(cfg.SandboxAllowedImports)); err != nil {
+		log.Fatal().Err(err).Msg("Failed to create strategy runtime")
+	}
+
 	log.Info().
 		Str("version", version).
 		Str("user_id", cfg.UserID).
@@ -116,7 +122,16 @@ func main() {
 	for i := range traders {
 		trader := &traders[i]
 
-		signalExecutor, err := executor.NewSignalExecutor(trader.ID, trader.SignalCode)
+		code, err := trader.FilterCode()
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("trader_id", trader.ID).
+				Msg("Failed to read trader filter")
+			continue
+		}
+
+		signalExecutor, err := executor.NewSignalExecutor(trader.ID, code)
 		if err != nil {
 			log.Error().
 				Err(err).
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
+	"github.com/vyx/go-screener/pkg/yaegi"
 	"github.com/yourusername/trader-machine/internal/types"
 )
 
@@ -26,6 +28,8 @@ func Load() (*types.Config, error) {
 		PaperTradingOnly: getEnvBool("PAPER_TRADING_ONLY", true),
 		BinanceAPIKey:    os.Getenv("BINANCE_API_KEY"),
 		BinanceSecretKey: os.Getenv("BINANCE_SECRET_KEY"),
+
+		SandboxAllowedImports: getEnvList("SANDBOX_ALLOWED_IMPORTS", append([]string(nil), yaegi.DefaultAllowedImports...)),
 	}
 
 	// Validate required fields
@@ -76,3 +80,18 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvList returns a comma-separated environment variable as a list or default
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
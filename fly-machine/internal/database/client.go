@@ -54,7 +54,7 @@ func (c *Client) Close() {
 // GetActiveTraders retrieves all active traders for a user
 func (c *Client) GetActiveTraders(ctx context.Context, userID string) ([]types.Trader, error) {
 	query := `
-		SELECT id, user_id, name, description, signal_code, ai_instructions,
+		SELECT id, user_id, name, description, filter, ai_instructions,
 		       timeframes, check_interval, reanalysis_interval, symbols,
 		       status, error_message, created_at, updated_at
 		FROM traders
@@ -72,7 +72,7 @@ func (c *Client) GetActiveTraders(ctx context.Context, userID string) ([]types.T
 	for rows.Next() {
 		var t types.Trader
 		err := rows.Scan(
-			&t.ID, &t.UserID, &t.Name, &t.Description, &t.SignalCode,
+			&t.ID, &t.UserID, &t.Name, &t.Description, &t.Filter,
 			&t.AIInstructions, &t.Timeframes, &t.CheckInterval,
 			&t.ReanalysisInterval, &t.Symbols, &t.Status, &t.ErrorMessage,
 			&t.CreatedAt, &t.UpdatedAt,
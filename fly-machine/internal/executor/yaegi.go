@@ -1,104 +1,130 @@
 package executor
 
 import (
+	"context"
 	"fmt"
-	"reflect"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
-	"github.com/traefik/yaegi/interp"
-	"github.com/traefik/yaegi/stdlib"
-	"github.com/yourusername/trader-machine/internal/indicators"
+	"github.com/vyx/go-screener/pkg/yaegi"
 )
 
-// SignalExecutor executes user-generated signal code using Yaegi
+// signalTimeout bounds a single signal check
+const signalTimeout = time.Second
+
+var (
+	sharedExecutor   *yaegi.Executor
+	sharedExecutorMu sync.Mutex
+
+	// filterStates holds the per-symbol state of every trader's filter (in memory)
+	filterStates = yaegi.NewStateStore(0, 0)
+)
+
+// SetSandboxPolicy replaces the shared runtime with one using the given policy
+// Signal executors created afterwards compile against it
+func SetSandboxPolicy(policy *yaegi.SandboxPolicy) error {
+	exec, err := yaegi.NewExecutorWithPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	sharedExecutorMu.Lock()
+	defer sharedExecutorMu.Unlock()
+	sharedExecutor = exec
+	return nil
+}
+
+// sharedRuntime returns the shared strategy runtime, creating it with the default policy
+func sharedRuntime() (*yaegi.Executor, error) {
+	sharedExecutorMu.Lock()
+	defer sharedExecutorMu.Unlock()
+
+	if sharedExecutor == nil {
+		exec, err := yaegi.NewExecutor()
+		if err != nil {
+			return nil, err
+		}
+		sharedExecutor = exec
+	}
+	return sharedExecutor, nil
+}
+
+// SignalExecutor runs a trader's filter code on the shared go-screener runtime
+// Traders use the same code contract as the screener backend, so a trader runs
+// identically in either deployment; older checkSignal programs run on raw data
 type SignalExecutor struct {
-	interpreter *interp.Interpreter
-	traderID    string
-	code        string
+	mu       sync.RWMutex
+	runtime  *yaegi.Executor
+	traderID string
+	code     string
 }
 
-// NewSignalExecutor creates a new signal executor
+// NewSignalExecutor compiles the trader's filter code
 func NewSignalExecutor(traderID, code string) (*SignalExecutor, error) {
-	i := interp.New(interp.Options{})
-
-	// Import standard library
-	i.Use(stdlib.Symbols)
-
-	// Import indicator helpers
-	i.Use(interp.Exports{
-		"github.com/yourusername/trader-machine/internal/indicators/indicators": map[string]reflect.Value{
-			"GetCloses":                    reflect.ValueOf(indicators.GetCloses),
-			"GetOpens":                     reflect.ValueOf(indicators.GetOpens),
-			"GetHighs":                     reflect.ValueOf(indicators.GetHighs),
-			"GetLows":                      reflect.ValueOf(indicators.GetLows),
-			"GetVolumes":                   reflect.ValueOf(indicators.GetVolumes),
-			"GetLatestSMA":                 reflect.ValueOf(indicators.GetLatestSMA),
-			"GetLatestEMA":                 reflect.ValueOf(indicators.GetLatestEMA),
-			"GetLatestWMA":                 reflect.ValueOf(indicators.GetLatestWMA),
-			"GetLatestVWAP":                reflect.ValueOf(indicators.GetLatestVWAP),
-			"GetLatestRSI":                 reflect.ValueOf(indicators.GetLatestRSI),
-			"GetLatestMACD":                reflect.ValueOf(indicators.GetLatestMACD),
-			"GetLatestStochastic":          reflect.ValueOf(indicators.GetLatestStochastic),
-			"GetLatestCCI":                 reflect.ValueOf(indicators.GetLatestCCI),
-			"GetLatestWilliamsR":           reflect.ValueOf(indicators.GetLatestWilliamsR),
-			"GetLatestROC":                 reflect.ValueOf(indicators.GetLatestROC),
-			"GetLatestBollingerBands":      reflect.ValueOf(indicators.GetLatestBollingerBands),
-			"GetLatestATR":                 reflect.ValueOf(indicators.GetLatestATR),
-			"GetLatestKeltnerChannels":     reflect.ValueOf(indicators.GetLatestKeltnerChannels),
-			"GetLatestDonchianChannels":    reflect.ValueOf(indicators.GetLatestDonchianChannels),
-			"GetLatestVolume":              reflect.ValueOf(indicators.GetLatestVolume),
-			"GetLatestVolumeChange":        reflect.ValueOf(indicators.GetLatestVolumeChange),
-			"GetLatestOBV":                 reflect.ValueOf(indicators.GetLatestOBV),
-			"GetLatestVolumeMA":            reflect.ValueOf(indicators.GetLatestVolumeMA),
-			"GetLatestADX":                 reflect.ValueOf(indicators.GetLatestADX),
-			"GetLatestAroon":               reflect.ValueOf(indicators.GetLatestAroon),
-			"GetPriceChange":               reflect.ValueOf(indicators.GetPriceChange),
-			"GetPriceChangePercent":        reflect.ValueOf(indicators.GetPriceChangePercent),
-			"GetHighestHigh":               reflect.ValueOf(indicators.GetHighestHigh),
-			"GetLowestLow":                 reflect.ValueOf(indicators.GetLowestLow),
-		},
-	})
-
-	// Compile user code (5-20ms)
+	exec, err := sharedRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := compileSignal(exec, traderID, code); err != nil {
+		return nil, err
+	}
+
+	return &SignalExecutor{
+		runtime:  exec,
+		traderID: traderID,
+		code:     code,
+	}, nil
+}
+
+// compileSignal compiles filter code into the runtime's cache
+func compileSignal(exec *yaegi.Executor, traderID, code string) error {
+	// Code written against the old checkSignal contract still runs on the legacy helpers
+	if yaegi.IsLegacySignal(code) {
+		log.Warn().
+			Str("trader_id", traderID).
+			Msg("Signal code uses the deprecated checkSignal contract")
+	}
+
 	log.Debug().
 		Str("trader_id", traderID).
 		Msg("Compiling signal code")
 
-	_, err := i.Eval(code)
-	if err != nil {
+	if _, err := exec.CompileFilter(code); err != nil {
 		log.Error().
 			Err(err).
 			Str("trader_id", traderID).
 			Msg("Failed to compile signal code")
-		return nil, fmt.Errorf("yaegi compilation failed: %w", err)
+		return err
 	}
 
 	log.Info().
 		Str("trader_id", traderID).
 		Msg("Signal code compiled successfully")
 
-	return &SignalExecutor{
-		interpreter: i,
-		traderID:    traderID,
-		code:        code,
-	}, nil
+	return nil
 }
 
-// CheckSignal executes the user's checkSignal function
+// CheckSignal evaluates the trader's filter against raw Binance data for a symbol
 func (se *SignalExecutor) CheckSignal(symbol string, ticker map[string]interface{}, klines map[string][][]interface{}) (bool, error) {
-	// Build function call
-	callStr := fmt.Sprintf(`checkSignal("%s", ticker, klines)`, symbol)
-
-	// Set variables in interpreter scope
-	se.interpreter.Use(interp.Exports{
-		"main/main": map[string]reflect.Value{
-			"ticker": reflect.ValueOf(ticker),
-			"klines": reflect.ValueOf(klines),
-		},
-	})
-
-	// Execute
-	v, err := se.interpreter.Eval(callStr)
+	se.mu.RLock()
+	code := se.code
+	se.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), signalTimeout)
+	defer cancel()
+
+	var (
+		result bool
+		err    error
+	)
+	if yaegi.IsLegacySignal(code) {
+		// checkSignal programs read the raw data as is
+		result, err = se.runtime.ExecuteLegacySignalWithContext(ctx, code, symbol, ticker, klines)
+	} else {
+		result, err = se.checkFilter(ctx, code, symbol, ticker, klines)
+	}
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -108,9 +134,6 @@ func (se *SignalExecutor) CheckSignal(symbol string, ticker map[string]interface
 		return false, fmt.Errorf("execution failed: %w", err)
 	}
 
-	// Extract boolean result
-	result := v.Bool()
-
 	log.Debug().
 		Str("trader_id", se.traderID).
 		Str("symbol", symbol).
@@ -120,6 +143,17 @@ func (se *SignalExecutor) CheckSignal(symbol string, ticker map[string]interface
 	return result, nil
 }
 
+// checkFilter evaluates filter code on the raw data converted to MarketData
+func (se *SignalExecutor) checkFilter(ctx context.Context, code, symbol string, ticker map[string]interface{}, klines map[string][][]interface{}) (bool, error) {
+	data, err := yaegi.MarketDataFromRaw(symbol, ticker, klines)
+	if err != nil {
+		return false, err
+	}
+	data.State = filterStates.Get(se.traderID, symbol)
+
+	return se.runtime.ExecuteFilterWithContext(ctx, code, data)
+}
+
 // GetTraderID returns the trader ID
 func (se *SignalExecutor) GetTraderID() string {
 	return se.traderID
@@ -127,13 +161,18 @@ func (se *SignalExecutor) GetTraderID() string {
 
 // Reload recompiles the signal code
 func (se *SignalExecutor) Reload(code string) error {
-	newExecutor, err := NewSignalExecutor(se.traderID, code)
-	if err != nil {
+	if err := compileSignal(se.runtime, se.traderID, code); err != nil {
 		return err
 	}
 
-	se.interpreter = newExecutor.interpreter
+	se.mu.Lock()
+	oldCode := se.code
 	se.code = code
+	se.mu.Unlock()
+
+	if oldCode != code {
+		se.runtime.InvalidateFilter(oldCode)
+	}
 
 	log.Info().
 		Str("trader_id", se.traderID).
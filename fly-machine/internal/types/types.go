@@ -1,25 +1,38 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
+
+	screenertypes "github.com/vyx/go-screener/pkg/types"
 )
 
 // Trader represents a trading strategy configuration
 type Trader struct {
-	ID                  string   `json:"id"`
-	UserID              string   `json:"user_id"`
-	Name                string   `json:"name"`
-	Description         string   `json:"description"`
-	SignalCode          string   `json:"signal_code"`
-	AIInstructions      string   `json:"ai_instructions"`
-	Timeframes          []string `json:"timeframes"`
-	CheckInterval       string   `json:"check_interval"`
-	ReanalysisInterval  string   `json:"reanalysis_interval"`
-	Symbols             []string `json:"symbols"`
-	Status              string   `json:"status"`
-	ErrorMessage        string   `json:"error_message"`
-	CreatedAt           time.Time
-	UpdatedAt           time.Time
+	ID                 string          `json:"id"`
+	UserID             string          `json:"user_id"`
+	Name               string          `json:"name"`
+	Description        string          `json:"description"`
+	Filter             json.RawMessage `json:"filter"` // Same filter JSON go-screener loads
+	AIInstructions     string          `json:"ai_instructions"`
+	Timeframes         []string        `json:"timeframes"`
+	CheckInterval      string          `json:"check_interval"`
+	ReanalysisInterval string          `json:"reanalysis_interval"`
+	Symbols            []string        `json:"symbols"`
+	Status             string          `json:"status"`
+	ErrorMessage       string          `json:"error_message"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+// FilterCode returns the code of the trader's filter, decoded the way go-screener does
+func (t *Trader) FilterCode() (string, error) {
+	filter, err := (&screenertypes.Trader{Filter: t.Filter}).GetFilter()
+	if err != nil {
+		return "", fmt.Errorf("invalid filter for trader %s: %w", t.ID, err)
+	}
+	return filter.Code, nil
 }
 
 // Signal represents a triggered trading signal
@@ -152,4 +165,7 @@ type Config struct {
 	PaperTradingOnly  bool
 	BinanceAPIKey     string
 	BinanceSecretKey  string
+
+	// Standard library packages signal code may import
+	SandboxAllowedImports []string
 }
@@ -0,0 +1,15 @@
+-- Add metadata column to signals table for storing rich filter results
+-- Filters may return a score, tags and arbitrary values along with the match
+
+-- Add metadata column
+ALTER TABLE signals ADD COLUMN metadata JSONB;
+
+-- Create GIN index for efficient JSONB queries (e.g. filtering by tag)
+CREATE INDEX idx_signals_metadata ON signals USING gin(metadata);
+
+-- Add column comment for documentation
+COMMENT ON COLUMN signals.metadata IS
+'Rich filter result attached to the signal.
+Format: {"score": number, "tags": [string], ...custom key/values}
+Example: {"score": 0.85, "tags": ["oversold", "volume-spike"], "rsi": 24.5}
+NULL for plain bool filters and signals created before this feature.';
@@ -0,0 +1,44 @@
+-- Migration: Create trader_symbol_state table for stateful filters
+-- Description: Persist the per-trader, per-symbol key/value state filters read and
+-- write through data.State, so it survives backend restarts (FILTER_STATE_PERSIST=true)
+
+CREATE TABLE trader_symbol_state (
+  trader_id UUID NOT NULL REFERENCES traders(id) ON DELETE CASCADE,
+  symbol TEXT NOT NULL,
+  state JSONB NOT NULL DEFAULT '{}'::jsonb,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (trader_id, symbol)
+);
+
+-- Keep updated_at current on upserts
+CREATE OR REPLACE FUNCTION update_trader_symbol_state_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+  NEW.updated_at = NOW();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trader_symbol_state_updated_at
+  BEFORE UPDATE ON trader_symbol_state
+  FOR EACH ROW
+  EXECUTE FUNCTION update_trader_symbol_state_updated_at();
+
+-- RLS Policies (the backend uses the service role and bypasses RLS)
+ALTER TABLE trader_symbol_state ENABLE ROW LEVEL SECURITY;
+
+-- Users can view the state of their own traders
+CREATE POLICY "Users can view own trader state"
+  ON trader_symbol_state FOR SELECT
+  TO authenticated
+  USING (
+    EXISTS (
+      SELECT 1 FROM traders
+      WHERE traders.id = trader_symbol_state.trader_id AND traders.user_id = auth.uid()
+    )
+  );
+
+COMMENT ON TABLE trader_symbol_state IS
+'Key/value state of a trader filter for one symbol, written by filters via data.State.
+Format: {"key": JSON value}, limited to FILTER_STATE_MAX_KEYS keys and FILTER_STATE_MAX_BYTES bytes.';